@@ -1,27 +1,103 @@
 package api
 
 import (
+	"fmt"
 	"io"
 	"strings"
 	//"bytes"
 	// "encoding/json"
 	// "errors"
-	// "fmt"
 	"net/http"
+	"net/url"
 	"path"
 	"strconv"
+	"time"
 	// "strings"
 
 	// "github.com/rjansen/fivecolors/config"
 	"github.com/rjansen/fivecolors/data"
+	"github.com/rjansen/fivecolors/security"
 	haki "github.com/rjansen/haki/http"
-	// "github.com/rjansen/fivecolors/security"
 	"github.com/rjansen/l"
 	"github.com/rjansen/raizel"
 	// "github.com/rjansen/avalon/identity"
 	// "github.com/valyala/fasthttp"
 )
 
+//requestInventory returns the inventory whose quantities are reported to the caller: the inventory
+//query parameter when it belongs to the session player, otherwise the player default inventory.
+//Anonymous requests keep the legacy shared inventory 0
+func requestInventory(r *http.Request) (int, error) {
+	player, found := security.FromContext(r.Context())
+	if !found {
+		return 0, nil
+	}
+	idParameter := r.URL.Query().Get("inventory")
+	if idParameter == "" {
+		return player.IDInventory, nil
+	}
+	inventory := data.Inventory{}
+	var err error
+	if inventory.ID, err = strconv.Atoi(idParameter); err != nil {
+		return 0, raizel.ErrNotFound
+	}
+	if err = raizel.Execute(inventory.ReadByID); err != nil {
+		return 0, err
+	}
+	if inventory.IDPlayer != player.ID {
+		return 0, raizel.ErrNotFound
+	}
+	return inventory.ID, nil
+}
+
+//queryErr writes the invalid search and order errors as 400 with the error details, the other errors as haki.Err
+func queryErr(w http.ResponseWriter, err error) error {
+	switch invalidErr := err.(type) {
+	case *data.SearchError:
+		l.Info("api.InvalidSearch", l.Err(err))
+		return haki.JSON(w, http.StatusBadRequest, invalidErr)
+	case *data.SortError:
+		l.Info("api.InvalidOrder", l.Err(err))
+		return haki.JSON(w, http.StatusBadRequest, invalidErr)
+	}
+	switch err {
+	case data.ErrInvalidCursor, data.ErrInvalidInventoryCard, data.ErrInvalidInventoryQtd:
+		l.Info("api.InvalidQuery", l.Err(err))
+		return haki.Status(w, http.StatusBadRequest)
+	}
+	return haki.Err(w, err)
+}
+
+//QueryPage is the response envelope of the query endpoints
+type QueryPage struct {
+	Items      interface{} `json:"items"`
+	NextCursor string      `json:"nextCursor,omitempty"`
+	Total      *int        `json:"total,omitempty"`
+}
+
+func newQueryPage(items interface{}, query data.Query) QueryPage {
+	page := QueryPage{Items: items, NextCursor: query.NextCursor}
+	if query.WithTotal {
+		page.Total = &query.Total
+	}
+	return page
+}
+
+//readPage reads the limit, cursor and total query parameters. The limit defaults to 100 and is bounded to data.MaxPageSize
+func readPage(queryParameters url.Values, query *data.Query) bool {
+	query.Cursor = queryParameters.Get("cursor")
+	query.WithTotal = queryParameters.Get("total") == "true"
+	query.Limit = data.PageSize(0)
+	if limitParameter := queryParameters.Get("limit"); limitParameter != "" {
+		limit, err := strconv.Atoi(limitParameter)
+		if err != nil || limit <= 0 {
+			return false
+		}
+		query.Limit = data.PageSize(limit)
+	}
+	return true
+}
+
 //NewAnonPlayerHandler creates a new unauthorized playerHandler instance
 func NewAnonPlayerHandler() http.HandlerFunc {
 	var playerHandler PlayerHandler
@@ -82,8 +158,11 @@ func (h CardHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) error {
 		l.String("LastPath", lastPath),
 	)
 	if r.Method == "GET" {
-		if lastPath == "" {
+		switch lastPath {
+		case "":
 			return h.Query(w, r)
+		case "prices":
+			return h.Prices(w, r)
 		}
 		return h.Read(w, r)
 	}
@@ -97,6 +176,9 @@ func (h CardHandler) Read(w http.ResponseWriter, r *http.Request) error {
 	)
 	var card data.Card
 	var err error
+	if card.InventoryCard.IDInventory, err = requestInventory(r); err != nil {
+		return haki.Status(w, http.StatusNotFound)
+	}
 	if id, atoirErr := strconv.Atoi(readParameter); atoirErr == nil {
 		card.ID = id
 		err = raizel.Execute(card.ReadByID)
@@ -117,19 +199,55 @@ func (h CardHandler) Read(w http.ResponseWriter, r *http.Request) error {
 	return haki.JSON(w, http.StatusOK, card)
 }
 
-func (h CardHandler) Query(w http.ResponseWriter, r *http.Request) error {
+//Prices returns the price history by finish of the card of the /{id}/prices path. The from and to query parameters,
+//in the data.PriceDateLayout, default to one year ago and today, the finish query parameter restricts the finishes
+func (h CardHandler) Prices(w http.ResponseWriter, r *http.Request) error {
+	readParameter := path.Base(path.Dir(r.URL.Path))
 	queryParameters := r.URL.Query()
-	l.Info("CardHandler.Query",
+	l.Info("CardHandler.Prices",
+		l.String("ReadParameter", readParameter),
 		l.Struct("QueryParameters", queryParameters),
 	)
-	if len(queryParameters) <= 0 {
+	var (
+		card     data.Card
+		prices   data.CardPrices
+		from, to time.Time
+		finish   string
+		err      error
+	)
+	if card.ID, err = strconv.Atoi(readParameter); err != nil {
 		return haki.Status(w, http.StatusBadRequest)
 	}
+	for name, target := range map[string]*time.Time{"from": &from, "to": &to} {
+		if parameter := queryParameters.Get(name); parameter != "" {
+			if *target, err = time.Parse(data.PriceDateLayout, parameter); err != nil {
+				return haki.Status(w, http.StatusBadRequest)
+			}
+		}
+	}
+	if parameter := queryParameters.Get("finish"); parameter != "" {
+		var valid bool
+		if finish, valid = data.ParseFinish(parameter); !valid {
+			return haki.Status(w, http.StatusBadRequest)
+		}
+	}
+	err = raizel.Execute(func(client raizel.Client) error {
+		var pricesErr error
+		prices, pricesErr = card.ReadPrices(client, from, to, finish)
+		return pricesErr
+	})
+	if err != nil {
+		if err == raizel.ErrNotFound {
+			return haki.Status(w, http.StatusNotFound)
+		}
+		return haki.Err(w, err)
+	}
+	return haki.JSON(w, http.StatusOK, prices)
+}
 
-	var (
-		card      data.Card
-		cardQuery data.CardQuery
-	)
+//newCardQuery reads the card filters shared by the card and inventory queries
+func newCardQuery(queryParameters url.Values) data.CardQuery {
+	var cardQuery data.CardQuery
 	cardQuery.Hydrate = queryParameters.Get("hydrate")
 	cardQuery.IDExpansion = queryParameters.Get("e")
 	cardQuery.Number = queryParameters.Get("n")
@@ -140,23 +258,50 @@ func (h CardHandler) Query(w http.ResponseWriter, r *http.Request) error {
 	cardQuery.NotRegexType = queryParameters.Get("nrx_type")
 	cardQuery.NotRegexCost = queryParameters.Get("nrx_cost")
 	cardQuery.NotRegexText = queryParameters.Get("nrx_text")
-	cardQuery.InventoryQtd = queryParameters.Get("q")
+	cardQuery.Finish = queryParameters.Get("finish")
+	cardQuery.Condition = queryParameters.Get("condition")
+	cardQuery.Language = queryParameters.Get("language")
+	cardQuery.Graded = queryParameters.Get("graded")
+	cardQuery.FullText = queryParameters.Get("fts")
+	//qtd is the minimum inventory quantity, q is always the card search language
+	cardQuery.InventoryQtd = queryParameters.Get("qtd")
+	cardQuery.Search = queryParameters.Get("q")
 	cardQuery.Order = queryParameters.Get("order")
+	return cardQuery
+}
+
+func (h CardHandler) Query(w http.ResponseWriter, r *http.Request) error {
+	queryParameters := r.URL.Query()
+	l.Info("CardHandler.Query",
+		l.Struct("QueryParameters", queryParameters),
+	)
+	if len(queryParameters) <= 0 {
+		return haki.Status(w, http.StatusBadRequest)
+	}
 
-	err := raizel.ExecuteWith(card.Query, &cardQuery)
+	var card data.Card
+	cardQuery := newCardQuery(queryParameters)
+	if !readPage(queryParameters, &cardQuery.Query) {
+		return haki.Status(w, http.StatusBadRequest)
+	}
+	var err error
+	if cardQuery.IDInventory, err = requestInventory(r); err != nil {
+		return haki.Status(w, http.StatusNotFound)
+	}
+	err = raizel.ExecuteWith(card.Query, &cardQuery)
 	if err != nil {
 		l.Error("CardHandler.QueryErr",
 			l.Struct("QueryParameters", queryParameters),
 			l.Err(err),
 		)
-		return haki.Err(w, err)
+		return queryErr(w, err)
 	}
 	cardsSize := len(cardQuery.Result)
 	l.Debug("CardHandler.QueryResult",
 		l.Int("Cards.Len", cardsSize),
 		l.String("Hydrate", cardQuery.Hydrate),
 	)
-	return haki.JSON(w, http.StatusOK, cardQuery.Result)
+	return haki.JSON(w, http.StatusOK, newQueryPage(cardQuery.Result, cardQuery.Query))
 }
 
 func NewAnonTokenHandler() http.HandlerFunc {
@@ -226,6 +371,9 @@ func (h TokenHandler) Query(w http.ResponseWriter, r *http.Request) error {
 	tokenQuery.NotRegexType = queryParameters.Get("nrx_type")
 	tokenQuery.IDExpansion = queryParameters.Get("e")
 	tokenQuery.Order = queryParameters.Get("order")
+	if !readPage(queryParameters, &tokenQuery.Query) {
+		return haki.Status(w, http.StatusBadRequest)
+	}
 
 	err := raizel.ExecuteWith(token.Query, &tokenQuery)
 	if err != nil {
@@ -233,14 +381,14 @@ func (h TokenHandler) Query(w http.ResponseWriter, r *http.Request) error {
 			l.Struct("QueryParameters", queryParameters),
 			l.Err(err),
 		)
-		return haki.Err(w, err)
+		return queryErr(w, err)
 	}
 	tokensSize := len(tokenQuery.Result)
 	l.Debug("TokenHandler.QueryResult",
 		l.Int("Tokens.Len", tokensSize),
 		l.String("Hydrate", tokenQuery.Hydrate),
 	)
-	return haki.JSON(w, http.StatusOK, tokenQuery.Result)
+	return haki.JSON(w, http.StatusOK, newQueryPage(tokenQuery.Result, tokenQuery.Query))
 }
 
 //NewAnonDeckHandler creates a new unauthorized deckHandler instance
@@ -264,27 +412,83 @@ func (h DeckHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) error {
 		if lastPath == "" {
 			return h.Query(w, r)
 		}
+		switch lastPath {
+		case "legality":
+			return h.Legality(w, r)
+		case "export":
+			return h.Export(w, r)
+		case "stats":
+			return h.Stats(w, r)
+		case "simulate":
+			return h.Simulate(w, r)
+		case "revisions":
+			return h.Revisions(w, r)
+		case "diff":
+			return h.Diff(w, r)
+		case "missing":
+			return h.Missing(w, r)
+		case "value":
+			return h.Value(w, r)
+		}
 		return h.Read(w, r)
 	case "POST":
+		switch lastPath {
+		case "import":
+			return h.Import(w, r)
+		case "restore":
+			return h.Restore(w, r)
+		case "reservations":
+			return h.Reserve(w, r)
+		}
 		return h.Persist(w, r)
 	case "DELETE":
+		if lastPath == "reservations" {
+			return h.Release(w, r)
+		}
 		return h.Delete(w, r)
 	}
 	return haki.Status(w, http.StatusMethodNotAllowed)
 }
 
+//Persist creates or updates a deck of the session player, the deck of another player is not found
 func (h DeckHandler) Persist(w http.ResponseWriter, r *http.Request) error {
 	queryParameters := r.URL.Query()
 	l.Info("DeckHandler.Persist",
 		l.Struct("QueryParameters", queryParameters),
 	)
+	player, found := security.FromContext(r.Context())
+	if !found {
+		return haki.Status(w, http.StatusUnauthorized)
+	}
 	var err error
 	var deck data.Deck
 	if err = haki.ReadJSON(r, &deck); err != nil {
 		return haki.Err(w, err)
 	}
+	//The deck always belongs to the session player, never to the player of the request body
+	deck.IDPlayer = player.ID
+	if formatName := queryParameters.Get("format"); formatName != "" {
+		format, formatErr := data.GetFormat(formatName)
+		if formatErr != nil {
+			l.Info("DeckHandler.Persist.InvalidFormat", l.String("Format", formatName))
+			return haki.Status(w, http.StatusBadRequest)
+		}
+		if err = raizel.Execute(deck.ReadCardAttributes); err != nil {
+			return legalityErr(w, err)
+		}
+		if legality := format.Validate(&deck); !legality.Legal {
+			l.Info("DeckHandler.Persist.IllegalDeck",
+				l.String("Format", format.Name),
+				l.Int("Violations.Len", len(legality.Violations)),
+			)
+			return haki.JSON(w, http.StatusBadRequest, legality)
+		}
+	}
 	isCreateRequest := deck.ID == 0
 	if err = raizel.Execute(deck.Persist); err != nil {
+		if err == raizel.ErrNotFound {
+			return haki.Status(w, http.StatusNotFound)
+		}
 		return haki.Err(w, err)
 	}
 	if isCreateRequest {
@@ -316,152 +520,1381 @@ func (h DeckHandler) Read(w http.ResponseWriter, r *http.Request) error {
 	return haki.JSON(w, http.StatusOK, deck)
 }
 
-func (h DeckHandler) Query(w http.ResponseWriter, r *http.Request) error {
+//Legality reads the deck of the /{id}/legality path and validates it against the formats.
+//legalityErr writes the deck cards unknown to the catalog as 400, the other errors as haki.Err
+func legalityErr(w http.ResponseWriter, err error) error {
+	if err == data.ErrUnknownDeckCard {
+		l.Info("api.UnknownDeckCard", l.Err(err))
+		return haki.Status(w, http.StatusBadRequest)
+	}
+	return haki.Err(w, err)
+}
+
+//The format query parameter restricts the verdict to one format
+func (h DeckHandler) Legality(w http.ResponseWriter, r *http.Request) error {
+	readParameter := path.Base(path.Dir(r.URL.Path))
+	formatName := r.URL.Query().Get("format")
+	l.Info("DeckHandler.Legality",
+		l.String("ReadParameter", readParameter),
+		l.String("Format", formatName),
+	)
+	var (
+		deck data.Deck
+		err  error
+	)
+	if deck.ID, err = strconv.Atoi(readParameter); err != nil {
+		return haki.Status(w, http.StatusBadRequest)
+	}
+	if err = raizel.Execute(deck.ReadByID); err != nil {
+		if err == raizel.ErrNotFound {
+			return haki.Status(w, http.StatusNotFound)
+		}
+		return legalityErr(w, err)
+	}
+	if formatName != "" {
+		format, formatErr := data.GetFormat(formatName)
+		if formatErr != nil {
+			return haki.Status(w, http.StatusBadRequest)
+		}
+		return haki.JSON(w, http.StatusOK, []data.Legality{format.Validate(&deck)})
+	}
+	return haki.JSON(w, http.StatusOK, deck.Legality())
+}
+
+//Stats reads the deck of the /{id}/stats path and returns the mana curve, color, type and board statistics
+func (h DeckHandler) Stats(w http.ResponseWriter, r *http.Request) error {
+	readParameter := path.Base(path.Dir(r.URL.Path))
+	l.Info("DeckHandler.Stats",
+		l.String("ReadParameter", readParameter),
+	)
+	var (
+		deck data.Deck
+		err  error
+	)
+	if deck.ID, err = strconv.Atoi(readParameter); err != nil {
+		return haki.Status(w, http.StatusBadRequest)
+	}
+	if err = raizel.Execute(deck.ReadByID); err != nil {
+		if err == raizel.ErrNotFound {
+			return haki.Status(w, http.StatusNotFound)
+		}
+		return haki.Err(w, err)
+	}
+	return haki.JSON(w, http.StatusOK, deck.Stats())
+}
+
+//Simulate reads the deck of the /{id}/simulate path and runs the Monte Carlo draws of the main board.
+//Without the seed query parameter a new seed is used, it is returned so the result can be reproduced
+func (h DeckHandler) Simulate(w http.ResponseWriter, r *http.Request) error {
+	readParameter := path.Base(path.Dir(r.URL.Path))
 	queryParameters := r.URL.Query()
-	l.Infof("DeckHandler.Query",
+	l.Info("DeckHandler.Simulate",
+		l.String("ReadParameter", readParameter),
 		l.Struct("QueryParameters", queryParameters),
 	)
 	var (
-		deck      data.Deck
-		deckQuery data.DeckQuery
+		deck    data.Deck
+		options data.SimulationOptions
+		err     error
 	)
-	deckQuery.RegexName = queryParameters.Get("rx_name")
-	err := raizel.ExecuteWith(deck.Query, &deckQuery)
-	if err != nil {
+	if deck.ID, err = strconv.Atoi(readParameter); err != nil {
+		return haki.Status(w, http.StatusBadRequest)
+	}
+	options.Seed = time.Now().UnixNano()
+	if seedParameter := queryParameters.Get("seed"); seedParameter != "" {
+		if options.Seed, err = strconv.ParseInt(seedParameter, 10, 64); err != nil {
+			return haki.Status(w, http.StatusBadRequest)
+		}
+	}
+	for name, target := range map[string]*int{
+		"iterations": &options.Iterations,
+		"turns":      &options.Turns,
+		"minLands":   &options.MinLands,
+		"maxLands":   &options.MaxLands,
+	} {
+		if parameter := queryParameters.Get(name); parameter != "" {
+			if *target, err = strconv.Atoi(parameter); err != nil {
+				return haki.Status(w, http.StatusBadRequest)
+			}
+		}
+	}
+	options.OnTheDraw = queryParameters.Get("draw") == "true"
+	if err = raizel.Execute(deck.ReadByID); err != nil {
+		if err == raizel.ErrNotFound {
+			return haki.Status(w, http.StatusNotFound)
+		}
 		return haki.Err(w, err)
 	}
-	return haki.JSON(w, http.StatusOK, deckQuery.Result)
+	simulation, err := deck.Simulate(options)
+	if err != nil {
+		l.Info("DeckHandler.Simulate.InvalidErr", l.Err(err))
+		return haki.Status(w, http.StatusBadRequest)
+	}
+	return haki.JSON(w, http.StatusOK, simulation)
 }
 
-func (h DeckHandler) Delete(w http.ResponseWriter, r *http.Request) error {
-	readParameter := path.Base(r.URL.Path)
-	l.Info("DeckHandler.Delete",
-		l.Struct("ReadParameter", readParameter),
+//Missing compares the deck of the /{id}/missing path with the session player inventory of the inventory query
+//parameter, or the player default inventory. Copies of other printings count unless exact=true
+func (h DeckHandler) Missing(w http.ResponseWriter, r *http.Request) error {
+	readParameter := path.Base(path.Dir(r.URL.Path))
+	queryParameters := r.URL.Query()
+	l.Info("DeckHandler.Missing",
+		l.String("ReadParameter", readParameter),
+		l.Struct("QueryParameters", queryParameters),
 	)
-	var deck data.Deck
-	var err error
-	deck.ID, err = strconv.Atoi(readParameter)
-	if err != nil {
-		l.Info("DeckHandler.Delete.InvalidRequest",
-			l.String("Parameter", readParameter),
-			l.Err(err),
-		)
+	if _, found := security.FromContext(r.Context()); !found {
+		return haki.Status(w, http.StatusUnauthorized)
+	}
+	var (
+		deck        data.Deck
+		idInventory int
+		err         error
+	)
+	if deck.ID, err = strconv.Atoi(readParameter); err != nil {
 		return haki.Status(w, http.StatusBadRequest)
 	}
-	err = raizel.Execute(deck.Delete)
+	if idInventory, err = requestInventory(r); err != nil {
+		return haki.Status(w, http.StatusNotFound)
+	}
+	var missing data.DeckMissing
+	err = raizel.Execute(func(client raizel.Client) error {
+		var missingErr error
+		missing, missingErr = deck.Missing(client, idInventory, queryParameters.Get("exact") == "true")
+		return missingErr
+	})
 	if err != nil {
+		if err == raizel.ErrNotFound {
+			return haki.Status(w, http.StatusNotFound)
+		}
 		return haki.Err(w, err)
 	}
-	return nil
-}
-
-//NewAnonExpansionHandler creates a new unauthorized expansionHandler instance
-func NewAnonExpansionHandler() http.HandlerFunc {
-	var expansionHandler ExpansionHandler
-	return haki.Handler(haki.Log(haki.Error(expansionHandler.ServeHTTP)))
+	return haki.JSON(w, http.StatusOK, missing)
 }
 
-type ExpansionHandler struct{}
-
-func (h ExpansionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) error {
-	basePath, lastPath := path.Split(r.URL.Path)
-	l.Debug("ExpansionHandler.ServeHTTP",
-		l.String("Method", r.Method),
-		l.String("Path", r.URL.Path),
-		l.String("BasePath", basePath),
-		l.String("LastPath", lastPath),
+//readValueOptions reads the date, from and interval query parameters, the dates use the data.PriceDateLayout
+func readValueOptions(queryParameters url.Values) (data.ValueOptions, bool) {
+	var (
+		options data.ValueOptions
+		err     error
 	)
-	if r.Method == "GET" {
-		if lastPath == "" {
-			return h.Query(w, r)
+	for name, target := range map[string]*time.Time{"date": &options.Date, "from": &options.From} {
+		if parameter := queryParameters.Get(name); parameter != "" {
+			if *target, err = time.Parse(data.PriceDateLayout, parameter); err != nil {
+				return options, false
+			}
 		}
-		return h.Read(w, r)
 	}
-	return haki.Status(w, http.StatusMethodNotAllowed)
+	options.Interval = queryParameters.Get("interval")
+	return options, true
 }
 
-func (h ExpansionHandler) Read(w http.ResponseWriter, r *http.Request) error {
-	readParameter := path.Base(r.URL.Path)
-	l.Info("ExpansionHandler.Read",
-		l.Struct("ReadParameter", readParameter),
+//Value returns the value of the cards of the deck of the /{id}/value path with the nonfoil prices on the date
+//query parameter, today by default. With the from and interval (day, week or month) query parameters the
+//value history is returned too
+func (h DeckHandler) Value(w http.ResponseWriter, r *http.Request) error {
+	readParameter := path.Base(path.Dir(r.URL.Path))
+	queryParameters := r.URL.Query()
+	l.Info("DeckHandler.Value",
+		l.String("ReadParameter", readParameter),
+		l.Struct("QueryParameters", queryParameters),
 	)
 	var (
-		expansion data.Expansion
-		err       error
+		deck  data.Deck
+		value data.CollectionValue
+		err   error
 	)
-	if id, atoirErr := strconv.Atoi(readParameter); atoirErr == nil {
-		expansion.ID = id
-		err = raizel.Execute(expansion.ReadByID)
-	} else {
-		expansion.Name = readParameter
-		err = raizel.Execute(expansion.ReadByName)
+	if deck.ID, err = strconv.Atoi(readParameter); err != nil {
+		return haki.Status(w, http.StatusBadRequest)
+	}
+	options, valid := readValueOptions(queryParameters)
+	if !valid {
+		return haki.Status(w, http.StatusBadRequest)
 	}
+	err = raizel.Execute(func(client raizel.Client) error {
+		var valueErr error
+		value, valueErr = deck.Value(client, options)
+		return valueErr
+	})
 	if err != nil {
-		return haki.Status(w, http.StatusNotFound)
+		switch err {
+		case raizel.ErrNotFound:
+			return haki.Status(w, http.StatusNotFound)
+		case data.ErrInvalidValueHistory:
+			return haki.Status(w, http.StatusBadRequest)
+		}
+		return haki.Err(w, err)
 	}
-	return haki.JSON(w, http.StatusOK, expansion)
+	return haki.JSON(w, http.StatusOK, value)
 }
 
-func (h ExpansionHandler) Query(w http.ResponseWriter, r *http.Request) error {
-	queryParameters := r.URL.Query()
-	l.Info("ExpansionHandler.Query",
-		l.Struct("QueryParameters", queryParameters),
+//Reserve commits the copies of the session player inventory, the inventory query parameter or the player default
+//inventory, to the cards of the session player deck of the /{id}/reservations path. The response has the over
+//commitment warnings, the deck of another player is not found
+func (h DeckHandler) Reserve(w http.ResponseWriter, r *http.Request) error {
+	readParameter := path.Base(path.Dir(r.URL.Path))
+	l.Info("DeckHandler.Reserve",
+		l.String("ReadParameter", readParameter),
 	)
+	player, found := security.FromContext(r.Context())
+	if !found {
+		return haki.Status(w, http.StatusUnauthorized)
+	}
 	var (
-		expansion    data.Expansion
-		queryBuilder data.ExpansionQuery
+		deck      = data.Deck{IDPlayer: player.ID}
+		inventory data.Inventory
+		err       error
 	)
-	queryBuilder.Hydrate = queryParameters.Get("hydrate")
-	queryBuilder.RegexName = queryParameters.Get("rx_name")
-	queryBuilder.Order = queryParameters.Get("order")
-	err := raizel.ExecuteWith(expansion.Query, &queryBuilder)
+	if deck.ID, err = strconv.Atoi(readParameter); err != nil {
+		return haki.Status(w, http.StatusBadRequest)
+	}
+	if inventory.ID, err = requestInventory(r); err != nil || inventory.ID <= 0 {
+		return haki.Status(w, http.StatusNotFound)
+	}
+	var reservations data.InventoryReservations
+	err = raizel.Execute(func(client raizel.Client) error {
+		if err := deck.Reserve(client, inventory.ID); err != nil {
+			return err
+		}
+		var readErr error
+		reservations, readErr = inventory.ReadReservations(client)
+		return readErr
+	})
 	if err != nil {
+		if err == raizel.ErrNotFound {
+			return haki.Status(w, http.StatusNotFound)
+		}
 		return haki.Err(w, err)
 	}
-	expansionSize := len(queryBuilder.Result)
-	l.Debug("ExpansionHandler.QueryResult",
-		l.Int("Expansions.Len", expansionSize),
-		l.String("Hydrate", queryBuilder.Hydrate),
-	)
-	return haki.JSON(w, http.StatusOK, queryBuilder.Result)
+	return haki.JSON(w, http.StatusOK, reservations.Warnings)
 }
 
-//NewAnonInventoryHandler creates a new DeckHandler instance
-func NewAnonInventoryHandler() http.HandlerFunc {
-	var inventoryHandler InventoryHandler
-	return haki.Handler(haki.Log(haki.Error(inventoryHandler.ServeHTTP)))
+//Release removes the reservations of the session player deck of the /{id}/reservations path in the session player
+//inventory, the inventory query parameter or the player default inventory. The deck of another player is not found
+func (h DeckHandler) Release(w http.ResponseWriter, r *http.Request) error {
+	readParameter := path.Base(path.Dir(r.URL.Path))
+	l.Info("DeckHandler.Release",
+		l.String("ReadParameter", readParameter),
+	)
+	player, found := security.FromContext(r.Context())
+	if !found {
+		return haki.Status(w, http.StatusUnauthorized)
+	}
+	var (
+		deck        = data.Deck{IDPlayer: player.ID}
+		idInventory int
+		err         error
+	)
+	if deck.ID, err = strconv.Atoi(readParameter); err != nil {
+		return haki.Status(w, http.StatusBadRequest)
+	}
+	if idInventory, err = requestInventory(r); err != nil || idInventory <= 0 {
+		return haki.Status(w, http.StatusNotFound)
+	}
+	if err = raizel.Execute(func(client raizel.Client) error {
+		return deck.Release(client, idInventory)
+	}); err != nil {
+		if err == raizel.ErrNotFound {
+			return haki.Status(w, http.StatusNotFound)
+		}
+		return haki.Err(w, err)
+	}
+	return haki.Status(w, http.StatusNoContent)
 }
 
-type InventoryHandler struct{}
-
-func (h InventoryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) error {
-	basePath, lastPath := path.Split(r.URL.Path)
-	l.Info("InventoryHandler.ServeHTTP",
-		l.String("Method", r.Method),
-		l.String("Path", r.URL.Path),
-		l.String("BasePath", basePath),
-		l.String("LastPath", lastPath),
-	)
-	if r.Method == "POST" || r.Method == "PUT" {
-		return h.Persist(w, r)
+//revisionErr writes 404 for the missing deck or revision
+func revisionErr(w http.ResponseWriter, err error) error {
+	if err == raizel.ErrNotFound || err == data.ErrRevisionNotFound {
+		return haki.Status(w, http.StatusNotFound)
 	}
-	return haki.Status(w, http.StatusMethodNotAllowed)
+	return haki.Err(w, err)
 }
 
-func (h InventoryHandler) Persist(w http.ResponseWriter, r *http.Request) error {
-	readParameter := path.Base(r.URL.Path)
-	l.Info("InventoryHandler.Persist",
-		l.String("ReadParameters", readParameter),
+//Revisions lists the saves of the deck of the /{id}/revisions path, newest first
+func (h DeckHandler) Revisions(w http.ResponseWriter, r *http.Request) error {
+	readParameter := path.Base(path.Dir(r.URL.Path))
+	l.Info("DeckHandler.Revisions",
+		l.String("ReadParameter", readParameter),
 	)
-	var inventory data.Inventory
-	if err := haki.ReadJSON(r, &inventory); err != nil {
-		return haki.Err(w, err)
+	var (
+		deck data.Deck
+		err  error
+	)
+	if deck.ID, err = strconv.Atoi(readParameter); err != nil {
+		return haki.Status(w, http.StatusBadRequest)
 	}
-	//Fixed to zero for anonymous inventory
-	inventory.ID = 0
-	inventory.IDPlayer = 0
-	if err := raizel.Execute(inventory.Persist); err != nil {
+	var revisions []data.DeckRevision
+	err = raizel.Execute(func(client raizel.Client) error {
+		var readErr error
+		revisions, readErr = deck.ReadRevisions(client)
+		return readErr
+	})
+	if err != nil {
 		return haki.Err(w, err)
 	}
+	if len(revisions) == 0 {
+		return haki.Status(w, http.StatusNotFound)
+	}
+	return haki.JSON(w, http.StatusOK, revisions)
+}
+
+//Diff compares the from and to revisions of the deck of the /{id}/diff path. Without to the last revision is used
+//and without from the revision before to
+func (h DeckHandler) Diff(w http.ResponseWriter, r *http.Request) error {
+	readParameter := path.Base(path.Dir(r.URL.Path))
+	queryParameters := r.URL.Query()
+	l.Info("DeckHandler.Diff",
+		l.String("ReadParameter", readParameter),
+		l.Struct("QueryParameters", queryParameters),
+	)
+	var (
+		deck     data.Deck
+		from, to int
+		err      error
+	)
+	if deck.ID, err = strconv.Atoi(readParameter); err != nil {
+		return haki.Status(w, http.StatusBadRequest)
+	}
+	if fromParameter := queryParameters.Get("from"); fromParameter != "" {
+		if from, err = strconv.Atoi(fromParameter); err != nil {
+			return haki.Status(w, http.StatusBadRequest)
+		}
+	}
+	if toParameter := queryParameters.Get("to"); toParameter != "" {
+		if to, err = strconv.Atoi(toParameter); err != nil {
+			return haki.Status(w, http.StatusBadRequest)
+		}
+	}
+	var diff data.DeckDiff
+	err = raizel.Execute(func(client raizel.Client) error {
+		var diffErr error
+		diff, diffErr = deck.Diff(client, from, to)
+		return diffErr
+	})
+	if err != nil {
+		return revisionErr(w, err)
+	}
+	return haki.JSON(w, http.StatusOK, diff)
+}
+
+//Restore persists the revision query parameter of the session player deck of the /{id}/restore path as its new
+//revision, the deck of another player is not found
+func (h DeckHandler) Restore(w http.ResponseWriter, r *http.Request) error {
+	readParameter := path.Base(path.Dir(r.URL.Path))
+	revisionParameter := r.URL.Query().Get("revision")
+	l.Info("DeckHandler.Restore",
+		l.String("ReadParameter", readParameter),
+		l.String("Revision", revisionParameter),
+	)
+	player, found := security.FromContext(r.Context())
+	if !found {
+		return haki.Status(w, http.StatusUnauthorized)
+	}
+	var (
+		deck     = data.Deck{IDPlayer: player.ID}
+		revision int
+		err      error
+	)
+	if deck.ID, err = strconv.Atoi(readParameter); err != nil {
+		return haki.Status(w, http.StatusBadRequest)
+	}
+	if revision, err = strconv.Atoi(revisionParameter); err != nil || revision < 1 {
+		return haki.Status(w, http.StatusBadRequest)
+	}
+	if err = raizel.Execute(func(client raizel.Client) error {
+		return deck.Restore(client, revision)
+	}); err != nil {
+		return revisionErr(w, err)
+	}
+	return haki.JSON(w, http.StatusOK, deck)
+}
+
+//DecklistImportResult is the response of a decklist import
+type DecklistImportResult struct {
+	Deck       data.Deck           `json:"deck"`
+	Unresolved []data.DecklistLine `json:"unresolved"`
+}
+
+//Import parses a plain text or .dek decklist from the request body and persists the resolved cards into a deck of
+//the session player. Query parameters: format (arena, mtgo or dek), name of the deck and the optional id of the
+//session player deck to replace
+func (h DeckHandler) Import(w http.ResponseWriter, r *http.Request) error {
+	queryParameters := r.URL.Query()
+	l.Info("DeckHandler.Import",
+		l.Struct("QueryParameters", queryParameters),
+	)
+	format := queryParameters.Get("format")
+	if format == "" {
+		format = data.DecklistArena
+	}
+	player, found := security.FromContext(r.Context())
+	if !found {
+		return haki.Status(w, http.StatusUnauthorized)
+	}
+	var deck data.Deck
+	deck.Name = strings.TrimSpace(queryParameters.Get("name"))
+	deck.IDPlayer = player.ID
+	if idParameter := queryParameters.Get("id"); idParameter != "" {
+		var atoiErr error
+		if deck.ID, atoiErr = strconv.Atoi(idParameter); atoiErr != nil {
+			return haki.Status(w, http.StatusBadRequest)
+		}
+	}
+	if deck.Name == "" {
+		l.Info("DeckHandler.Import.InvalidRequest", l.String("Message", "name parameter is empty"))
+		return haki.Status(w, http.StatusBadRequest)
+	}
+	lines, invalid, err := data.ParseDecklist(format, r.Body)
+	if err != nil {
+		l.Info("DeckHandler.Import.ParseErr", l.String("Format", format), l.Err(err))
+		return haki.Status(w, http.StatusBadRequest)
+	}
+	var unresolved []data.DecklistLine
+	err = raizel.Execute(func(client raizel.Client) error {
+		if deck.ID > 0 {
+			owned := data.Deck{ID: deck.ID}
+			if err := client.QueryOne("select d.id, d.name, d.id_player from deck d where d.id = $1", owned.FetchSmall, deck.ID); err != nil {
+				return err
+			}
+			if owned.IDPlayer != player.ID {
+				return raizel.ErrNotFound
+			}
+		}
+		var importErr error
+		if unresolved, importErr = deck.ImportDecklist(client, lines); importErr != nil {
+			return importErr
+		}
+		if len(deck.Cards) == 0 {
+			return nil
+		}
+		return deck.Persist(client)
+	})
+	if err != nil {
+		if err == raizel.ErrNotFound {
+			return haki.Status(w, http.StatusNotFound)
+		}
+		return haki.Err(w, err)
+	}
+	result := DecklistImportResult{
+		Deck:       deck,
+		Unresolved: append(invalid, unresolved...),
+	}
+	if len(deck.Cards) == 0 {
+		return haki.JSON(w, http.StatusBadRequest, result)
+	}
+	if queryParameters.Get("id") == "" {
+		return haki.JSON(w, http.StatusCreated, result)
+	}
+	return haki.JSON(w, http.StatusOK, result)
+}
+
+//Export writes the deck of the /{id}/export path as an arena, mtgo or dek decklist
+func (h DeckHandler) Export(w http.ResponseWriter, r *http.Request) error {
+	readParameter := path.Base(path.Dir(r.URL.Path))
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = data.DecklistArena
+	}
+	l.Info("DeckHandler.Export",
+		l.String("ReadParameter", readParameter),
+		l.String("Format", format),
+	)
+	var (
+		deck data.Deck
+		err  error
+	)
+	if deck.ID, err = strconv.Atoi(readParameter); err != nil {
+		return haki.Status(w, http.StatusBadRequest)
+	}
+	contentType, extension := "text/plain; charset=utf-8", ".txt"
+	switch format {
+	case data.DecklistArena, data.DecklistMTGO:
+	case data.DecklistDek:
+		contentType, extension = "application/xml; charset=utf-8", ".dek"
+	default:
+		return haki.Status(w, http.StatusBadRequest)
+	}
+	if err = raizel.Execute(deck.ReadByID); err != nil {
+		if err == raizel.ErrNotFound {
+			return haki.Status(w, http.StatusNotFound)
+		}
+		return haki.Err(w, err)
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", deck.Name+extension))
+	w.WriteHeader(http.StatusOK)
+	//The status is already sent, a failed write can only be logged
+	if err = deck.WriteDecklist(format, w); err != nil {
+		l.Error("DeckHandler.Export.WriteErr",
+			l.Int("ID", deck.ID),
+			l.String("Format", format),
+			l.Err(err),
+		)
+	}
+	return nil
+}
+
+func (h DeckHandler) Query(w http.ResponseWriter, r *http.Request) error {
+	queryParameters := r.URL.Query()
+	l.Info("DeckHandler.Query",
+		l.Struct("QueryParameters", queryParameters),
+	)
+	var (
+		deck      data.Deck
+		deckQuery data.DeckQuery
+	)
+	deckQuery.RegexName = queryParameters.Get("rx_name")
+	deckQuery.Order = queryParameters.Get("order")
+	if !readPage(queryParameters, &deckQuery.Query) {
+		return haki.Status(w, http.StatusBadRequest)
+	}
+	err := raizel.ExecuteWith(deck.Query, &deckQuery)
+	if err != nil {
+		return queryErr(w, err)
+	}
+	return haki.JSON(w, http.StatusOK, newQueryPage(deckQuery.Result, deckQuery.Query))
+}
+
+//Delete removes the session player deck of the /{id} path, the deck of another player is not found
+func (h DeckHandler) Delete(w http.ResponseWriter, r *http.Request) error {
+	readParameter := path.Base(r.URL.Path)
+	l.Info("DeckHandler.Delete",
+		l.Struct("ReadParameter", readParameter),
+	)
+	player, found := security.FromContext(r.Context())
+	if !found {
+		return haki.Status(w, http.StatusUnauthorized)
+	}
+	deck := data.Deck{IDPlayer: player.ID}
+	var err error
+	deck.ID, err = strconv.Atoi(readParameter)
+	if err != nil {
+		l.Info("DeckHandler.Delete.InvalidRequest",
+			l.String("Parameter", readParameter),
+			l.Err(err),
+		)
+		return haki.Status(w, http.StatusBadRequest)
+	}
+	err = raizel.Execute(deck.Delete)
+	if err != nil {
+		if err == raizel.ErrNotFound {
+			return haki.Status(w, http.StatusNotFound)
+		}
+		return haki.Err(w, err)
+	}
+	return nil
+}
+
+//NewAnonExpansionHandler creates a new unauthorized expansionHandler instance
+func NewAnonExpansionHandler() http.HandlerFunc {
+	var expansionHandler ExpansionHandler
+	return haki.Handler(haki.Log(haki.Error(expansionHandler.ServeHTTP)))
+}
+
+type ExpansionHandler struct{}
+
+func (h ExpansionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) error {
+	basePath, lastPath := path.Split(r.URL.Path)
+	l.Debug("ExpansionHandler.ServeHTTP",
+		l.String("Method", r.Method),
+		l.String("Path", r.URL.Path),
+		l.String("BasePath", basePath),
+		l.String("LastPath", lastPath),
+	)
+	if r.Method == "GET" {
+		if lastPath == "" {
+			return h.Query(w, r)
+		}
+		return h.Read(w, r)
+	}
+	return haki.Status(w, http.StatusMethodNotAllowed)
+}
+
+func (h ExpansionHandler) Read(w http.ResponseWriter, r *http.Request) error {
+	readParameter := path.Base(r.URL.Path)
+	l.Info("ExpansionHandler.Read",
+		l.Struct("ReadParameter", readParameter),
+	)
+	var (
+		expansion data.Expansion
+		err       error
+	)
+	if id, atoirErr := strconv.Atoi(readParameter); atoirErr == nil {
+		expansion.ID = id
+		err = raizel.Execute(expansion.ReadByID)
+	} else {
+		expansion.Name = readParameter
+		err = raizel.Execute(expansion.ReadByName)
+	}
+	if err != nil {
+		return haki.Status(w, http.StatusNotFound)
+	}
+	return haki.JSON(w, http.StatusOK, expansion)
+}
+
+func (h ExpansionHandler) Query(w http.ResponseWriter, r *http.Request) error {
+	queryParameters := r.URL.Query()
+	l.Info("ExpansionHandler.Query",
+		l.Struct("QueryParameters", queryParameters),
+	)
+	var (
+		expansion    data.Expansion
+		queryBuilder data.ExpansionQuery
+	)
+	queryBuilder.Hydrate = queryParameters.Get("hydrate")
+	queryBuilder.RegexName = queryParameters.Get("rx_name")
+	queryBuilder.Order = queryParameters.Get("order")
+	if !readPage(queryParameters, &queryBuilder.Query) {
+		return haki.Status(w, http.StatusBadRequest)
+	}
+	err := raizel.ExecuteWith(expansion.Query, &queryBuilder)
+	if err != nil {
+		return queryErr(w, err)
+	}
+	expansionSize := len(queryBuilder.Result)
+	l.Debug("ExpansionHandler.QueryResult",
+		l.Int("Expansions.Len", expansionSize),
+		l.String("Hydrate", queryBuilder.Hydrate),
+	)
+	return haki.JSON(w, http.StatusOK, newQueryPage(queryBuilder.Result, queryBuilder.Query))
+}
+
+//NewAnonInventoryHandler creates a new DeckHandler instance
+func NewAnonInventoryHandler() http.HandlerFunc {
+	var inventoryHandler InventoryHandler
+	return haki.Handler(haki.Log(haki.Error(inventoryHandler.ServeHTTP)))
+}
+
+type InventoryHandler struct{}
+
+func (h InventoryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) error {
+	basePath, lastPath := path.Split(r.URL.Path)
+	l.Info("InventoryHandler.ServeHTTP",
+		l.String("Method", r.Method),
+		l.String("Path", r.URL.Path),
+		l.String("BasePath", basePath),
+		l.String("LastPath", lastPath),
+	)
+	switch r.Method {
+	case "GET":
+		switch lastPath {
+		case "":
+			return h.Query(w, r)
+		case "export":
+			return h.Export(w, r)
+		case "reservations":
+			return h.Reservations(w, r)
+		case "value":
+			return h.Value(w, r)
+		case "movers":
+			return h.Movers(w, r)
+		}
+		return h.Read(w, r)
+	case "POST", "PUT":
+		if lastPath == "import" {
+			return h.Import(w, r)
+		}
+		return h.Persist(w, r)
+	}
+	return haki.Status(w, http.StatusMethodNotAllowed)
+}
+
+const (
+	//collectionImportAdd adds the imported copies to the inventory copies
+	collectionImportAdd = "add"
+	//collectionImportReplace replaces the inventory quantities of the imported cards
+	collectionImportReplace = "replace"
+)
+
+//CollectionImportResult is the response of a collection import
+type CollectionImportResult struct {
+	IDInventory int                  `json:"idInventory"`
+	DryRun      bool                 `json:"dryRun"`
+	Mode        string               `json:"mode"`
+	Persisted   bool                 `json:"persisted"`
+	Cards       int                  `json:"cards"`
+	Copies      int                  `json:"copies"`
+	Errors      int                  `json:"errors"`
+	Rows        []data.CollectionRow `json:"rows"`
+}
+
+//Import reads the collection CSV body into the session player inventory of the /{id}/import path.
+//The col_name, col_set, col_number, col_quantity, col_foil, col_condition and col_language query parameters map
+//the CSV header names. With dry_run=true only the preview is returned, rows with errors are reported and
+//nothing is persisted unless skip_errors=true. The imported copies are added to the copies already in the inventory,
+//with mode=replace the imported cards have their quantities replaced
+func (h InventoryHandler) Import(w http.ResponseWriter, r *http.Request) error {
+	readParameter := path.Base(path.Dir(r.URL.Path))
+	queryParameters := r.URL.Query()
+	l.Info("InventoryHandler.Import",
+		l.String("ReadParameter", readParameter),
+		l.Struct("QueryParameters", queryParameters),
+	)
+	player, found := security.FromContext(r.Context())
+	if !found {
+		return haki.Status(w, http.StatusUnauthorized)
+	}
+	var (
+		inventory data.Inventory
+		result    CollectionImportResult
+		err       error
+	)
+	if inventory.ID, err = strconv.Atoi(readParameter); err != nil {
+		return haki.Status(w, http.StatusBadRequest)
+	}
+	columns := map[string]string{}
+	for _, field := range data.CollectionFields {
+		columns[field] = queryParameters.Get("col_" + field)
+	}
+	if result.Rows, err = data.ParseCollectionCSV(r.Body, columns); err != nil {
+		l.Info("InventoryHandler.Import.ParseErr", l.Err(err))
+		return haki.Status(w, http.StatusBadRequest)
+	}
+	if result.Rows == nil {
+		result.Rows = []data.CollectionRow{}
+	}
+	result.IDInventory = inventory.ID
+	result.DryRun = queryParameters.Get("dry_run") == "true"
+	skipErrors := queryParameters.Get("skip_errors") == "true"
+	persist := inventory.Add
+	switch result.Mode = queryParameters.Get("mode"); result.Mode {
+	case "", collectionImportAdd:
+		result.Mode = collectionImportAdd
+	case collectionImportReplace:
+		persist = inventory.Persist
+	default:
+		l.Info("InventoryHandler.Import.InvalidMode", l.String("Mode", result.Mode))
+		return haki.Status(w, http.StatusBadRequest)
+	}
+	err = raizel.Execute(func(client raizel.Client) error {
+		if err := inventory.ReadByID(client); err != nil {
+			return err
+		}
+		if inventory.IDPlayer != player.ID {
+			return raizel.ErrNotFound
+		}
+		if err := inventory.ResolveCollection(client, result.Rows); err != nil {
+			return err
+		}
+		for _, row := range result.Rows {
+			if row.Reason != "" {
+				result.Errors++
+			}
+		}
+		for _, card := range inventory.Cards {
+			result.Copies += card.InventoryCard.Quantity
+		}
+		result.Cards = len(inventory.Cards)
+		if result.DryRun || (result.Errors > 0 && !skipErrors) || len(inventory.Cards) == 0 {
+			return nil
+		}
+		inventory.Name = ""
+		result.Persisted = true
+		return persist(client)
+	})
+	if err != nil {
+		if err == raizel.ErrNotFound {
+			return haki.Status(w, http.StatusNotFound)
+		}
+		return haki.Err(w, err)
+	}
+	if !result.DryRun && !result.Persisted {
+		return haki.JSON(w, http.StatusBadRequest, result)
+	}
+	return haki.JSON(w, http.StatusOK, result)
+}
+
+//Export streams the cards of the session player inventory of the /{id}/export path as csv, json or txt
+func (h InventoryHandler) Export(w http.ResponseWriter, r *http.Request) error {
+	readParameter := path.Base(path.Dir(r.URL.Path))
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = data.ExportCSV
+	}
+	l.Info("InventoryHandler.Export",
+		l.String("ReadParameter", readParameter),
+		l.String("Format", format),
+	)
+	player, found := security.FromContext(r.Context())
+	if !found {
+		return haki.Status(w, http.StatusUnauthorized)
+	}
+	var (
+		inventory data.Inventory
+		err       error
+	)
+	if inventory.ID, err = strconv.Atoi(readParameter); err != nil {
+		return haki.Status(w, http.StatusBadRequest)
+	}
+	contentType := map[string]string{
+		data.ExportCSV:  "text/csv; charset=utf-8",
+		data.ExportJSON: "application/json; charset=utf-8",
+		data.ExportText: "text/plain; charset=utf-8",
+	}[format]
+	if contentType == "" {
+		return haki.Status(w, http.StatusBadRequest)
+	}
+	return raizel.Execute(func(client raizel.Client) error {
+		if err := inventory.ReadByID(client); err != nil {
+			if err == raizel.ErrNotFound {
+				return haki.Status(w, http.StatusNotFound)
+			}
+			return haki.Err(w, err)
+		}
+		if inventory.IDPlayer != player.ID {
+			return haki.Status(w, http.StatusNotFound)
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", inventory.Name+"."+format))
+		w.WriteHeader(http.StatusOK)
+		//The status is already sent, a failed export can only be logged and ends the truncated body
+		if err := inventory.Export(client, format, w); err != nil {
+			l.Error("InventoryHandler.Export.WriteErr",
+				l.Int("ID", inventory.ID),
+				l.String("Format", format),
+				l.Err(err),
+			)
+		}
+		return nil
+	})
+}
+
+//Value returns the value of the copies of the session player inventory of the /{id}/value path by card and
+//finish on the date query parameter, today by default. With the from and interval (day, week or month) query
+//parameters the value history of the current copies is returned too
+func (h InventoryHandler) Value(w http.ResponseWriter, r *http.Request) error {
+	readParameter := path.Base(path.Dir(r.URL.Path))
+	queryParameters := r.URL.Query()
+	l.Info("InventoryHandler.Value",
+		l.String("ReadParameter", readParameter),
+		l.Struct("QueryParameters", queryParameters),
+	)
+	player, found := security.FromContext(r.Context())
+	if !found {
+		return haki.Status(w, http.StatusUnauthorized)
+	}
+	var (
+		inventory data.Inventory
+		value     data.CollectionValue
+		err       error
+	)
+	if inventory.ID, err = strconv.Atoi(readParameter); err != nil {
+		return haki.Status(w, http.StatusBadRequest)
+	}
+	options, valid := readValueOptions(queryParameters)
+	if !valid {
+		return haki.Status(w, http.StatusBadRequest)
+	}
+	err = raizel.Execute(func(client raizel.Client) error {
+		if err := inventory.ReadByID(client); err != nil {
+			return err
+		}
+		if inventory.IDPlayer != player.ID {
+			return raizel.ErrNotFound
+		}
+		var valueErr error
+		value, valueErr = inventory.Value(client, options)
+		return valueErr
+	})
+	if err != nil {
+		switch err {
+		case raizel.ErrNotFound:
+			return haki.Status(w, http.StatusNotFound)
+		case data.ErrInvalidValueHistory:
+			return haki.Status(w, http.StatusBadRequest)
+		}
+		return haki.Err(w, err)
+	}
+	return haki.JSON(w, http.StatusOK, value)
+}
+
+//Movers returns the cards of the session player inventory of the /{id}/movers path whose price changed the most,
+//by percent, in the window query parameter (7d by default, 30d, ...) ending on the date query parameter, today by
+//default. The limit query parameter bounds the cards and the card query filters restrict them
+func (h InventoryHandler) Movers(w http.ResponseWriter, r *http.Request) error {
+	readParameter := path.Base(path.Dir(r.URL.Path))
+	queryParameters := r.URL.Query()
+	l.Info("InventoryHandler.Movers",
+		l.String("ReadParameter", readParameter),
+		l.Struct("QueryParameters", queryParameters),
+	)
+	player, found := security.FromContext(r.Context())
+	if !found {
+		return haki.Status(w, http.StatusUnauthorized)
+	}
+	var (
+		inventory data.Inventory
+		options   data.MoversOptions
+		movers    data.InventoryMovers
+		err       error
+	)
+	if inventory.ID, err = strconv.Atoi(readParameter); err != nil {
+		return haki.Status(w, http.StatusBadRequest)
+	}
+	if parameter := queryParameters.Get("window"); parameter != "" {
+		if options.Window, err = data.ParseMoversWindow(parameter); err != nil {
+			return haki.Status(w, http.StatusBadRequest)
+		}
+	}
+	if parameter := queryParameters.Get("limit"); parameter != "" {
+		if options.Limit, err = strconv.Atoi(parameter); err != nil || options.Limit <= 0 {
+			return haki.Status(w, http.StatusBadRequest)
+		}
+	}
+	if parameter := queryParameters.Get("date"); parameter != "" {
+		if options.Date, err = time.Parse(data.PriceDateLayout, parameter); err != nil {
+			return haki.Status(w, http.StatusBadRequest)
+		}
+	}
+	cardQuery := newCardQuery(queryParameters)
+	err = raizel.Execute(func(client raizel.Client) error {
+		if err := inventory.ReadByID(client); err != nil {
+			return err
+		}
+		if inventory.IDPlayer != player.ID {
+			return raizel.ErrNotFound
+		}
+		var moversErr error
+		movers, moversErr = inventory.Movers(client, &cardQuery, options)
+		return moversErr
+	})
+	if err != nil {
+		switch err {
+		case raizel.ErrNotFound:
+			return haki.Status(w, http.StatusNotFound)
+		case data.ErrInvalidMovers:
+			return haki.Status(w, http.StatusBadRequest)
+		}
+		return queryErr(w, err)
+	}
+	return haki.JSON(w, http.StatusOK, movers)
+}
+
+//Reservations returns, for the cards of the session player inventory of the /{id}/reservations path, the copies
+//committed to each deck, the free copies and the warnings of cards committed beyond their copies
+func (h InventoryHandler) Reservations(w http.ResponseWriter, r *http.Request) error {
+	readParameter := path.Base(path.Dir(r.URL.Path))
+	l.Info("InventoryHandler.Reservations",
+		l.String("ReadParameter", readParameter),
+	)
+	player, found := security.FromContext(r.Context())
+	if !found {
+		return haki.Status(w, http.StatusUnauthorized)
+	}
+	var (
+		inventory    data.Inventory
+		reservations data.InventoryReservations
+		err          error
+	)
+	if inventory.ID, err = strconv.Atoi(readParameter); err != nil {
+		return haki.Status(w, http.StatusBadRequest)
+	}
+	err = raizel.Execute(func(client raizel.Client) error {
+		if err := inventory.ReadByID(client); err != nil {
+			return err
+		}
+		if inventory.IDPlayer != player.ID {
+			return raizel.ErrNotFound
+		}
+		var readErr error
+		reservations, readErr = inventory.ReadReservations(client)
+		return readErr
+	})
+	if err != nil {
+		if err == raizel.ErrNotFound {
+			return haki.Status(w, http.StatusNotFound)
+		}
+		return haki.Err(w, err)
+	}
+	return haki.JSON(w, http.StatusOK, reservations)
+}
+
+//Query returns the named inventories of the session player
+func (h InventoryHandler) Query(w http.ResponseWriter, r *http.Request) error {
+	sessionPlayer, found := security.FromContext(r.Context())
+	if !found {
+		return haki.Status(w, http.StatusUnauthorized)
+	}
+	l.Info("InventoryHandler.Query", l.Int("IDPlayer", sessionPlayer.ID))
+	player := data.Player{ID: sessionPlayer.ID}
+	if err := raizel.Execute(player.ReadInventories); err != nil {
+		return haki.Err(w, err)
+	}
+	if player.Inventories == nil {
+		player.Inventories = []data.Inventory{}
+	}
+	return haki.JSON(w, http.StatusOK, player.Inventories)
+}
+
+//InventoryPage is the response of an inventory read, the QueryPage of the inventory cards with the inventory and
+//the totals of the matching cards
+type InventoryPage struct {
+	QueryPage
+	ID       int                   `json:"id"`
+	Name     string                `json:"name"`
+	IDPlayer int                   `json:"idPlayer"`
+	Totals   *data.InventoryTotals `json:"totals,omitempty"`
+}
+
+//Read returns one page of the cards of a session player inventory with the totals of the matching cards.
+//Query parameters: limit, cursor, total and the card query filters
+func (h InventoryHandler) Read(w http.ResponseWriter, r *http.Request) error {
+	readParameter := path.Base(r.URL.Path)
+	queryParameters := r.URL.Query()
+	l.Info("InventoryHandler.Read",
+		l.String("ReadParameter", readParameter),
+		l.Struct("QueryParameters", queryParameters),
+	)
+	player, found := security.FromContext(r.Context())
+	if !found {
+		return haki.Status(w, http.StatusUnauthorized)
+	}
+	var (
+		inventory data.Inventory
+		err       error
+	)
+	if inventory.ID, err = strconv.Atoi(readParameter); err != nil {
+		return haki.Status(w, http.StatusBadRequest)
+	}
+	cardQuery := newCardQuery(queryParameters)
+	if !readPage(queryParameters, &cardQuery.Query) {
+		return haki.Status(w, http.StatusBadRequest)
+	}
+	err = raizel.Execute(func(client raizel.Client) error {
+		if err := inventory.ReadByID(client); err != nil {
+			return err
+		}
+		if inventory.IDPlayer != player.ID {
+			return raizel.ErrNotFound
+		}
+		return inventory.ReadCards(client, &cardQuery)
+	})
+	if err != nil {
+		if err == raizel.ErrNotFound {
+			return haki.Status(w, http.StatusNotFound)
+		}
+		l.Error("InventoryHandler.ReadErr", l.String("ReadParameter", readParameter), l.Err(err))
+		return queryErr(w, err)
+	}
+	return haki.JSON(w, http.StatusOK, InventoryPage{
+		QueryPage: newQueryPage(inventory.Cards, cardQuery.Query),
+		ID:        inventory.ID,
+		Name:      inventory.Name,
+		IDPlayer:  inventory.IDPlayer,
+		Totals:    inventory.Totals,
+	})
+}
+
+//Persist updates the cards of a session player inventory identified by the path or the payload id.
+//Without id a payload with a name creates a new named inventory, otherwise the player default inventory is updated.
+//Without id, name nor default inventory the request is invalid
+func (h InventoryHandler) Persist(w http.ResponseWriter, r *http.Request) error {
+	readParameter := path.Base(r.URL.Path)
+	l.Info("InventoryHandler.Persist",
+		l.String("ReadParameters", readParameter),
+	)
+	player, found := security.FromContext(r.Context())
+	if !found {
+		return haki.Status(w, http.StatusUnauthorized)
+	}
+	var inventory data.Inventory
+	if err := haki.ReadJSON(r, &inventory); err != nil {
+		return haki.Err(w, err)
+	}
+	if id, atoiErr := strconv.Atoi(readParameter); atoiErr == nil {
+		inventory.ID = id
+	}
+	if inventory.ID <= 0 && strings.TrimSpace(inventory.Name) == "" {
+		inventory.ID = player.IDInventory
+	}
+	inventory.IDPlayer = player.ID
+	isCreateRequest := inventory.ID <= 0
+	if err := raizel.Execute(inventory.Persist); err != nil {
+		switch err {
+		case raizel.ErrNotFound:
+			return haki.Status(w, http.StatusNotFound)
+		case data.ErrInvalidInventory, data.ErrInvalidInventoryCard:
+			return haki.Status(w, http.StatusBadRequest)
+		}
+		return haki.Err(w, err)
+	}
+	if isCreateRequest {
+		w.WriteHeader(http.StatusCreated)
+		_, err := io.WriteString(w, strconv.Itoa(inventory.ID))
+		return err
+	}
 	return haki.Status(w, http.StatusAccepted)
 }
+
+//NewAnonTradeHandler creates a new unauthorized tradeHandler instance
+func NewAnonTradeHandler() http.HandlerFunc {
+	var tradeHandler TradeHandler
+	return haki.Handler(haki.Log(haki.Error(tradeHandler.ServeHTTP)))
+}
+
+type TradeHandler struct{}
+
+func (h TradeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) error {
+	basePath, lastPath := path.Split(r.URL.Path)
+	l.Info("TradeHandler.ServeHTTP",
+		l.String("Method", r.Method),
+		l.String("Path", r.URL.Path),
+		l.String("BasePath", basePath),
+		l.String("LastPath", lastPath),
+	)
+	switch r.Method {
+	case "GET":
+		if lastPath == "" {
+			return h.Query(w, r)
+		}
+		return h.Read(w, r)
+	case "POST":
+		switch lastPath {
+		case "":
+			return h.Propose(w, r)
+		case "accept", "decline", "counter":
+			return h.Answer(w, r)
+		}
+	}
+	return haki.Status(w, http.StatusMethodNotAllowed)
+}
+
+//tradeErr writes the invalid trades as 400, the answers of other players as 403, the missing copies as 409 with
+//the missing copies and the answers of closed trades as 409
+func tradeErr(w http.ResponseWriter, err error) error {
+	if unavailableErr, isUnavailable := err.(*data.TradeUnavailableError); isUnavailable {
+		return haki.JSON(w, http.StatusConflict, unavailableErr)
+	}
+	switch err {
+	case raizel.ErrNotFound:
+		return haki.Status(w, http.StatusNotFound)
+	case data.ErrInvalidTrade, data.ErrInvalidInventoryCard:
+		return haki.Status(w, http.StatusBadRequest)
+	case data.ErrTradeNotReceiver:
+		return haki.Status(w, http.StatusForbidden)
+	case data.ErrTradeClosed:
+		return haki.Status(w, http.StatusConflict)
+	}
+	return haki.Err(w, err)
+}
+
+//Query returns the trades proposed by or to the session player, the last updated first, with the status
+//query parameter (proposed, accepted, countered or declined) when it is set
+func (h TradeHandler) Query(w http.ResponseWriter, r *http.Request) error {
+	queryParameters := r.URL.Query()
+	l.Info("TradeHandler.Query",
+		l.Struct("QueryParameters", queryParameters),
+	)
+	player, found := security.FromContext(r.Context())
+	if !found {
+		return haki.Status(w, http.StatusUnauthorized)
+	}
+	var trades []data.Trade
+	err := raizel.Execute(func(client raizel.Client) error {
+		var readErr error
+		trades, readErr = player.ReadTrades(client, queryParameters.Get("status"))
+		return readErr
+	})
+	if err != nil {
+		return haki.Err(w, err)
+	}
+	return haki.JSON(w, http.StatusOK, trades)
+}
+
+//Read returns the trade of the /{id} path, with its cards and status history, to its proposer or receiver
+func (h TradeHandler) Read(w http.ResponseWriter, r *http.Request) error {
+	readParameter := path.Base(r.URL.Path)
+	l.Info("TradeHandler.Read",
+		l.String("ReadParameter", readParameter),
+	)
+	player, found := security.FromContext(r.Context())
+	if !found {
+		return haki.Status(w, http.StatusUnauthorized)
+	}
+	var (
+		trade data.Trade
+		err   error
+	)
+	if trade.ID, err = strconv.Atoi(readParameter); err != nil {
+		return haki.Status(w, http.StatusBadRequest)
+	}
+	err = raizel.Execute(func(client raizel.Client) error {
+		return trade.Read(client, player.ID)
+	})
+	if err != nil {
+		return tradeErr(w, err)
+	}
+	return haki.JSON(w, http.StatusOK, trade)
+}
+
+//Propose records the trade of the payload from the session player to the idReceiver player. The offered copies
+//are of the idProposerInventory and the requested ones of the idReceiverInventory, the players default inventories
+//when they are empty. The response is the proposed trade
+func (h TradeHandler) Propose(w http.ResponseWriter, r *http.Request) error {
+	l.Info("TradeHandler.Propose")
+	player, found := security.FromContext(r.Context())
+	if !found {
+		return haki.Status(w, http.StatusUnauthorized)
+	}
+	var trade data.Trade
+	if err := haki.ReadJSON(r, &trade); err != nil {
+		return haki.Err(w, err)
+	}
+	trade.ID, trade.IDParent = 0, 0
+	trade.IDProposer = player.ID
+	if err := raizel.Execute(trade.Propose); err != nil {
+		return tradeErr(w, err)
+	}
+	return haki.JSON(w, http.StatusCreated, trade)
+}
+
+//TradeAnswer is the payload of the accept, decline and counter requests of a trade. Offered and Requested are
+//the cards of the counter proposal
+type TradeAnswer struct {
+	Message   string           `json:"message"`
+	Offered   []data.TradeCard `json:"offered"`
+	Requested []data.TradeCard `json:"requested"`
+}
+
+//Answer accepts, declines or counters, by the last path element, the trade of the /{id}/{answer} path received by
+//the session player. The payload, a TradeAnswer, is optional for accept and decline. An accepted trade exchanges
+//the copies of the inventories, a counter proposal is a new trade returned with the 201 status
+func (h TradeHandler) Answer(w http.ResponseWriter, r *http.Request) error {
+	readParameter := path.Base(path.Dir(r.URL.Path))
+	answer := path.Base(r.URL.Path)
+	l.Info("TradeHandler.Answer",
+		l.String("ReadParameter", readParameter),
+		l.String("Answer", answer),
+	)
+	player, found := security.FromContext(r.Context())
+	if !found {
+		return haki.Status(w, http.StatusUnauthorized)
+	}
+	var (
+		trade   data.Trade
+		payload TradeAnswer
+		err     error
+	)
+	if trade.ID, err = strconv.Atoi(readParameter); err != nil {
+		return haki.Status(w, http.StatusBadRequest)
+	}
+	if r.ContentLength != 0 {
+		if err = haki.ReadJSON(r, &payload); err != nil {
+			return haki.Err(w, err)
+		}
+	}
+	counter := data.Trade{Message: payload.Message, Offered: payload.Offered, Requested: payload.Requested}
+	err = raizel.Execute(func(client raizel.Client) error {
+		switch answer {
+		case "accept":
+			return trade.Accept(client, player.ID, payload.Message)
+		case "decline":
+			return trade.Decline(client, player.ID, payload.Message)
+		}
+		return trade.Counter(client, player.ID, &counter)
+	})
+	if err != nil {
+		return tradeErr(w, err)
+	}
+	if answer == "counter" {
+		return haki.JSON(w, http.StatusCreated, counter)
+	}
+	return haki.JSON(w, http.StatusOK, trade)
+}
+
+//NewAnonWishlistHandler creates a new unauthorized wishlistHandler instance
+func NewAnonWishlistHandler() http.HandlerFunc {
+	var wishlistHandler WishlistHandler
+	return haki.Handler(haki.Log(haki.Error(wishlistHandler.ServeHTTP)))
+}
+
+type WishlistHandler struct{}
+
+func (h WishlistHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) error {
+	basePath, lastPath := path.Split(r.URL.Path)
+	l.Info("WishlistHandler.ServeHTTP",
+		l.String("Method", r.Method),
+		l.String("Path", r.URL.Path),
+		l.String("BasePath", basePath),
+		l.String("LastPath", lastPath),
+	)
+	switch r.Method {
+	case "GET":
+		switch lastPath {
+		case "":
+			return h.Read(w, r)
+		case "matches":
+			return h.Matches(w, r)
+		}
+	case "POST", "PUT":
+		if lastPath == "" {
+			return h.Persist(w, r)
+		}
+	}
+	return haki.Status(w, http.StatusMethodNotAllowed)
+}
+
+//Read returns the wishlist of the session player
+func (h WishlistHandler) Read(w http.ResponseWriter, r *http.Request) error {
+	l.Info("WishlistHandler.Read")
+	player, found := security.FromContext(r.Context())
+	if !found {
+		return haki.Status(w, http.StatusUnauthorized)
+	}
+	wishlist := data.Wishlist{IDPlayer: player.ID}
+	if err := raizel.Execute(wishlist.Read); err != nil {
+		return haki.Err(w, err)
+	}
+	return haki.JSON(w, http.StatusOK, wishlist)
+}
+
+//Persist adds or updates the payload cards in the wishlist of the session player, the cards with quantity zero are
+//removed. The response is the updated wishlist
+func (h WishlistHandler) Persist(w http.ResponseWriter, r *http.Request) error {
+	l.Info("WishlistHandler.Persist")
+	player, found := security.FromContext(r.Context())
+	if !found {
+		return haki.Status(w, http.StatusUnauthorized)
+	}
+	var wishlist data.Wishlist
+	if err := haki.ReadJSON(r, &wishlist); err != nil {
+		return haki.Err(w, err)
+	}
+	wishlist.IDPlayer = player.ID
+	err := raizel.Execute(func(client raizel.Client) error {
+		if err := wishlist.Persist(client); err != nil {
+			return err
+		}
+		return wishlist.Read(client)
+	})
+	if err != nil {
+		if err == data.ErrInvalidWishlist {
+			return haki.Status(w, http.StatusBadRequest)
+		}
+		return haki.Err(w, err)
+	}
+	return haki.JSON(w, http.StatusOK, wishlist)
+}
+
+//Matches returns the wishlist cards the session player misses with the spare copies of the other players and the
+//wishlist cards the other players miss with the spare copies of the session player. The spare copies are the
+//inventory copies beyond the deck reservations
+func (h WishlistHandler) Matches(w http.ResponseWriter, r *http.Request) error {
+	l.Info("WishlistHandler.Matches")
+	player, found := security.FromContext(r.Context())
+	if !found {
+		return haki.Status(w, http.StatusUnauthorized)
+	}
+	var (
+		wishlist = data.Wishlist{IDPlayer: player.ID}
+		matches  data.WishlistMatches
+	)
+	err := raizel.Execute(func(client raizel.Client) error {
+		var matchesErr error
+		matches, matchesErr = wishlist.Matches(client)
+		return matchesErr
+	})
+	if err != nil {
+		return haki.Err(w, err)
+	}
+	return haki.JSON(w, http.StatusOK, matches)
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"errors"
+	"math/big"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/rjansen/fivecolors/data"
+	haki "github.com/rjansen/haki/http"
+	"github.com/rjansen/l"
+	"github.com/rjansen/raizel"
+)
+
+//MaxHypergeometricDeckSize is the largest deck size accepted by the hypergeometric calculator
+const MaxHypergeometricDeckSize = 1000
+
+//ErrInvalidHypergeometric is raised when the hypergeometric parameters are out of bounds
+var ErrInvalidHypergeometric = errors.New("api.Hypergeometric.InvalidErr: Message='Required 0 <= successes <= draws <= deckSize <= 1000 and 0 <= copies <= deckSize'")
+
+//Hypergeometric is the probability of drawing successes copies of a card in draws cards from the deck
+type Hypergeometric struct {
+	DeckID    int     `json:"deckId,omitempty"`
+	Card      string  `json:"card,omitempty"`
+	DeckSize  int     `json:"deckSize"`
+	Copies    int     `json:"copies"`
+	Draws     int     `json:"draws"`
+	Successes int     `json:"successes"`
+	Exactly   float64 `json:"exactly"`
+	AtLeast   float64 `json:"atLeast"`
+	AtMost    float64 `json:"atMost"`
+}
+
+//Calculate fills the exactly, at least and at most probabilities computed with exact rationals
+func (h *Hypergeometric) Calculate() error {
+	if h.DeckSize < 0 || h.DeckSize > MaxHypergeometricDeckSize || h.Copies < 0 || h.Copies > h.DeckSize ||
+		h.Draws < 0 || h.Draws > h.DeckSize || h.Successes < 0 || h.Successes > h.Draws {
+		return ErrInvalidHypergeometric
+	}
+	var (
+		others  = h.DeckSize - h.Copies
+		first   = h.Draws - others
+		exactly = new(big.Rat)
+		atMost  = new(big.Rat)
+	)
+	if first < 0 {
+		first = 0
+	}
+	//Only the first possible term uses binomials, the next ones are P(k+1) = P(k) * ratio(k)
+	if first <= h.Successes && first <= h.Copies {
+		probability := h.probability(first)
+		for k := first; k <= h.Successes && k <= h.Copies; k++ {
+			if k > first {
+				probability.Mul(probability, h.ratio(k-1))
+			}
+			atMost.Add(atMost, probability)
+			if k == h.Successes {
+				exactly.Set(probability)
+			}
+		}
+	}
+	atLeast := new(big.Rat).Sub(big.NewRat(1, 1), atMost)
+	atLeast.Add(atLeast, exactly)
+	h.Exactly, _ = exactly.Float64()
+	h.AtMost, _ = atMost.Float64()
+	h.AtLeast, _ = atLeast.Float64()
+	return nil
+}
+
+//probability is C(copies, k) * C(deckSize - copies, draws - k) / C(deckSize, draws)
+func (h *Hypergeometric) probability(k int) *big.Rat {
+	ways := new(big.Int).Binomial(int64(h.Copies), int64(k))
+	ways.Mul(ways, new(big.Int).Binomial(int64(h.DeckSize-h.Copies), int64(h.Draws-k)))
+	return new(big.Rat).SetFrac(ways, new(big.Int).Binomial(int64(h.DeckSize), int64(h.Draws)))
+}
+
+//ratio is P(k+1) / P(k) = (copies - k) * (draws - k) / ((k + 1) * (deckSize - copies - draws + k + 1))
+func (h *Hypergeometric) ratio(k int) *big.Rat {
+	numerator := int64(h.Copies-k) * int64(h.Draws-k)
+	denominator := int64(k+1) * int64(h.DeckSize-h.Copies-h.Draws+k+1)
+	return big.NewRat(numerator, denominator)
+}
+
+//NewAnonMathHandler creates a new unauthorized MathHandler instance
+func NewAnonMathHandler() http.HandlerFunc {
+	var mathHandler MathHandler
+	return haki.Handler(haki.Log(haki.Error(mathHandler.ServeHTTP)))
+}
+
+//MathHandler serves the deck building calculators
+type MathHandler struct{}
+
+func (h MathHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) error {
+	lastPath := path.Base(r.URL.Path)
+	l.Debug("MathHandler.ServeHTTP",
+		l.String("Method", r.Method),
+		l.String("Path", r.URL.Path),
+		l.String("LastPath", lastPath),
+	)
+	if r.Method != "GET" {
+		return haki.Status(w, http.StatusMethodNotAllowed)
+	}
+	switch lastPath {
+	case "hypergeometric":
+		return h.Hypergeometric(w, r)
+	}
+	return haki.Status(w, http.StatusNotFound)
+}
+
+//Hypergeometric reads the deckSize, copies, draws and successes query parameters. With the deckId and card parameters
+//the deck size is the main board of the deck and the copies are the main board copies of the card. Decks larger than
+//MaxHypergeometricDeckSize are a bad request
+func (h MathHandler) Hypergeometric(w http.ResponseWriter, r *http.Request) error {
+	queryParameters := r.URL.Query()
+	l.Info("MathHandler.Hypergeometric",
+		l.Struct("QueryParameters", queryParameters),
+	)
+	var (
+		hypergeometric Hypergeometric
+		err            error
+	)
+	for name, target := range map[string]*int{
+		"deckId":    &hypergeometric.DeckID,
+		"deckSize":  &hypergeometric.DeckSize,
+		"copies":    &hypergeometric.Copies,
+		"draws":     &hypergeometric.Draws,
+		"successes": &hypergeometric.Successes,
+	} {
+		if parameter := queryParameters.Get(name); parameter != "" {
+			if *target, err = strconv.Atoi(parameter); err != nil {
+				return haki.Status(w, http.StatusBadRequest)
+			}
+		}
+	}
+	if hypergeometric.DeckID > 0 {
+		hypergeometric.Card = strings.TrimSpace(queryParameters.Get("card"))
+		if hypergeometric.Card == "" {
+			return haki.Status(w, http.StatusBadRequest)
+		}
+		deck := data.Deck{ID: hypergeometric.DeckID}
+		if err = raizel.Execute(deck.ReadByID); err != nil {
+			if err == raizel.ErrNotFound {
+				return haki.Status(w, http.StatusNotFound)
+			}
+			return haki.Err(w, err)
+		}
+		found := false
+		hypergeometric.DeckSize, hypergeometric.Copies = 0, 0
+		for _, card := range deck.Cards {
+			if card.DeckCard.IDBoard != data.MainBoard {
+				continue
+			}
+			hypergeometric.DeckSize += card.DeckCard.Quantity
+			if strings.EqualFold(card.Name, hypergeometric.Card) {
+				found = true
+				hypergeometric.Card = card.Name
+				hypergeometric.Copies += card.DeckCard.Quantity
+			}
+		}
+		if !found {
+			return haki.Status(w, http.StatusNotFound)
+		}
+	}
+	if err = hypergeometric.Calculate(); err != nil {
+		l.Info("MathHandler.Hypergeometric.InvalidErr", l.Err(err))
+		return haki.Status(w, http.StatusBadRequest)
+	}
+	return haki.JSON(w, http.StatusOK, hypergeometric)
+}
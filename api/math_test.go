@@ -0,0 +1,56 @@
+package api_test
+
+import (
+	"testing"
+
+	"github.com/rjansen/fivecolors/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HypergeometricOpeningHand(t *testing.T) {
+	hypergeometric := api.Hypergeometric{DeckSize: 60, Copies: 4, Draws: 7, Successes: 1}
+	assert.Nil(t, hypergeometric.Calculate())
+	assert.InDelta(t, 0.3363, hypergeometric.Exactly, 1e-4)
+	assert.InDelta(t, 0.3995, hypergeometric.AtLeast, 1e-4)
+	assert.InDelta(t, 0.9368, hypergeometric.AtMost, 1e-4)
+}
+
+func Test_HypergeometricBounds(t *testing.T) {
+	allCopies := api.Hypergeometric{DeckSize: 10, Copies: 10, Draws: 3, Successes: 3}
+	assert.Nil(t, allCopies.Calculate())
+	assert.Equal(t, 1.0, allCopies.Exactly)
+	assert.Equal(t, 1.0, allCopies.AtLeast)
+	assert.Equal(t, 1.0, allCopies.AtMost)
+
+	impossible := api.Hypergeometric{DeckSize: 60, Copies: 2, Draws: 7, Successes: 3}
+	assert.Nil(t, impossible.Calculate())
+	assert.Equal(t, 0.0, impossible.Exactly)
+	assert.Equal(t, 0.0, impossible.AtLeast)
+	assert.Equal(t, 1.0, impossible.AtMost)
+
+	for _, invalid := range []api.Hypergeometric{
+		{DeckSize: 60, Copies: 61, Draws: 7},
+		{DeckSize: 60, Copies: 4, Draws: 61},
+		{DeckSize: 60, Copies: 4, Draws: 7, Successes: 8},
+		{DeckSize: 60, Copies: 4, Draws: 7, Successes: -1},
+		{DeckSize: api.MaxHypergeometricDeckSize + 1, Copies: 4, Draws: 7},
+	} {
+		assert.Equal(t, api.ErrInvalidHypergeometric, invalid.Calculate())
+	}
+}
+
+func Test_HypergeometricLargeDeck(t *testing.T) {
+	hypergeometric := api.Hypergeometric{DeckSize: 1000, Copies: 400, Draws: 500, Successes: 250}
+	assert.Nil(t, hypergeometric.Calculate())
+	assert.InDelta(t, 1.0, hypergeometric.AtLeast+hypergeometric.AtMost-hypergeometric.Exactly, 1e-9)
+	assert.True(t, hypergeometric.AtMost > 0.99)
+
+	forced := api.Hypergeometric{DeckSize: 10, Copies: 6, Draws: 7, Successes: 3}
+	assert.Nil(t, forced.Calculate())
+	assert.InDelta(t, 20.0/120.0, forced.Exactly, 1e-12)
+	assert.InDelta(t, 20.0/120.0, forced.AtMost, 1e-12)
+	forced.Successes = 4
+	assert.Nil(t, forced.Calculate())
+	assert.InDelta(t, 60.0/120.0, forced.Exactly, 1e-12)
+	assert.InDelta(t, 80.0/120.0, forced.AtMost, 1e-12)
+}
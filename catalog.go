@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rjansen/fivecolors/data"
+	"github.com/rjansen/l"
+	"github.com/rjansen/raizel"
+)
+
+//importCatalog runs the import-catalog command: fivecolors -ecf <config> import-catalog <AllPrintings.json|SET.json> [SET...]
+//The optional set codes restrict the import of an AllPrintings file
+func importCatalog(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: fivecolors -ecf <config> import-catalog <AllPrintings.json|SET.json> [SET...]")
+	}
+	file, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	codes := map[string]bool{}
+	for _, code := range args[1:] {
+		codes[strings.ToUpper(code)] = true
+	}
+	var total data.CatalogStats
+	err = data.ReadCatalog(file, func(set *data.CatalogSet) error {
+		if len(codes) > 0 && !codes[strings.ToUpper(set.Code)] {
+			return nil
+		}
+		return raizel.Execute(func(client raizel.Client) error {
+			stats, persistErr := set.Persist(client)
+			if persistErr != nil {
+				return persistErr
+			}
+			total.Add(stats)
+			fmt.Printf("%s %s: %s\n", strings.ToUpper(set.Code), set.Name, stats)
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+	l.Info("5colors.CatalogImported", l.String("File", args[0]), l.String("Stats", total.String()))
+	fmt.Printf("Total: %s\n", total)
+	return nil
+}
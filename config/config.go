@@ -1,11 +1,11 @@
 package config
 
 import (
+	"fmt"
+	"github.com/rjansen/fivecolors/security"
 	"github.com/rjansen/l"
 	"github.com/rjansen/migi"
 	raizelSQL "github.com/rjansen/raizel/sql"
-	//"github.com/rjansen/avalon/identity"
-	"fmt"
 	"sync"
 )
 
@@ -17,22 +17,22 @@ var (
 
 //Configuration holds all possible configurations structs
 type Configuration struct {
-	Version     string          `mapstructure:"version"`
-	Environment string          `mapstructure:"environment"`
-	AssetDir    string          `mapstructure:"assetDir"`
-	WebDir      string          `mapstructure:"webDir"`
-	Handler     HandlerConfig   `mapstructure:"handler"`
-	L           l.Configuration `mapstructure:"l"`
-	// Identity    identity.Configuration  `mapstructure:"identity"`
-	Raizel raizelSQL.Configuration `mapstructure:"raizel"`
+	Version     string                  `mapstructure:"version"`
+	Environment string                  `mapstructure:"environment"`
+	AssetDir    string                  `mapstructure:"assetDir"`
+	WebDir      string                  `mapstructure:"webDir"`
+	Handler     HandlerConfig           `mapstructure:"handler"`
+	L           l.Configuration         `mapstructure:"l"`
+	Security    security.Configuration  `mapstructure:"security"`
+	Raizel      raizelSQL.Configuration `mapstructure:"raizel"`
 }
 
 func (c Configuration) String() string {
-	return fmt.Sprintf("Configuration Version=%s Environment=%s AssetDir=%s L=%s Handler=%s Raizel=%s",
+	return fmt.Sprintf("Configuration Version=%s Environment=%s AssetDir=%s L=%s Handler=%s Security=%s Raizel=%s",
 		c.Version, c.Environment, c.AssetDir,
 		c.L.String(),
 		c.Handler.String(),
-		// c.Identity.String(),
+		c.Security.String(),
 		c.Raizel.String(),
 	)
 }
@@ -54,5 +54,7 @@ func Setup() error {
 	migi.SetEnvPrefix("")
 	migi.BindEnv("handler.port", "PORT")
 	migi.BindEnv("raizel.url", "DATABASE_URL")
+	//SESSION_SECRET signs the session tokens and is required to serve the api
+	migi.BindEnv("security.secret", "SESSION_SECRET")
 	return migi.Unmarshal(&Value)
 }
@@ -0,0 +1,50 @@
+package data
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/rjansen/l"
+	"github.com/rjansen/raizel"
+)
+
+//batchRows is the number of rows of one multi-row command, postgres accepts up to 65535 parameters by command
+const batchRows = 1000
+
+//batchInsert runs the insert once by batchRows rows. The insert has one %s where the values list goes,
+//every row must have the same number of columns
+func batchInsert(client raizel.Client, insert string, rows [][]interface{}) error {
+	for start := 0; start < len(rows); start += batchRows {
+		end := start + batchRows
+		if end > len(rows) {
+			end = len(rows)
+		}
+		var (
+			values bytes.Buffer
+			params = make([]interface{}, 0, (end-start)*len(rows[start]))
+		)
+		for i, row := range rows[start:end] {
+			if i > 0 {
+				values.WriteString(", ")
+			}
+			values.WriteByte('(')
+			for j, value := range row {
+				if j > 0 {
+					values.WriteString(", ")
+				}
+				params = append(params, value)
+				fmt.Fprintf(&values, "$%d", len(params))
+			}
+			values.WriteByte(')')
+		}
+		if _, err := client.Exec(fmt.Sprintf(insert, values.String()), params...); err != nil {
+			l.Error("data.BatchInsertErr",
+				l.Int("Rows.Start", start),
+				l.Int("Rows.End", end),
+				l.Err(err),
+			)
+			return err
+		}
+	}
+	return nil
+}
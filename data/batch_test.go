@@ -0,0 +1,125 @@
+package data_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/rjansen/fivecolors/data"
+	"github.com/rjansen/raizel"
+	"github.com/stretchr/testify/assert"
+)
+
+func bulkInventory(cards int) *data.Inventory {
+	inventory := &data.Inventory{ID: 3, IDPlayer: 1, Cards: make([]data.Card, cards)}
+	for i := range inventory.Cards {
+		inventory.Cards[i] = data.Card{ID: i + 1, InventoryCard: data.InventoryCard{Quantity: i%4 + 1}}
+	}
+	return inventory
+}
+
+func Test_InventoryPersistBatches(t *testing.T) {
+	client := &fakeClient{}
+	assert.Nil(t, bulkInventory(2500).Persist(client))
+	//The inventory update and three multi-row upserts of 1000, 1000 and 500 cards
+	assert.Len(t, client.commands, 4)
+	assert.Len(t, client.params[1], 7000)
+	assert.Len(t, client.params[3], 3500)
+	assert.Contains(t, client.commands[3], "($3494, $3495, $3496, $3497, $3498, $3499, $3500)\n")
+	assert.Contains(t, client.commands[3], "do update set quantity = excluded.quantity")
+	assert.True(t, client.committed)
+}
+
+func Test_InventoryAddSumsQuantities(t *testing.T) {
+	client := &fakeClient{}
+	inventory := &data.Inventory{ID: 3, IDPlayer: 1, Cards: []data.Card{
+		{ID: 1, InventoryCard: data.InventoryCard{Quantity: 2}},
+		{ID: 1, InventoryCard: data.InventoryCard{Quantity: 3}},
+		{ID: 2, InventoryCard: data.InventoryCard{Quantity: 1}},
+	}}
+	assert.Nil(t, inventory.Add(client))
+	assert.Len(t, client.commands, 2)
+	assert.Contains(t, client.commands[1], "do update set quantity = inventory_card.quantity + excluded.quantity")
+	assert.Equal(t, 5, client.params[1][6])
+	assert.Equal(t, 1, client.params[1][13])
+	assert.True(t, client.committed)
+}
+
+func Test_DeckPersistMergesRepeatedCards(t *testing.T) {
+	client := &fakeClient{}
+	deck := fakeDeck()
+	deck.Cards = append(deck.Cards,
+		data.Card{ID: 1, DeckCard: data.DeckCard{IDBoard: data.MainBoard, Quantity: 3}},
+		data.Card{ID: 1, DeckCard: data.DeckCard{IDBoard: data.SideBoard, Quantity: 1}},
+	)
+	assert.Nil(t, deck.Persist(client))
+	var upsert int
+	for i, command := range client.commands {
+		if strings.Contains(command, "insert into deck_card") {
+			upsert = i
+		}
+	}
+	assert.Contains(t, client.commands[upsert], "values ($1, $2, $3, $4), ($5, $6, $7, $8), ($9, $10, $11, $12)\n")
+	assert.Equal(t, []interface{}{7, 1, data.MainBoard, 3, 7, 2, data.MainBoard, 4, 7, 1, data.SideBoard, 1}, client.params[upsert])
+}
+
+//BenchmarkInventoryPersist updates up to 5,000 cards of a new inventory of the FIVECOLORS_TEST_DATABASE_URL postgres,
+//rowByRow is the former one upsert by card
+func BenchmarkInventoryPersist(b *testing.B) {
+	transactionDatabase(b)
+	idPlayer, cardIDs := testPlayerAndCards(b, 5000)
+	inventory := &data.Inventory{Name: "BenchmarkInventoryPersist", IDPlayer: idPlayer, Cards: make([]data.Card, len(cardIDs))}
+	for i, id := range cardIDs {
+		inventory.Cards[i] = data.Card{ID: id, InventoryCard: data.InventoryCard{Quantity: i%4 + 1}}
+	}
+	if err := raizel.Execute(inventory.Persist); err != nil {
+		b.Fatal(err)
+	}
+	defer raizel.Execute(func(client raizel.Client) error {
+		if _, err := client.Exec("delete from inventory_card where id_inventory = $1", inventory.ID); err != nil {
+			return err
+		}
+		_, err := client.Exec("delete from inventory where id = $1", inventory.ID)
+		return err
+	})
+	b.Run("rowByRow", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			err := raizel.Execute(func(client raizel.Client) error {
+				for _, card := range inventory.Cards {
+					inventoryCard := card.InventoryCard
+					if err := inventoryCard.Normalize(); err != nil {
+						return err
+					}
+					_, err := client.Exec(`
+						insert into inventory_card (id_inventory, id_card, finish, condition, language, graded, quantity)
+						values ($1, $2, $3, $4, $5, $6, $7)
+						on conflict(id_inventory, id_card, finish, condition, language, graded)
+						do update set quantity = excluded.quantity`,
+						inventory.ID, card.ID, inventoryCard.Finish, inventoryCard.Condition,
+						inventoryCard.Language, inventoryCard.Graded, inventoryCard.Quantity)
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("batch", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			if err := raizel.Execute(inventory.Persist); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func ExampleInventory_Persist() {
+	client := &fakeClient{}
+	bulkInventory(5000).Persist(client)
+	fmt.Println(len(client.commands), "round trips")
+	// Output: 6 round trips
+}
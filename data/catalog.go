@@ -0,0 +1,392 @@
+package data
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/rjansen/l"
+	"github.com/rjansen/raizel"
+)
+
+var (
+	//ErrInvalidCatalog is raised when the file is not a MTGJSON AllPrintings or set file
+	ErrInvalidCatalog = errors.New("data.Catalog.InvalidErr: Message='File is not a MTGJSON AllPrintings or set file'")
+
+	manaSymbol  = regexp.MustCompile(`\{([^}]+)\}`)
+	manaSymbols = map[string]string{
+		"W": "White",
+		"U": "Blue",
+		"B": "Black",
+		"R": "Red",
+		"G": "Green",
+		"C": "Colorless",
+		"S": "Snow",
+		"P": "Phyrexian",
+	}
+	//catalogRarities maps the MTGJSON rarity to the id_rarity values, special and bonus printings use 0 like the basic lands
+	catalogRarities = map[string]int{
+		"common":   RarityCommon,
+		"uncommon": 2,
+		"rare":     3,
+		"mythic":   4,
+	}
+)
+
+//CatalogSet is one set of a MTGJSON AllPrintings or per-set file
+type CatalogSet struct {
+	Code   string         `json:"code"`
+	Name   string         `json:"name"`
+	Cards  []CatalogCard  `json:"cards"`
+	Tokens []CatalogToken `json:"tokens"`
+}
+
+//CatalogCard is one card printing of a MTGJSON set
+type CatalogCard struct {
+	//UUID is the MTGJSON identifier of the printing, the AllPrices files are keyed by it
+	UUID        string `json:"uuid"`
+	Name        string `json:"name"`
+	Number      string `json:"number"`
+	Side        string `json:"side"`
+	ManaCost    string `json:"manaCost"`
+	Type        string `json:"type"`
+	Text        string `json:"text"`
+	FlavorText  string `json:"flavorText"`
+	Artist      string `json:"artist"`
+	Rarity      string `json:"rarity"`
+	Power       string `json:"power"`
+	Toughness   string `json:"toughness"`
+	Loyalty     string `json:"loyalty"`
+	Identifiers struct {
+		MultiverseID string `json:"multiverseId"`
+	} `json:"identifiers"`
+}
+
+//CatalogToken is one token printing of a MTGJSON set
+type CatalogToken struct {
+	Name      string   `json:"name"`
+	Type      string   `json:"type"`
+	Text      string   `json:"text"`
+	Colors    []string `json:"colors"`
+	Power     string   `json:"power"`
+	Toughness string   `json:"toughness"`
+	Artist    string   `json:"artist"`
+}
+
+//CatalogCount is the number of inserted and updated rows of one table
+type CatalogCount struct {
+	Inserted int `json:"inserted"`
+	Updated  int `json:"updated"`
+}
+
+//CatalogStats is the result of a catalog import
+type CatalogStats struct {
+	Expansions CatalogCount `json:"expansions"`
+	Cards      CatalogCount `json:"cards"`
+	Tokens     CatalogCount `json:"tokens"`
+}
+
+//Add sums the other stats into these stats
+func (s *CatalogStats) Add(other CatalogStats) {
+	s.Expansions.Inserted += other.Expansions.Inserted
+	s.Expansions.Updated += other.Expansions.Updated
+	s.Cards.Inserted += other.Cards.Inserted
+	s.Cards.Updated += other.Cards.Updated
+	s.Tokens.Inserted += other.Tokens.Inserted
+	s.Tokens.Updated += other.Tokens.Updated
+}
+
+func (s CatalogStats) String() string {
+	return fmt.Sprintf("Expansions(inserted=%d updated=%d) Cards(inserted=%d updated=%d) Tokens(inserted=%d updated=%d)",
+		s.Expansions.Inserted, s.Expansions.Updated,
+		s.Cards.Inserted, s.Cards.Updated,
+		s.Tokens.Inserted, s.Tokens.Updated,
+	)
+}
+
+//ReadCatalog streams the sets of a MTGJSON file to the setFunc. AllPrintings files are read one set at a time,
+//so the whole file is never held in memory. Per-set files call setFunc only once
+func ReadCatalog(r io.Reader, setFunc func(*CatalogSet) error) error {
+	decoder := json.NewDecoder(r)
+	if err := expectDelim(decoder, '{'); err != nil {
+		return err
+	}
+	for decoder.More() {
+		key, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		if key != "data" {
+			var skip json.RawMessage
+			if err := decoder.Decode(&skip); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := expectDelim(decoder, '{'); err != nil {
+			return err
+		}
+		setFields := map[string]json.RawMessage{}
+		for decoder.More() {
+			token, err := decoder.Token()
+			if err != nil {
+				return err
+			}
+			key, _ := token.(string)
+			if key != "" && unicode.IsLower(rune(key[0])) {
+				//Per-set file: data holds the set fields instead of sets by code
+				var field json.RawMessage
+				if err := decoder.Decode(&field); err != nil {
+					return err
+				}
+				setFields[key] = field
+				continue
+			}
+			var set CatalogSet
+			if err := decoder.Decode(&set); err != nil {
+				return err
+			}
+			if err := setFunc(&set); err != nil {
+				return err
+			}
+		}
+		if len(setFields) > 0 {
+			raw, err := json.Marshal(setFields)
+			if err != nil {
+				return err
+			}
+			var set CatalogSet
+			if err := json.Unmarshal(raw, &set); err != nil {
+				return err
+			}
+			if set.Code == "" {
+				return ErrInvalidCatalog
+			}
+			if err := setFunc(&set); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return ErrInvalidCatalog
+}
+
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return ErrInvalidCatalog
+	}
+	return nil
+}
+
+//ManacostLabel converts a MTGJSON mana cost, {2}{B}{W/U}, to the manacost_label form, 2, Black, White/Blue
+func ManacostLabel(manaCost string) string {
+	var symbols []string
+	for _, match := range manaSymbol.FindAllStringSubmatch(manaCost, -1) {
+		parts := strings.Split(match[1], "/")
+		for i, part := range parts {
+			if name, found := manaSymbols[part]; found {
+				parts[i] = name
+			}
+		}
+		symbols = append(symbols, strings.Join(parts, "/"))
+	}
+	return strings.Join(symbols, ", ")
+}
+
+//IDRarity returns the id_rarity of the card printing
+func (c CatalogCard) IDRarity() int {
+	return catalogRarities[c.Rarity]
+}
+
+//CombatpowerLabel returns power/toughness for creatures and the loyalty for planeswalkers
+func (c CatalogCard) CombatpowerLabel() string {
+	if c.Power != "" || c.Toughness != "" {
+		return c.Power + "/" + c.Toughness
+	}
+	return c.Loyalty
+}
+
+//Color returns the spelled token colors, Green, White
+func (t CatalogToken) Color() string {
+	colors := make([]string, len(t.Colors))
+	for i, color := range t.Colors {
+		colors[i] = manaSymbols[color]
+	}
+	return strings.Join(colors, ", ")
+}
+
+func emptyAsNull(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+//Persist upserts the set into expansion, card and token. Expansions are matched by code, or by name for
+//the rows loaded before expansion.code existed, cards by expansion and multiverse_number (collector number)
+//and tokens by expansion, name, type, power and toughness. The label, id_rarity and id_asset of the existing
+//rows are kept, new rows use the name as label and 0 as id_asset until the images are loaded.
+//The set runs in one transaction, a failed set is not persisted at all
+func (s *CatalogSet) Persist(client raizel.Client) (CatalogStats, error) {
+	if strings.TrimSpace(s.Code) == "" || strings.TrimSpace(s.Name) == "" {
+		return CatalogStats{}, errors.New("data.CatalogSet.PersistError: Message='CatalogSet.Code or CatalogSet.Name is empty'")
+	}
+	var stats CatalogStats
+	err := InTransaction(client, func(tx raizel.Client) error {
+		var persistErr error
+		stats, persistErr = s.persist(tx)
+		return persistErr
+	})
+	if err != nil {
+		return CatalogStats{}, err
+	}
+	return stats, nil
+}
+
+func (s *CatalogSet) persist(client raizel.Client) (CatalogStats, error) {
+	var stats CatalogStats
+	expansion := Expansion{Code: strings.ToUpper(s.Code), Name: s.Name}
+	inserted, err := expansion.upsert(client)
+	if err != nil {
+		return stats, err
+	}
+	stats.Expansions.add(inserted)
+
+	for _, card := range s.Cards {
+		if card.Side != "" && card.Side != "a" {
+			//The other faces share the collector number and the full name of the a side
+			continue
+		}
+		if inserted, err = card.upsert(client, expansion.ID); err != nil {
+			l.Error("data.CatalogSet.CardErr",
+				l.String("Code", expansion.Code),
+				l.String("Name", card.Name),
+				l.String("Number", card.Number),
+				l.Err(err),
+			)
+			return stats, err
+		}
+		stats.Cards.add(inserted)
+	}
+	for _, token := range s.Tokens {
+		if inserted, err = token.upsert(client, expansion.ID); err != nil {
+			l.Error("data.CatalogSet.TokenErr",
+				l.String("Code", expansion.Code),
+				l.String("Name", token.Name),
+				l.Err(err),
+			)
+			return stats, err
+		}
+		stats.Tokens.add(inserted)
+	}
+	l.Info("data.CatalogSet.Persisted",
+		l.String("Code", expansion.Code),
+		l.Int("ID", expansion.ID),
+		l.String("Stats", stats.String()),
+	)
+	return stats, nil
+}
+
+func (c *CatalogCount) add(inserted bool) {
+	if inserted {
+		c.Inserted++
+	} else {
+		c.Updated++
+	}
+}
+
+func fetchInt(value *int) func(raizel.Fetchable) error {
+	return func(f raizel.Fetchable) error {
+		return f.Scan(value)
+	}
+}
+
+func (e *Expansion) upsert(client raizel.Client) (bool, error) {
+	err := client.QueryOne(`select e.id from expansion e where upper(e.code) = $1`, fetchInt(&e.ID), e.Code)
+	if err == raizel.ErrNotFound {
+		err = client.QueryOne(`select e.id from expansion e where e.code is null and e.name = $1`, fetchInt(&e.ID), e.Name)
+	}
+	if err == raizel.ErrNotFound {
+		insert := `insert into expansion (id, name, label, code) values (nextval('sq_expansion'), $1, $1, $2) returning id`
+		return true, client.QueryOne(insert, fetchInt(&e.ID), e.Name, e.Code)
+	}
+	if err != nil {
+		return false, err
+	}
+	_, err = client.Exec(`update expansion set name = $1, code = $2 where id = $3`, e.Name, e.Code, e.ID)
+	return false, err
+}
+
+func (c CatalogCard) upsert(client raizel.Client, idExpansion int) (bool, error) {
+	var id int
+	err := client.QueryOne(`select c.id from card c where c.id_expansion = $1 and c.multiverse_number = $2`,
+		fetchInt(&id), idExpansion, c.Number)
+	if err == raizel.ErrNotFound {
+		insert := `
+			insert into card (id, multiverseid, multiverse_number, name, label, text,
+				manacost_label, combatpower_label, type_label, id_rarity, flavor, artist,
+				rate, rate_votes, id_asset, id_expansion, uuid)
+			values (nextval('sq_card'), $1, $2, $3, $3, $4, $5, $6, $7, $8, $9, $10, 0, 0, 0, $11, $12)
+			returning id
+		`
+		return true, client.QueryOne(insert, fetchInt(&id),
+			emptyAsNull(c.Identifiers.MultiverseID), c.Number, c.Name, emptyAsNull(c.Text),
+			emptyAsNull(ManacostLabel(c.ManaCost)), emptyAsNull(c.CombatpowerLabel()), c.Type, c.IDRarity(),
+			emptyAsNull(c.FlavorText), c.Artist, idExpansion, emptyAsNull(c.UUID),
+		)
+	}
+	if err != nil {
+		return false, err
+	}
+	update := `
+		update card set multiverseid = coalesce($1, multiverseid), name = $2, text = $3,
+			manacost_label = $4, combatpower_label = $5, type_label = $6, flavor = $7, artist = $8,
+			uuid = coalesce($10, uuid)
+		where id = $9
+	`
+	_, err = client.Exec(update,
+		emptyAsNull(c.Identifiers.MultiverseID), c.Name, emptyAsNull(c.Text),
+		emptyAsNull(ManacostLabel(c.ManaCost)), emptyAsNull(c.CombatpowerLabel()), c.Type,
+		emptyAsNull(c.FlavorText), c.Artist, id, emptyAsNull(c.UUID),
+	)
+	return false, err
+}
+
+func (t CatalogToken) upsert(client raizel.Client, idExpansion int) (bool, error) {
+	var id int
+	query := `
+		select t.id from token t
+		where t.id_expansion = $1 and t.name = $2 and t.type = $3
+			and coalesce(t.power, '') = $4 and coalesce(t.toughness, '') = $5
+	`
+	err := client.QueryOne(query, fetchInt(&id), idExpansion, t.Name, t.Type, t.Power, t.Toughness)
+	combatPower := ""
+	if t.Power != "" || t.Toughness != "" {
+		combatPower = t.Power + "/" + t.Toughness
+	}
+	if err == raizel.ErrNotFound {
+		insert := `
+			insert into token (id, name, label, text, color, combat_power, power, toughness, type, artist, id_asset, id_expansion)
+			values (nextval('sq_token'), $1, $1, $2, $3, $4, $5, $6, $7, $8, 0, $9)
+			returning id
+		`
+		return true, client.QueryOne(insert, fetchInt(&id),
+			t.Name, emptyAsNull(t.Text), emptyAsNull(t.Color()), emptyAsNull(combatPower),
+			emptyAsNull(t.Power), emptyAsNull(t.Toughness), t.Type, t.Artist, idExpansion,
+		)
+	}
+	if err != nil {
+		return false, err
+	}
+	update := `update token set text = $1, color = $2, combat_power = $3, artist = $4 where id = $5`
+	_, err = client.Exec(update, emptyAsNull(t.Text), emptyAsNull(t.Color()), emptyAsNull(combatPower), t.Artist, id)
+	return false, err
+}
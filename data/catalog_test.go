@@ -0,0 +1,69 @@
+package data_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rjansen/fivecolors/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ReadAllPrintingsCatalog(t *testing.T) {
+	catalog := `{"meta": {"version": "5.2.2"}, "data": {
+		"10E": {"code": "10E", "name": "Tenth Edition", "cards": [
+			{"name": "Lightning Bolt", "number": "211", "manaCost": "{R}", "rarity": "common", "identifiers": {"multiverseId": "129732"}}
+		]},
+		"DOM": {"code": "DOM", "name": "Dominaria", "cards": [], "tokens": [
+			{"name": "Saproling", "type": "Token Creature — Saproling", "colors": ["G"], "power": "1", "toughness": "1"}
+		]}
+	}}`
+	var sets []*data.CatalogSet
+	err := data.ReadCatalog(strings.NewReader(catalog), func(set *data.CatalogSet) error {
+		sets = append(sets, set)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Len(t, sets, 2)
+	assert.Equal(t, "Tenth Edition", sets[0].Name)
+	assert.Equal(t, "129732", sets[0].Cards[0].Identifiers.MultiverseID)
+	assert.Equal(t, "Green", sets[1].Tokens[0].Color())
+}
+
+func Test_ReadSetCatalog(t *testing.T) {
+	catalog := `{"data": {"baseSetSize": 269, "code": "DOM", "name": "Dominaria", "cards": [
+		{"name": "Shalai, Voice of Plenty", "number": "35", "manaCost": "{3}{W}", "rarity": "mythic", "power": "3", "toughness": "4"}
+	]}, "meta": {"version": "5.2.2"}}`
+	var sets []*data.CatalogSet
+	err := data.ReadCatalog(strings.NewReader(catalog), func(set *data.CatalogSet) error {
+		sets = append(sets, set)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Len(t, sets, 1)
+	assert.Equal(t, "DOM", sets[0].Code)
+	assert.Equal(t, 4, sets[0].Cards[0].IDRarity())
+	assert.Equal(t, "3/4", sets[0].Cards[0].CombatpowerLabel())
+
+	err = data.ReadCatalog(strings.NewReader(`[]`), func(*data.CatalogSet) error { return nil })
+	assert.Equal(t, data.ErrInvalidCatalog, err)
+}
+
+func Test_CatalogSetPersistRollsBack(t *testing.T) {
+	client := &fakeClient{failOn: "update token"}
+	set := data.CatalogSet{Code: "m10", Name: "Magic 2010",
+		Cards:  []data.CatalogCard{{Name: "Lightning Bolt", Number: "146"}},
+		Tokens: []data.CatalogToken{{Name: "Goblin"}},
+	}
+	stats, err := set.Persist(client)
+	assert.Equal(t, errFakeExec, err)
+	assert.Equal(t, data.CatalogStats{}, stats)
+	assert.Equal(t, 1, client.begun)
+	assert.True(t, client.rolledBack)
+	assert.False(t, client.committed)
+}
+
+func Test_ManacostLabel(t *testing.T) {
+	assert.Equal(t, "2, Black, Black", data.ManacostLabel("{2}{B}{B}"))
+	assert.Equal(t, "X, White/Blue, Green/Phyrexian", data.ManacostLabel("{X}{W/U}{G/P}"))
+	assert.Equal(t, "", data.ManacostLabel(""))
+}
@@ -0,0 +1,278 @@
+package data
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/rjansen/l"
+	"github.com/rjansen/raizel"
+)
+
+const (
+	//CollectionName is the card name column of a collection CSV
+	CollectionName = "name"
+	//CollectionSet is the set code or set name column of a collection CSV
+	CollectionSet = "set"
+	//CollectionNumber is the collector number (multiverse_number) column of a collection CSV
+	CollectionNumber = "number"
+	//CollectionQuantity is the copies column of a collection CSV, an empty quantity is one copy
+	CollectionQuantity = "quantity"
+	//CollectionFoil is the foil or printing column of a collection CSV
+	CollectionFoil = "foil"
+	//CollectionCondition is the condition column of a collection CSV
+	CollectionCondition = "condition"
+	//CollectionLanguage is the language column of a collection CSV
+	CollectionLanguage = "language"
+)
+
+var (
+	//ErrInvalidCollectionColumns is raised when the CSV header has no name column nor set and number columns
+	ErrInvalidCollectionColumns = errors.New("data.Collection.InvalidColumnsErr: Message='CSV requires a name column or set and number columns'")
+
+	//CollectionFields are the collection fields in the column order of the CSV export
+	CollectionFields = []string{
+		CollectionQuantity, CollectionName, CollectionSet, CollectionNumber, CollectionFoil, CollectionCondition, CollectionLanguage,
+	}
+	//collectionHeaders are the header names, lower case, of the collection fields used by Deckbox, TCGplayer,
+	//Moxfield and spreadsheets. The first header found wins
+	collectionHeaders = map[string][]string{
+		CollectionName:      {"name", "card name", "card"},
+		CollectionSet:       {"set code", "edition code", "set", "edition", "set name"},
+		CollectionNumber:    {"collector number", "card number", "number", "collector_number", "no"},
+		CollectionQuantity:  {"quantity", "count", "qty", "amount"},
+		CollectionFoil:      {"foil", "printing", "finish"},
+		CollectionCondition: {"condition"},
+		CollectionLanguage:  {"language", "lang"},
+	}
+)
+
+//CollectionRow is one card row of a collection CSV and the card it resolved to
+type CollectionRow struct {
+	Line      int    `json:"line"`
+	Name      string `json:"name"`
+	Set       string `json:"set,omitempty"`
+	Number    string `json:"number,omitempty"`
+	Quantity  int    `json:"quantity"`
+	Finish    string `json:"finish"`
+	Condition string `json:"condition"`
+	Language  string `json:"language"`
+	IDCard    int    `json:"idCard,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+//ParseCollectionCSV reads the collection rows of the CSV. The columns map the collection fields to header names,
+//the fields out of columns are found by the usual header names. Rows with an invalid quantity, finish, condition
+//or language carry the Reason
+func ParseCollectionCSV(r io.Reader, columns map[string]string) ([]CollectionRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, ErrInvalidCollectionColumns
+		}
+		return nil, err
+	}
+	indexes := headerIndexes(header, collectionHeaders, columns)
+	if _, hasName := indexes[CollectionName]; !hasName {
+		_, hasSet := indexes[CollectionSet]
+		_, hasNumber := indexes[CollectionNumber]
+		if !hasSet || !hasNumber {
+			return nil, ErrInvalidCollectionColumns
+		}
+	}
+	var rows []CollectionRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		field := func(name string) string {
+			if index, found := indexes[name]; found && index < len(record) {
+				return strings.TrimSpace(record[index])
+			}
+			return ""
+		}
+		row := CollectionRow{
+			Line:     line,
+			Name:     field(CollectionName),
+			Set:      field(CollectionSet),
+			Number:   field(CollectionNumber),
+			Quantity: 1,
+		}
+		if row.Name == "" && row.Set == "" && row.Number == "" {
+			continue
+		}
+		var validFinish, validCondition, validLanguage bool
+		row.Finish, validFinish = ParseFinish(field(CollectionFoil))
+		row.Condition, validCondition = ParseCondition(field(CollectionCondition))
+		row.Language, validLanguage = ParseLanguage(field(CollectionLanguage))
+		if quantity := field(CollectionQuantity); quantity != "" {
+			if row.Quantity, err = strconv.Atoi(quantity); err != nil || row.Quantity <= 0 {
+				row.Reason = "invalid quantity"
+			}
+		}
+		switch {
+		case row.Reason != "":
+		case !validFinish:
+			row.Reason = "invalid finish"
+		case !validCondition:
+			row.Reason = "invalid condition"
+		case !validLanguage:
+			row.Reason = "invalid language"
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+//headerIndexes finds the column index of the fields by their header names, the columns override the header names
+func headerIndexes(header []string, fieldHeaders map[string][]string, columns map[string]string) map[string]int {
+	positions := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, found := positions[name]; !found {
+			positions[name] = i
+		}
+	}
+	indexes := map[string]int{}
+	for field, headers := range fieldHeaders {
+		if column := strings.TrimSpace(columns[field]); column != "" {
+			headers = []string{strings.ToLower(column)}
+		}
+		for _, name := range headers {
+			if index, found := positions[name]; found {
+				indexes[field] = index
+				break
+			}
+		}
+	}
+	return indexes
+}
+
+//resolveCard finds the Card ID by name, set code or name and collector number, any of them may be empty but not all.
+//When many printings match the newest expansion wins
+func resolveCard(client raizel.Client, name, set, number string) (int, error) {
+	query := `
+		select c.id
+		from card c
+			left join expansion e on c.id_expansion = e.id
+		where ($1 = '' or lower(c.name) = lower($1))
+			and ($2 = '' or upper(e.code) = upper($2) or lower(e.name) = lower($2))
+			and ($3 = '' or c.multiverse_number = $3)
+		order by e.id desc, c.id desc
+		limit 1
+	`
+	var id int
+	return id, client.QueryOne(query, fetchInt(&id), name, set, number)
+}
+
+//cardKey is the name, set code or name and collector number resolving a card
+type cardKey struct {
+	name, set, number string
+}
+
+//resolveCards finds, in one query, the Card ID of every key like resolveCard. The not found keys get the ID zero
+func resolveCards(client raizel.Client, keys []cardKey) ([]int, error) {
+	ids := make([]int, len(keys))
+	if len(keys) == 0 {
+		return ids, nil
+	}
+	names, sets, numbers := make([]string, len(keys)), make([]string, len(keys)), make([]string, len(keys))
+	for index, key := range keys {
+		names[index], sets[index], numbers[index] = key.name, key.set, key.number
+	}
+	query := `
+		select k.position, r.id
+		from unnest($1::text[], $2::text[], $3::text[]) with ordinality k(name, set_code, number, position)
+			join lateral (
+				select c.id
+				from card c
+					left join expansion e on c.id_expansion = e.id
+				where (k.name = '' or lower(c.name) = lower(k.name))
+					and (k.set_code = '' or upper(e.code) = upper(k.set_code) or lower(e.name) = lower(k.set_code))
+					and (k.number = '' or c.multiverse_number = k.number)
+				order by e.id desc, c.id desc
+				limit 1
+			) r on true
+	`
+	err := client.Query(query, func(i raizel.Iterable) error {
+		for i.Next() {
+			var position, id int
+			if err := i.Scan(&position, &id); err != nil {
+				return err
+			}
+			ids[position-1] = id
+		}
+		return nil
+	}, pq.Array(names), pq.Array(sets), pq.Array(numbers))
+	return ids, err
+}
+
+//ResolveCollection finds, in one query, the Card ID of every row without Reason by name, set code or name and
+//collector number, any of them may be empty but not all. When many printings match the newest expansion wins.
+//The rows not found get the Reason and the resolved rows become Inventory.Cards, summing the copies by card,
+//finish, condition and language
+func (i *Inventory) ResolveCollection(client raizel.Client, rows []CollectionRow) error {
+	type copyKey struct {
+		idCard                      int
+		finish, condition, language string
+	}
+	var keys []cardKey
+	keyIndex := map[cardKey]int{}
+	for index := range rows {
+		row := &rows[index]
+		if row.Reason != "" {
+			continue
+		}
+		if row.Name == "" && (row.Set == "" || row.Number == "") {
+			row.Reason = "name or set and number required"
+			continue
+		}
+		key := cardKey{row.Name, row.Set, row.Number}
+		if _, found := keyIndex[key]; !found {
+			keyIndex[key] = len(keys)
+			keys = append(keys, key)
+		}
+	}
+	ids, err := resolveCards(client, keys)
+	if err != nil {
+		return err
+	}
+	copyIndex := map[copyKey]int{}
+	i.Cards = nil
+	for index := range rows {
+		row := &rows[index]
+		if row.Reason != "" {
+			continue
+		}
+		if row.IDCard = ids[keyIndex[cardKey{row.Name, row.Set, row.Number}]]; row.IDCard == 0 {
+			row.Reason = "card not found"
+			continue
+		}
+		key := copyKey{row.IDCard, row.Finish, row.Condition, row.Language}
+		if position, found := copyIndex[key]; found {
+			i.Cards[position].InventoryCard.Quantity += row.Quantity
+			continue
+		}
+		copyIndex[key] = len(i.Cards)
+		i.Cards = append(i.Cards, Card{ID: row.IDCard, InventoryCard: InventoryCard{IDInventory: i.ID, Quantity: row.Quantity,
+			Finish: row.Finish, Condition: row.Condition, Language: row.Language}})
+	}
+	l.Debug("data.Inventory.ResolvedCollection",
+		l.Int("ID", i.ID),
+		l.Int("Rows.Len", len(rows)),
+		l.Int("Cards.Len", len(i.Cards)),
+	)
+	return nil
+}
@@ -0,0 +1,83 @@
+package data_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/rjansen/fivecolors/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseCollectionCSVDeckbox(t *testing.T) {
+	csv := "\ufeffCount,Tradelist Count,Name,Edition,Card Number,Condition,Language,Foil\n" +
+		"4,0,Lightning Bolt,Magic 2010,146,Near Mint,English,\n" +
+		"1,0,\"Fire // Ice\",Apocalypse,128,Played,Japanese,foil\n" +
+		",,,,,,,\n" +
+		"x,0,Goblin Guide,Zendikar,126,Near Mint,English,\n"
+	rows, err := data.ParseCollectionCSV(strings.NewReader(csv), nil)
+	assert.Nil(t, err)
+	assert.Len(t, rows, 3)
+	assert.Equal(t, data.CollectionRow{Line: 2, Name: "Lightning Bolt", Set: "Magic 2010", Number: "146", Quantity: 4,
+		Finish: data.FinishNonFoil, Condition: "NM", Language: "en"}, rows[0])
+	assert.Equal(t, "Fire // Ice", rows[1].Name)
+	assert.Equal(t, data.FinishFoil, rows[1].Finish)
+	assert.Equal(t, "HP", rows[1].Condition)
+	assert.Equal(t, "ja", rows[1].Language)
+	assert.Equal(t, 5, rows[2].Line)
+	assert.Equal(t, "invalid quantity", rows[2].Reason)
+}
+
+func Test_ParseCollectionCSVColumns(t *testing.T) {
+	_, err := data.ParseCollectionCSV(strings.NewReader("Qty,Title,Code,Nr\n2,Counterspell,TMP,57\n"), nil)
+	assert.Equal(t, data.ErrInvalidCollectionColumns, err)
+	rows, err := data.ParseCollectionCSV(strings.NewReader("Qty,Title,Code,Nr\n2,Counterspell,TMP,57\n"),
+		map[string]string{data.CollectionName: "Title"})
+	assert.Nil(t, err)
+	assert.Equal(t, []data.CollectionRow{{Line: 2, Name: "Counterspell", Quantity: 2, Finish: data.FinishNonFoil,
+		Condition: data.DefaultCondition, Language: data.DefaultLanguage}}, rows)
+
+	rows, err = data.ParseCollectionCSV(strings.NewReader("Qty,Code,Nr\n,TMP,57\n"),
+		map[string]string{data.CollectionSet: "code", data.CollectionNumber: "Nr"})
+	assert.Nil(t, err)
+	assert.Equal(t, []data.CollectionRow{{Line: 2, Set: "TMP", Number: "57", Quantity: 1, Finish: data.FinishNonFoil,
+		Condition: data.DefaultCondition, Language: data.DefaultLanguage}}, rows)
+
+	rows, err = data.ParseCollectionCSV(strings.NewReader("Name,Foil,Condition,Language\n"+
+		"Shock,shiny,NM,en\nShock,,Worn,en\nShock,,,Elvish\n"), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "invalid finish", rows[0].Reason)
+	assert.Equal(t, "invalid condition", rows[1].Reason)
+	assert.Equal(t, "invalid language", rows[2].Reason)
+}
+
+func Test_InventoryResolveCollection(t *testing.T) {
+	rows := []data.CollectionRow{
+		{Line: 2, Name: "Lightning Bolt", Quantity: 4, Finish: data.FinishNonFoil, Condition: "NM", Language: "en"},
+		{Line: 3, Name: "Lightning Bolt", Set: "M10", Quantity: 1, Finish: data.FinishFoil, Condition: "NM", Language: "en"},
+		{Line: 6, Name: "Lightning Bolt", Quantity: 2, Finish: data.FinishNonFoil, Condition: "NM", Language: "en"},
+		{Line: 4, Name: "Goblin Guide", Quantity: 0, Reason: "invalid quantity"},
+		{Line: 5, Set: "M10", Quantity: 1},
+		{Line: 7, Name: "Lightning Blot", Quantity: 1, Finish: data.FinishNonFoil, Condition: "NM", Language: "en"},
+	}
+	inventory := data.Inventory{ID: 3}
+	client := &fakeClient{results: map[string][][]interface{}{"unnest": {{1, 7}, {2, 7}}}}
+	assert.Nil(t, inventory.ResolveCollection(client, rows))
+	assert.Len(t, client.commands, 1)
+	assert.Equal(t, []interface{}{
+		pq.Array([]string{"Lightning Bolt", "Lightning Bolt", "Lightning Blot"}),
+		pq.Array([]string{"", "M10", ""}),
+		pq.Array([]string{"", "", ""}),
+	}, client.params[0])
+	assert.Equal(t, 7, rows[0].IDCard)
+	assert.Equal(t, 7, rows[2].IDCard)
+	assert.Equal(t, "invalid quantity", rows[3].Reason)
+	assert.Equal(t, "name or set and number required", rows[4].Reason)
+	assert.Equal(t, "card not found", rows[5].Reason)
+	if assert.Len(t, inventory.Cards, 2) {
+		assert.Equal(t, data.InventoryCard{IDInventory: 3, Quantity: 6, Finish: data.FinishNonFoil, Condition: "NM",
+			Language: "en"}, inventory.Cards[0].InventoryCard)
+		assert.Equal(t, 1, inventory.Cards[1].InventoryCard.Quantity)
+		assert.Equal(t, data.FinishFoil, inventory.Cards[1].InventoryCard.Finish)
+	}
+}
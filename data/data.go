@@ -20,6 +20,10 @@ const (
 )
 
 var (
+	//ErrPlayerExists is raised when a new player is registered with a username already taken
+	ErrPlayerExists = errors.New("data.Player.ExistsErr: Message='Player.Username already exists'")
+	//ErrInvalidInventory is raised when an inventory is persisted without an ID nor a name to create it
+	ErrInvalidInventory     = errors.New("data.Inventory.InvalidErr: Message='Inventory.ID or Inventory.Name is required'")
 	selectLimit             = 100
 	primaryKeyViolation     = regexp.MustCompile(`Duplicate.*PRIMARY`)
 	primaryKeyViolationByID = regexp.MustCompile(`duplicate key value`)
@@ -42,13 +46,21 @@ type Card struct {
 	Artist           string        `json:"artist"`
 	Expansion        Expansion     `json:"expansion"`
 	InventoryCard    InventoryCard `json:"inventoryCard"`
-	DeckCard         DeckCard      `json:"deckCard"`
-	IDAsset          int           `json:"idAsset"`
+	//Copies are the inventory copies by finish, condition, language and graded, InventoryCard.Quantity sums them
+	Copies   []InventoryCard `json:"copies,omitempty"`
+	DeckCard DeckCard        `json:"deckCard"`
+	IDAsset  int             `json:"idAsset"`
 }
 
+//InventoryCard is the copies of a card in an inventory, by finish, condition, language and graded
 type InventoryCard struct {
-	IDInventory int `json:"idInvetory"`
-	Quantity    int `json:"quantity"`
+	IDInventory int    `json:"idInvetory"`
+	Quantity    int    `json:"quantity"`
+	Finish      string `json:"finish,omitempty"`
+	Condition   string `json:"condition,omitempty"`
+	Language    string `json:"language,omitempty"`
+	//Graded is the grading company and grade, empty for raw copies
+	Graded string `json:"graded,omitempty"`
 }
 
 type DeckCard struct {
@@ -63,7 +75,7 @@ func (c *Card) FetchFullWithDeckCard(fetchable raizel.Fetchable) error {
 		&c.IDRarity, &c.Flavor, &c.Artist,
 		&c.Rate, &c.RateVotes, &c.IDAsset,
 		&c.DeckCard.IDDeck, &c.DeckCard.IDBoard, &c.DeckCard.Quantity,
-		&c.Expansion.ID, &c.Expansion.Name, &c.Expansion.IDAsset)
+		&c.Expansion.ID, &c.Expansion.Code, &c.Expansion.Name, &c.Expansion.IDAsset)
 }
 
 func (c *Card) FetchFull(fetchable raizel.Fetchable) error {
@@ -74,6 +86,7 @@ func (c *Card) FetchFull(fetchable raizel.Fetchable) error {
 		&c.InventoryCard.IDInventory, &c.InventoryCard.Quantity)
 }
 
+//ReadByID reads the card and its quantity in the Card.InventoryCard.IDInventory inventory
 func (c *Card) ReadByID(client raizel.Client) error {
 	if c.ID <= 0 {
 		return errors.New("data.Card.ReadErr: Message='Card.ID is empty'")
@@ -87,12 +100,13 @@ func (c *Card) ReadByID(client raizel.Client) error {
         from card c
             left join expansion e on c.id_expansion = e.id
             left join expansion_asset a on a.id_expansion = e.id and a.id_rarity = c.id_rarity
-            left join inventory_card i on i.id_inventory = 0 and i.id_card = c.id
+            left join ` + inventoryCopies("$2", nil) + ` on i.id_card = c.id
         where c.id = $1
 	`
-	return client.QueryOne(query, c.FetchFull, c.ID)
+	return client.QueryOne(query, c.FetchFull, c.ID, c.InventoryCard.IDInventory)
 }
 
+//ReadByName reads the card and its quantity in the Card.InventoryCard.IDInventory inventory
 func (c *Card) ReadByName(client raizel.Client) error {
 	if strings.TrimSpace(c.Name) == "" {
 		return errors.New("data.Card.ReadErr: Message='Card.ID is empty'")
@@ -106,10 +120,35 @@ func (c *Card) ReadByName(client raizel.Client) error {
         from card c
             left join expansion e on c.id_expansion = e.id
             left join expansion_asset a on a.id_expansion = e.id and a.id_rarity = c.id_rarity
-            left join inventory_card i on i.id_inventory = 0 and i.id_card = c.id
+            left join ` + inventoryCopies("$2", nil) + ` on i.id_card = c.id
         where c.name = $1
 	`
-	return client.QueryOne(query, c.FetchFull, c.Name)
+	return client.QueryOne(query, c.FetchFull, c.Name, c.InventoryCard.IDInventory)
+}
+
+//ReadByCollectorNumber reads the card printing identified by Card.Expansion.Code and Card.Index (multiverse_number)
+func (c *Card) ReadByCollectorNumber(client raizel.Client) error {
+	if strings.TrimSpace(c.Expansion.Code) == "" || strings.TrimSpace(c.Index) == "" {
+		return errors.New("data.Card.ReadErr: Message='Card.Expansion.Code or Card.Index is empty'")
+	}
+	query := `
+		select c.id, c.multiverseid, c.multiverse_number, c.name, c.label, coalesce(c.text, ''),
+            coalesce(c.manacost_label, ''), coalesce(c.combatpower_label, ''), c.type_label,
+            c.id_rarity, coalesce(c.flavor, ''), c.artist, c.rate, c.rate_votes, c.id_asset,
+            e.id, e.name, e.label, a.id_asset,
+            coalesce(i.id_inventory, 0), coalesce(i.quantity, 0)
+        from card c
+            join expansion e on c.id_expansion = e.id
+            left join expansion_asset a on a.id_expansion = e.id and a.id_rarity = c.id_rarity
+            left join ` + inventoryCopies("$3", nil) + ` on i.id_card = c.id
+        where upper(e.code) = upper($1) and c.multiverse_number = $2
+	`
+	code := c.Expansion.Code
+	if err := client.QueryOne(query, c.FetchFull, code, c.Index, c.InventoryCard.IDInventory); err != nil {
+		return err
+	}
+	c.Expansion.Code = code
+	return nil
 }
 
 func (c Card) Query(client raizel.Client, args ...interface{}) error {
@@ -121,7 +160,7 @@ func (c Card) Query(client raizel.Client, args ...interface{}) error {
 	if queryErr != nil {
 		return queryErr
 	}
-	return nil
+	return builder.count(client)
 }
 
 type Token struct {
@@ -189,11 +228,12 @@ func (t Token) Query(client raizel.Client, args ...interface{}) error {
 	if queryErr != nil {
 		return queryErr
 	}
-	return nil
+	return builder.count(client)
 }
 
 type Expansion struct {
 	ID      int    `json:"id"`
+	Code    string `json:"code,omitempty"`
 	Name    string `json:"name"`
 	Label   string `json:"label"`
 	IDAsset int    `json:"idAsset"`
@@ -242,7 +282,7 @@ func (e Expansion) Query(client raizel.Client, args ...interface{}) error {
 	if queryErr != nil {
 		return queryErr
 	}
-	return nil
+	return builder.count(client)
 }
 
 func GetPlayer(username string) (*Player, error) {
@@ -259,6 +299,10 @@ type Player struct {
 	Username    string `json:"username"`
 	IDInventory int    `json:"idInventory"`
 	IDDecks     []int  `json:"idDecks"`
+	//Inventories holds the player named inventories, IDInventory is the default one
+	Inventories []Inventory `json:"inventories,omitempty"`
+	//Password holds the password hash, it is never serialized
+	Password string `json:"-"`
 }
 
 //FillFromSession loads the player attributes from identity.Session object
@@ -300,7 +344,7 @@ func (p *Player) ReadByUsername(client raizel.Client) error {
 		return errors.New("data.Player.ReadError: Message='Player.Username is empty'")
 	}
 	query := `
-        select p.id, p.username, (select max(i.id) from inventory i where i.id_player = p.id) as id_inventory
+        select p.id, p.username, coalesce((select min(i.id) from inventory i where i.id_player = p.id), 0) as id_inventory
         from player p
         where p.username = $1
     `
@@ -309,9 +353,38 @@ func (p *Player) ReadByUsername(client raizel.Client) error {
 		return err
 	}
 	//Read Fully
+	if err := p.ReadInventories(client); err != nil {
+		return err
+	}
 	return p.ReadDecks(client, -1)
 }
 
+//ReadInventories reads the player named inventories ordered by creation
+func (p *Player) ReadInventories(client raizel.Client) error {
+	if p.ID <= 0 {
+		return errors.New("data.Player.ReadInventoriesErr: Message='Player.ID is empty'")
+	}
+	query := `
+        select i.id, i.name, i.id_player
+        from inventory i
+        where i.id_player = $1
+        order by i.id
+    `
+	iterFunc := func(i raizel.Iterable) error {
+		var inventories []Inventory
+		for i.Next() {
+			var inventory Inventory
+			if err := inventory.FetchSmall(i); err != nil {
+				return err
+			}
+			inventories = append(inventories, inventory)
+		}
+		p.Inventories = inventories
+		return nil
+	}
+	return client.Query(query, iterFunc, p.ID)
+}
+
 func (p *Player) ReadDecks(client raizel.Client, page int) error {
 	if p.ID < 0 {
 		return errors.New("data.Player.ReadDecksErr: Message='Player.ID is invalid'")
@@ -360,33 +433,25 @@ func (p *Player) Persist(client raizel.Client) error {
 	}
 
 	if countPlayer <= 0 {
-		insert := `insert into player (id, username) values (nextval('sq_player'), $1) returning id`
-
-		var insertedID int
+		insert := `insert into player (id, username, password) values (nextval('sq_player'), $1, $2) returning id`
 		idFetchFunc := func(f raizel.Fetchable) error {
-			return f.Scan(&insertedID)
+			return f.Scan(&p.ID)
 		}
-
-		_, insertErr := client.Exec(insert, idFetchFunc, p.Username)
-		if insertErr != nil {
+		if insertErr := client.QueryOne(insert, idFetchFunc, p.Username, p.Password); insertErr != nil {
 			return insertErr
 		}
-		p.ID = int(insertedID)
 
-		insertInventory := `insert into inventory (name, id_player) values ($1, $2)`
-		insertInvetoryResult, insertInvetoryErr := client.Exec(insertInventory, p.Username, p.ID)
-		if insertInvetoryErr != nil {
-			return insertInvetoryErr
+		insertInventory := `insert into inventory (name, id_player) values ($1, $2) returning id`
+		inventoryFetchFunc := func(f raizel.Fetchable) error {
+			return f.Scan(&p.IDInventory)
 		}
-		inventoryID, lastIDInventoryErr := insertInvetoryResult.LastInsertId()
-		if lastIDInventoryErr != nil {
-			return lastIDInventoryErr
+		if insertInventoryErr := client.QueryOne(insertInventory, inventoryFetchFunc, p.Username, p.ID); insertInventoryErr != nil {
+			return insertInventoryErr
 		}
-		p.IDInventory = int(inventoryID)
 
 		l.Infof("Player.PersistedNewPlayer: ID=%v Username='%v' IDInventory=%v", p.ID, p.Username, p.IDInventory)
 	} else {
-		update := `update player set dt_lastlogin = current_timestamp() where username = $1`
+		update := `update player set dt_lastlogin = current_timestamp where username = $1`
 
 		_, updateErr := client.Exec(update, p.Username)
 		if updateErr != nil {
@@ -397,60 +462,174 @@ func (p *Player) Persist(client raizel.Client) error {
 	return nil
 }
 
+//Register persists a new player with the Player.Password hash and the player default inventory
+func (p *Player) Register(client raizel.Client) error {
+	if strings.TrimSpace(p.Password) == "" {
+		return errors.New("data.Player.RegisterError: Message='Player.Password is empty'")
+	}
+	var existsID int
+	err := client.QueryOne(`select p.id from player p where p.username = $1`,
+		func(f raizel.Fetchable) error { return f.Scan(&existsID) }, p.Username)
+	if err == nil {
+		return ErrPlayerExists
+	}
+	if err != raizel.ErrNotFound {
+		return err
+	}
+	return p.Persist(client)
+}
+
+//ReadPassword reads the stored password hash of Player.Username into Player.Password
+func (p *Player) ReadPassword(client raizel.Client) error {
+	if strings.TrimSpace(p.Username) == "" {
+		return errors.New("data.Player.ReadPasswordError: Message='Player.Username is empty'")
+	}
+	query := `select coalesce(p.password, '') from player p where p.username = $1`
+	return client.QueryOne(query, func(f raizel.Fetchable) error { return f.Scan(&p.Password) }, p.Username)
+}
+
 type Inventory struct {
 	ID       int    `json:"id"`
 	Name     string `json:"name"`
 	Label    string `json:"label"`
 	IDPlayer int    `json:"idPlayer"`
 	Cards    []Card `json:"cards"`
+	//Totals summarizes the cards matched by ReadCards
+	Totals *InventoryTotals `json:"totals,omitempty"`
+}
+
+//InventoryTotals is the count of distinct cards and copies of an inventory
+type InventoryTotals struct {
+	DistinctCards int              `json:"distinctCards"`
+	Copies        int              `json:"copies"`
+	Expansions    []ExpansionTotal `json:"expansions"`
+}
+
+//ExpansionTotal is the count of distinct cards and copies of one expansion of an inventory
+type ExpansionTotal struct {
+	Expansion     Expansion `json:"expansion"`
+	DistinctCards int       `json:"distinctCards"`
+	Copies        int       `json:"copies"`
 }
 
+const (
+	//replaceQuantity is the upsert quantity of Inventory.Persist
+	replaceQuantity = "excluded.quantity"
+	//addQuantity is the upsert quantity of Inventory.Add
+	addQuantity = "inventory_card.quantity + excluded.quantity"
+)
+
+//Persist creates a new named inventory when Inventory.ID is empty, otherwise updates the inventory
+//owned by Inventory.IDPlayer, and upserts the Inventory.Cards quantities.
+//raizel.ErrNotFound is returned when the inventory does not belong to the player.
+//Everything runs in one transaction
 func (i *Inventory) Persist(client raizel.Client) error {
-	if i.IDPlayer > 0 {
-		return errors.New("data.Inventory.UpdateError: Message='Inventory.IDPlayer is not zero'")
-	}
-	if i.ID > 0 {
-		return errors.New("data.Inventory.UpdateError: Message='Inventory.ID is not zero'")
-	}
+	return i.persistWith(client, replaceQuantity)
+}
 
-	cardPersistQuery := `
-		insert into inventory_card (id_inventory, id_card, quantity) 
-		values ($1, $2, $3)
-		on conflict(id_inventory, id_card)
-		do update set quantity = $3
-	`
+//Add is Persist adding the Inventory.Cards quantities to the copies already in the inventory instead of
+//replacing them, the quantities of a repeated copy are summed
+func (i *Inventory) Add(client raizel.Client) error {
+	return i.persistWith(client, addQuantity)
+}
 
-	// insertCardQuery :=
-	// 	`insert into inventory_card (id_inventory, id_card, quantity)
-	//     values ($1, $2, $3)`
-	// updateCardQuery :=
-	// 	`update inventory_card
-	//         set quantity = $1
-	//     where id_inventory = $2 and id_card = $3`
+func (i *Inventory) persistWith(client raizel.Client, quantity string) error {
+	id := i.ID
+	err := InTransaction(client, func(tx raizel.Client) error {
+		return i.persist(tx, quantity)
+	})
+	if err != nil {
+		i.ID = id
+	}
+	return err
+}
 
+func (i *Inventory) persist(client raizel.Client, quantity string) error {
+	if i.IDPlayer <= 0 {
+		return errors.New("data.Inventory.PersistError: Message='Inventory.IDPlayer is empty'")
+	}
+	i.Name = strings.TrimSpace(i.Name)
+	if i.ID <= 0 {
+		if i.Name == "" {
+			return ErrInvalidInventory
+		}
+		fetchID := func(f raizel.Fetchable) error {
+			return f.Scan(&i.ID)
+		}
+		insert := `insert into inventory (name, id_player) values ($1, $2) returning id`
+		if err := client.QueryOne(insert, fetchID, i.Name, i.IDPlayer); err != nil {
+			return err
+		}
+		l.Debug("data.Inventory.InsertNewInventory",
+			l.Int("ID", i.ID),
+			l.Int("IDPlayer", i.IDPlayer),
+			l.String("Name", i.Name),
+		)
+	} else {
+		update := `update inventory set name = coalesce(nullif($1, ''), name) where id = $2 and id_player = $3`
+		updateResult, err := client.Exec(update, i.Name, i.ID, i.IDPlayer)
+		if err != nil {
+			return err
+		}
+		rowsUpdated, err := updateResult.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowsUpdated != 1 {
+			l.Info("data.Inventory.NotOwned",
+				l.Int("ID", i.ID),
+				l.Int("IDPlayer", i.IDPlayer),
+			)
+			return raizel.ErrNotFound
+		}
+	}
+
+	//One multi-row upsert can not touch a row twice, the last quantity of a repeated copy wins or, adding, the
+	//quantities of a repeated copy are summed
+	type copyKey struct {
+		idCard                              int
+		finish, condition, language, graded string
+	}
+	rows := make([][]interface{}, 0, len(i.Cards))
+	rowByCopy := make(map[copyKey]int, len(i.Cards))
 	for _, card := range i.Cards {
-		_, insertErr := client.Exec(cardPersistQuery, i.ID, card.ID, card.InventoryCard.Quantity)
-		if insertErr != nil {
-			l.Error("data.Inventory.InsertCardErr", l.Err(insertErr))
-			return insertErr
-			// if !primaryKeyViolationByID.MatchString(insertErr.Error()) {
-			// 	return insertErr
-			// }
-			// updateResult, updateErr := client.Exec(updateCardQuery, card.InventoryCard.Quantity, i.ID, card.ID)
-			// if updateErr != nil {
-			// 	return updateErr
-			// }
-			// rowsUpdated, err := updateResult.RowsAffected()
-			// if err != nil {
-			// 	l.Errorf("Inventory.UpdateGetRowsAffectedEx: Message='%v'", err.Error())
-			// } else {
-			// 	if rowsUpdated != 1 {
-			// 		l.Errorf("Inventory.UpdateMultipleEx: Message='%d Card Records was update for Inventory.ID=%d'", rowsUpdated, i.ID)
-			// 	}
-			// }
+		copies := card.Copies
+		if len(copies) == 0 {
+			copies = []InventoryCard{card.InventoryCard}
 		}
+		for _, inventoryCard := range copies {
+			if err := inventoryCard.Normalize(); err != nil {
+				l.Info("data.Inventory.InvalidCopy", l.Int("IDCard", card.ID), l.Struct("Copy", inventoryCard))
+				return err
+			}
+			key := copyKey{card.ID, inventoryCard.Finish, inventoryCard.Condition, inventoryCard.Language, inventoryCard.Graded}
+			row := []interface{}{i.ID, card.ID, key.finish, key.condition, key.language, key.graded, inventoryCard.Quantity}
+			if index, repeated := rowByCopy[key]; repeated {
+				if quantity == addQuantity {
+					row[6] = rows[index][6].(int) + inventoryCard.Quantity
+				}
+				rows[index] = row
+				continue
+			}
+			rowByCopy[key] = len(rows)
+			rows = append(rows, row)
+		}
+	}
+	cardPersistQuery := `
+		insert into inventory_card (id_inventory, id_card, finish, condition, language, graded, quantity)
+		values %s
+		on conflict(id_inventory, id_card, finish, condition, language, graded)
+		do update set quantity = ` + quantity + `
+	`
+	if err := batchInsert(client, cardPersistQuery, rows); err != nil {
+		l.Error("data.Inventory.InsertCardErr", l.Err(err))
+		return err
 	}
-	l.Infof("Inventory.Persisted: ID=%v IDPlayer=%v", i.ID, i.IDPlayer)
+	l.Info("data.Inventory.Persisted",
+		l.Int("ID", i.ID),
+		l.Int("IDPlayer", i.IDPlayer),
+		l.Int("Cards.Len", len(i.Cards)),
+	)
 	return nil
 }
 
@@ -490,63 +669,60 @@ func (i *Inventory) Persist(client raizel.Client) error {
 // 	return nil
 // }
 
-// func (i *Inventory) FetchFull(fetchable raizel.Fetchable) error {
-// 	return fetchable.Scan(&i.ID, &i.Name, &i.IDPlayer)
-// }
+func (i *Inventory) FetchSmall(fetchable raizel.Fetchable) error {
+	return fetchable.Scan(&i.ID, &i.Name, &i.IDPlayer)
+}
 
-//Read gets the entity representation from the database.
-//Inventory.ID must not empty to perform a Read operation
-// func (i *Inventory) Read() error {
-// 	i.Attach()
-// 	defer closeDB(i)
-// 	if i.ID <= 0 {
-// 		return errors.New("data.Inventory.ReadError: Message='Inventory.ID is empty'")
-// 	}
-// 	if i.IDPlayer <= 0 {
-// 		return errors.New("data.Inventory.ReadError: Message='Inventory.IDPlayer is empty'")
-// 	}
-// 	row := i.db.QueryRow("select i.id, i.name, i.id_player from inventory i where i.id = ? and i.id_player = ?", i.ID, i.IDPlayer)
-// 	return i.Fetch(row)
-// }
+//ReadByID reads the inventory header, without the cards
+func (i *Inventory) ReadByID(client raizel.Client) error {
+	if i.ID <= 0 {
+		return errors.New("data.Inventory.ReadError: Message='Inventory.ID is empty'")
+	}
+	return client.QueryOne("select i.id, i.name, i.id_player from inventory i where i.id = $1", i.FetchSmall, i.ID)
+}
 
-//ReadCards gets one related Cards page of this Inventory
-//Inventory.ID must not empty to perform a ReadCards operation
-// func (i *Inventory) ReadCards(page int) error {
-// 	i.Attach()
-// 	defer closeDB(i)
-// 	if i.ID <= 0 {
-// 		return errors.New("data.Inventory.ReadCardsError: Message='Inventory.ID is empty'")
-// 	}
-// 	query :=
-// 		`select c.id, c.multiverse_number, c.name, c.label, coalesce(c.text, ''),
-//             coalesce(c.manacost_label, ''), coalesce(c.combatpower_label, ''), c.type_label,
-//             c.id_rarity, coalesce(c.flavor, ''), c.artist,
-//             c.rate, c.rate_votes, c.id_asset,
-//             coalesce(i.id_inventory, 1), coalesce(i.quantity, 0),
-//             e.id, e.name, e.label, a.id_asset
-//         from card c
-//             left join expansion e on c.id_expansion = e.id
-//             left join expansion_asset a on a.id_expansion = e.id and a.id_rarity = c.id_rarity
-//             left join inventory_card i on i.id_card = c.id
-//         where i.id_inventory = ?
-//         order by e.name, abs(c.multiverse_number)`
-// 	rows, readCardsErr := i.db.Query(query, i.ID)
-// 	if readCardsErr != nil {
-// 		return readCardsErr
-// 	}
-// 	//cards := make([]Card, 0)
-// 	cards := []Card{}
-// 	//var cards []Card
-// 	for rows.Next() {
-// 		nextCard := Card{Expansion: Expansion{}, InventoryCard: InventoryCard{}}
-// 		if err := nextCard.Fetch(rows); err != nil {
-// 			return err
-// 		}
-// 		cards = append(cards, nextCard)
-// 	}
-// 	i.Cards = cards
-// 	return nil
-// }
+//ReadCards reads the cardQuery page, see Query, of the inventory cards matching the cardQuery restrictions with their
+//copies and the totals of the matching cards. A zero cardQuery.Limit reads all cards.
+//Only cards with at least one copy are read unless cardQuery.InventoryQtd is set
+func (i *Inventory) ReadCards(client raizel.Client, cardQuery *CardQuery) error {
+	if i.ID <= 0 {
+		return errors.New("data.Inventory.ReadCardsError: Message='Inventory.ID is empty'")
+	}
+	if cardQuery == nil {
+		cardQuery = &CardQuery{}
+	}
+	cardQuery.IDInventory = i.ID
+	if cardQuery.InventoryQtd == "" {
+		cardQuery.InventoryQtd = "1"
+	}
+	if err := cardQuery.Build(); err != nil {
+		return err
+	}
+	if err := client.Query(cardQuery.SQL, cardQuery.Fetch, cardQuery.Values...); err != nil {
+		return err
+	}
+	if err := cardQuery.count(client); err != nil {
+		return err
+	}
+	i.Cards = cardQuery.Result
+	if err := i.readCopies(client, cardQuery); err != nil {
+		return err
+	}
+	if err := cardQuery.BuildTotals(); err != nil {
+		return err
+	}
+	if err := client.Query(cardQuery.SQL, cardQuery.FetchTotals, cardQuery.Values...); err != nil {
+		return err
+	}
+	i.Totals = cardQuery.Totals
+	l.Debug("data.Inventory.ReadCards",
+		l.Int("ID", i.ID),
+		l.Int("Limit", cardQuery.Limit),
+		l.Int("Cards.Len", len(i.Cards)),
+		l.Int("Totals.DistinctCards", i.Totals.DistinctCards),
+	)
+	return nil
+}
 
 type Deck struct {
 	ID          int    `json:"id"`
@@ -556,10 +732,27 @@ type Deck struct {
 	Cards       []Card `json:"cards"`
 }
 
+//Delete removes the Deck of the Deck.IDPlayer, its cards and revisions in one transaction
 func (d *Deck) Delete(client raizel.Client) error {
+	return InTransaction(client, d.delete)
+}
+
+func (d *Deck) delete(client raizel.Client) error {
 	if d.ID <= 0 {
 		return errors.New("data.Deck.DeleteErr Message='Deck.ID is empty'")
 	}
+	//A deck of another player is not found
+	var owned Deck
+	if err := client.QueryOne("select d.id, d.name, d.id_player from deck d where d.id = $1 for update", owned.FetchSmall, d.ID); err != nil {
+		return err
+	}
+	if owned.IDPlayer != d.IDPlayer {
+		l.Info("data.Deck.NotOwned",
+			l.Int("ID", d.ID),
+			l.Int("IDPlayer", d.IDPlayer),
+		)
+		return raizel.ErrNotFound
+	}
 	deleteCardsResult, err := client.Exec("delete from deck_card where id_deck = $1", d.ID)
 	if err != nil {
 		return err
@@ -574,6 +767,12 @@ func (d *Deck) Delete(client raizel.Client) error {
 		l.Int("Deck.ID", d.ID),
 		l.Int("Deck.IDPlayer", d.IDPlayer),
 	)
+	if _, err := client.Exec("delete from deck_reservation where id_deck = $1", d.ID); err != nil {
+		return err
+	}
+	if _, err := client.Exec("delete from deck_revision where id_deck = $1", d.ID); err != nil {
+		return err
+	}
 	_, deleteErr := client.Exec("delete from deck where id = $1", d.ID)
 	if deleteErr != nil {
 		return deleteErr
@@ -594,7 +793,18 @@ func (d *Deck) FetchSmall(fetchable raizel.Fetchable) error {
 	return fetchable.Scan(&d.ID, &d.Name, &d.IDPlayer)
 }
 
+//Persist saves the Deck, its cards and a new revision in one transaction
 func (d *Deck) Persist(client raizel.Client) error {
+	id := d.ID
+	err := InTransaction(client, d.persist)
+	if err != nil {
+		//The rolled back insert must not leave the new id
+		d.ID = id
+	}
+	return err
+}
+
+func (d *Deck) persist(client raizel.Client) error {
 	if d.Name == "" {
 		return errors.New("data.Deck.PersistError: Message='Deck.Name is empty'")
 	}
@@ -613,20 +823,21 @@ func (d *Deck) Persist(client raizel.Client) error {
 			l.String("Name", d.Name),
 		)
 	} else {
-		updateResult, updateErr := client.Exec("update deck set name = $1, id_player = $2 where id = $3", d.Name, d.IDPlayer, d.ID)
+		//The owner of a deck never changes, a deck of another player is not found
+		updateResult, updateErr := client.Exec("update deck set name = $1 where id = $2 and id_player = $3", d.Name, d.ID, d.IDPlayer)
 		if updateErr != nil {
 			return updateErr
 		}
 		rowsUpdated, err := updateResult.RowsAffected()
 		if err != nil {
-			l.Warn("data.Deck.UpdateGetRowsAffectedErr", l.Err(err))
-		} else {
-			if rowsUpdated != 1 {
-				l.Warn("data.Deck.UpdateMultipleErr",
-					l.Int64("RowsUpdated", rowsUpdated),
-					l.Int("ID", d.ID),
-				)
-			}
+			return err
+		}
+		if rowsUpdated != 1 {
+			l.Info("data.Deck.NotOwned",
+				l.Int("ID", d.ID),
+				l.Int("IDPlayer", d.IDPlayer),
+			)
+			return raizel.ErrNotFound
 		}
 		l.Debug("data.Deck.UpdateOldDeck",
 			l.Int("ID", d.ID),
@@ -639,45 +850,38 @@ func (d *Deck) Persist(client raizel.Client) error {
 		return deleteErr
 	}
 
+	type boardCard struct {
+		idCard, idBoard int
+	}
+	//One multi-row upsert can not touch a row twice, the last quantity of a repeated card wins
+	rows := make([][]interface{}, 0, len(d.Cards))
+	rowByCard := make(map[boardCard]int, len(d.Cards))
+	for _, card := range d.Cards {
+		row := []interface{}{d.ID, card.ID, card.DeckCard.IDBoard, card.DeckCard.Quantity}
+		key := boardCard{card.ID, card.DeckCard.IDBoard}
+		if index, repeated := rowByCard[key]; repeated {
+			rows[index] = row
+			continue
+		}
+		rowByCard[key] = len(rows)
+		rows = append(rows, row)
+	}
 	persistCardQuery := `
 		insert into deck_card (id_deck, id_card, id_board, quantity)
-        values ($1, $2, $3, $4)
+		values %s
 		on conflict(id_deck, id_card, id_board)
-		do update set quantity = $4
+		do update set quantity = excluded.quantity
 	`
-
-	// insertCardQuery :=
-	// 	`insert into deck_card (id_deck, id_card, id_board, quantity)
-	//     values ($1, $2, $3, $4)`
-	// updateCardQuery :=
-	// 	`update deck_card
-	//         set quantity = $1
-	//     where id_deck = $2 and id_card = $3 and id_board = $4`
-
-	for _, card := range d.Cards {
-		_, insertErr := client.Exec(persistCardQuery, d.ID, card.ID, card.DeckCard.IDBoard, card.DeckCard.Quantity)
-		if insertErr != nil {
-			l.Error("data.Deck.InsertCardErr", l.Err(insertErr))
-			return insertErr
-			// if !primaryKeyViolationByID.MatchString(insertErr.Error()) {
-			// 	return insertErr
-			// }
-			// updateResult, updateErr := client.Exec(updateCardQuery, card.DeckCard.Quantity, d.ID, card.ID, card.DeckCard.IDBoard)
-			// if updateErr != nil {
-			// 	return updateErr
-			// }
-			// rowsUpdated, err := updateResult.RowsAffected()
-			// if err != nil {
-			// 	l.Warn("data.Deck.UpdateGetRowsAffectedWarn", l.Err(err))
-			// } else {
-			// 	if rowsUpdated != 1 {
-			// 		l.Warn("data.Deck.UpdateMultipleErr",
-			// 			l.Int64("RowsUpdated", rowsUpdated),
-			// 			l.Int("ID", d.ID),
-			// 		)
-			// 	}
-			// }
-		}
+	if err := batchInsert(client, persistCardQuery, rows); err != nil {
+		l.Error("data.Deck.InsertCardErr", l.Err(err))
+		return err
+	}
+	if err := d.trimReservations(client); err != nil {
+		return err
+	}
+	if err := d.recordRevision(client); err != nil {
+		l.Error("data.Deck.RecordRevisionErr", l.Err(err))
+		return err
 	}
 	l.Info("data.Deck.Persisted",
 		l.Int("ID", d.ID),
@@ -721,13 +925,13 @@ func (d *Deck) ReadCards(client raizel.Client, page int) error {
             c.id_rarity, coalesce(c.flavor, ''), c.artist,
             c.rate, c.rate_votes, c.id_asset,
             d.id_deck, d.id_board, coalesce(d.quantity, 0) as deck_quantity,
-            e.id, e.name, a.id_asset
+            e.id, coalesce(e.code, ''), e.name, a.id_asset
         from card c
             left join expansion e on c.id_expansion = e.id
             left join expansion_asset a on a.id_expansion = e.id and a.id_rarity = c.id_rarity
             left join deck_card d on d.id_card = c.id
         where d.id_deck = $1 
-        order by d.id_board, c.type_label, e.name`
+        order by d.id_board, c.type_label, e.name, c.id`
 
 	cardsFetchFunc := func(i raizel.Iterable) error {
 		//tempCards := make([]Card, selectLimit)
@@ -761,5 +965,5 @@ func (d Deck) Query(client raizel.Client, args ...interface{}) error {
 	if queryErr != nil {
 		return queryErr
 	}
-	return nil
+	return builder.count(client)
 }
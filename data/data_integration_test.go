@@ -1,3 +1,7 @@
+//go:build integration
+// +build integration
+
+//The integration tests of the first api version run against a populated database with go test -tags integration
 package data_test
 
 import (
@@ -0,0 +1,301 @@
+package data
+
+import (
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/rjansen/l"
+	"github.com/rjansen/raizel"
+)
+
+const (
+	//DecklistArena identifies the MTG Arena "4 Card Name (SET) 123" text format
+	DecklistArena = "arena"
+	//DecklistMTGO identifies the MTGO "4 Card Name" .txt format
+	DecklistMTGO = "mtgo"
+	//DecklistDek identifies the MTGO .dek xml format
+	DecklistDek = "dek"
+)
+
+var (
+	//ErrUnknownDecklistFormat is raised when the decklist format is not arena, mtgo or dek
+	ErrUnknownDecklistFormat = errors.New("data.Decklist.UnknownFormatErr: Message='Decklist format must be arena, mtgo or dek'")
+
+	decklistCardLine = regexp.MustCompile(`^(?i:(SB:)\s*)?(\d+)x?\s+(.+?)(?:\s+\(([A-Za-z0-9]+)\)(?:\s+(\S+))?)?$`)
+	decklistSections = map[string]int{
+		"deck":       MainBoard,
+		"main":       MainBoard,
+		"mainboard":  MainBoard,
+		"commander":  MainBoard,
+		"sideboard":  SideBoard,
+		"companion":  SideBoard,
+		"maybeboard": 0,
+	}
+)
+
+//DecklistLine is one card line of a plain text decklist
+type DecklistLine struct {
+	Line            int    `json:"line"`
+	Text            string `json:"text"`
+	Quantity        int    `json:"quantity"`
+	Name            string `json:"name"`
+	SetCode         string `json:"setCode,omitempty"`
+	CollectorNumber string `json:"collectorNumber,omitempty"`
+	IDBoard         int    `json:"idBoard"`
+	Reason          string `json:"reason,omitempty"`
+}
+
+//dekDeck is the MTGO .dek xml document
+type dekDeck struct {
+	XMLName              xml.Name  `xml:"Deck"`
+	NetDeckID            int       `xml:"NetDeckID"`
+	PreconstructedDeckID int       `xml:"PreconstructedDeckID"`
+	Cards                []dekCard `xml:"Cards"`
+}
+
+type dekCard struct {
+	CatID     string `xml:"CatID,attr"`
+	Quantity  int    `xml:"Quantity,attr"`
+	Sideboard bool   `xml:"Sideboard,attr"`
+	Name      string `xml:"Name,attr"`
+}
+
+//ParseDecklist reads the decklist in the provided format and returns the card lines and the lines it could not parse
+func ParseDecklist(format string, r io.Reader) ([]DecklistLine, []DecklistLine, error) {
+	switch format {
+	case DecklistArena, DecklistMTGO:
+		return parseTextDecklist(r)
+	case DecklistDek:
+		return parseDekDecklist(r)
+	}
+	return nil, nil, ErrUnknownDecklistFormat
+}
+
+//parseTextDecklist handles both arena and mtgo text lists: sections are introduced by a header line
+//(Deck, Sideboard, Commander, Companion) or by the first blank line after the main board
+func parseTextDecklist(r io.Reader) ([]DecklistLine, []DecklistLine, error) {
+	var (
+		lines, invalid []DecklistLine
+		board          = MainBoard
+		lineNumber     int
+		readCards      bool
+	)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lineNumber++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			if readCards && board == MainBoard {
+				board = SideBoard
+			}
+			continue
+		}
+		if strings.HasPrefix(text, "//") || strings.HasPrefix(text, "#") {
+			continue
+		}
+		header := strings.ToLower(strings.TrimSuffix(text, ":"))
+		if sectionBoard, isSection := decklistSections[header]; isSection {
+			board = sectionBoard
+			continue
+		}
+		if strings.HasPrefix(header, "name ") || strings.HasPrefix(header, "about") {
+			continue
+		}
+		match := decklistCardLine.FindStringSubmatch(text)
+		if match == nil {
+			invalid = append(invalid, DecklistLine{Line: lineNumber, Text: text, Reason: "unparseable line"})
+			continue
+		}
+		quantity, _ := strconv.Atoi(match[2])
+		line := DecklistLine{
+			Line:            lineNumber,
+			Text:            text,
+			Quantity:        quantity,
+			Name:            strings.TrimSpace(match[3]),
+			SetCode:         strings.ToUpper(match[4]),
+			CollectorNumber: match[5],
+			IDBoard:         board,
+		}
+		if match[1] != "" {
+			line.IDBoard = SideBoard
+		}
+		if line.IDBoard == 0 {
+			//Maybeboard cards are not part of the deck
+			continue
+		}
+		readCards = true
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	return lines, invalid, nil
+}
+
+func parseDekDecklist(r io.Reader) ([]DecklistLine, []DecklistLine, error) {
+	var deck dekDeck
+	if err := xml.NewDecoder(r).Decode(&deck); err != nil {
+		return nil, nil, err
+	}
+	var lines, invalid []DecklistLine
+	for i, card := range deck.Cards {
+		line := DecklistLine{
+			Line:     i + 1,
+			Text:     fmt.Sprintf("%d %s", card.Quantity, card.Name),
+			Quantity: card.Quantity,
+			Name:     strings.TrimSpace(card.Name),
+			IDBoard:  MainBoard,
+		}
+		if card.Sideboard {
+			line.IDBoard = SideBoard
+		}
+		if line.Name == "" || line.Quantity <= 0 {
+			line.Reason = "missing card name or quantity"
+			invalid = append(invalid, line)
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, invalid, nil
+}
+
+//ImportDecklist resolves the decklist lines into Deck.Cards by the set code plus the collector number of the
+//printing and, when the line has no printing or it is unknown, by Card.ReadByName. The lines that can not be
+//resolved are returned
+func (d *Deck) ImportDecklist(client raizel.Client, lines []DecklistLine) ([]DecklistLine, error) {
+	var unresolved []DecklistLine
+	for _, line := range lines {
+		err := raizel.ErrNotFound
+		var card Card
+		if line.SetCode != "" && line.CollectorNumber != "" {
+			card = Card{Index: line.CollectorNumber, Expansion: Expansion{Code: line.SetCode}}
+			err = card.ReadByCollectorNumber(client)
+		}
+		if err == raizel.ErrNotFound {
+			card = Card{Name: line.Name}
+			err = card.ReadByName(client)
+		}
+		if err != nil {
+			if err != raizel.ErrNotFound {
+				return nil, err
+			}
+			line.Reason = "card not found"
+			unresolved = append(unresolved, line)
+			continue
+		}
+		d.addCard(card, line.IDBoard, line.Quantity)
+	}
+	l.Debug("data.Deck.ImportedDecklist",
+		l.Int("ID", d.ID),
+		l.Int("Lines.Len", len(lines)),
+		l.Int("Unresolved.Len", len(unresolved)),
+	)
+	return unresolved, nil
+}
+
+//addCard appends the card to the board or sums the quantity when it is already there
+func (d *Deck) addCard(card Card, idBoard, quantity int) {
+	for i := range d.Cards {
+		if d.Cards[i].ID == card.ID && d.Cards[i].DeckCard.IDBoard == idBoard {
+			d.Cards[i].DeckCard.Quantity += quantity
+			return
+		}
+	}
+	card.DeckCard = DeckCard{IDDeck: d.ID, IDBoard: idBoard, Quantity: quantity}
+	d.Cards = append(d.Cards, card)
+}
+
+//WriteDecklist writes the Deck.Cards in the provided decklist format
+func (d *Deck) WriteDecklist(format string, w io.Writer) error {
+	switch format {
+	case DecklistArena:
+		return d.writeTextDecklist(w, true)
+	case DecklistMTGO:
+		return d.writeTextDecklist(w, false)
+	case DecklistDek:
+		return d.writeDekDecklist(w)
+	}
+	return ErrUnknownDecklistFormat
+}
+
+//boardLines merges the printings of the same card name when the printing is not exported
+func (d *Deck) boardLines(idBoard int, printing bool) []DecklistLine {
+	var lines []DecklistLine
+	index := map[string]int{}
+	for _, card := range d.Cards {
+		if card.DeckCard.IDBoard != idBoard || card.DeckCard.Quantity <= 0 {
+			continue
+		}
+		line := DecklistLine{Quantity: card.DeckCard.Quantity, Name: card.Name, IDBoard: idBoard}
+		if printing {
+			line.SetCode = strings.ToUpper(card.Expansion.Code)
+			line.CollectorNumber = card.Index
+		}
+		key := line.Name + "|" + line.SetCode + "|" + line.CollectorNumber
+		if i, found := index[key]; found {
+			lines[i].Quantity += line.Quantity
+			continue
+		}
+		index[key] = len(lines)
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func (d *Deck) writeTextDecklist(w io.Writer, arena bool) error {
+	main, side := d.boardLines(MainBoard, arena), d.boardLines(SideBoard, arena)
+	buffer := bufio.NewWriter(w)
+	if arena {
+		buffer.WriteString("Deck\n")
+	}
+	for _, line := range main {
+		buffer.WriteString(line.String() + "\n")
+	}
+	if len(side) > 0 {
+		buffer.WriteString("\n")
+		if arena {
+			buffer.WriteString("Sideboard\n")
+		}
+		for _, line := range side {
+			buffer.WriteString(line.String() + "\n")
+		}
+	}
+	return buffer.Flush()
+}
+
+func (d *Deck) writeDekDecklist(w io.Writer) error {
+	deck := dekDeck{}
+	for _, idBoard := range []int{MainBoard, SideBoard} {
+		for _, line := range d.boardLines(idBoard, false) {
+			deck.Cards = append(deck.Cards, dekCard{
+				CatID:     "0",
+				Quantity:  line.Quantity,
+				Sideboard: idBoard == SideBoard,
+				Name:      line.Name,
+			})
+		}
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	return encoder.Encode(deck)
+}
+
+func (line DecklistLine) String() string {
+	text := fmt.Sprintf("%d %s", line.Quantity, line.Name)
+	if line.SetCode != "" {
+		text += " (" + line.SetCode + ")"
+		if line.CollectorNumber != "" {
+			text += " " + line.CollectorNumber
+		}
+	}
+	return text
+}
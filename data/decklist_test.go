@@ -0,0 +1,97 @@
+package data_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rjansen/fivecolors/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseArenaDecklist(t *testing.T) {
+	decklist := `Deck
+4 Lightning Bolt (M11) 146
+20 Mountain (M19) 272
+1 Fire // Ice
+
+Sideboard
+2 Smash to Smithereens (ORI) 163
+not a card line
+`
+	lines, invalid, err := data.ParseDecklist(data.DecklistArena, strings.NewReader(decklist))
+	assert.Nil(t, err)
+	assert.Len(t, lines, 4)
+	assert.Equal(t, data.DecklistLine{
+		Line: 2, Text: "4 Lightning Bolt (M11) 146", Quantity: 4, Name: "Lightning Bolt",
+		SetCode: "M11", CollectorNumber: "146", IDBoard: data.MainBoard,
+	}, lines[0])
+	assert.Equal(t, "Fire // Ice", lines[2].Name)
+	assert.Equal(t, "", lines[2].SetCode)
+	assert.Equal(t, data.SideBoard, lines[3].IDBoard)
+	assert.Len(t, invalid, 1)
+	assert.Equal(t, 8, invalid[0].Line)
+}
+
+func Test_ParseMTGODecklist(t *testing.T) {
+	decklist := "4 Lightning Bolt\n56 Mountain\n\n3 Pyroblast\nSB: 1 Smash to Smithereens\n"
+	lines, invalid, err := data.ParseDecklist(data.DecklistMTGO, strings.NewReader(decklist))
+	assert.Nil(t, err)
+	assert.Empty(t, invalid)
+	assert.Len(t, lines, 4)
+	assert.Equal(t, data.MainBoard, lines[1].IDBoard)
+	assert.Equal(t, data.SideBoard, lines[2].IDBoard)
+	assert.Equal(t, data.SideBoard, lines[3].IDBoard)
+	assert.Equal(t, "Smash to Smithereens", lines[3].Name)
+}
+
+func Test_DecklistDekRoundTrip(t *testing.T) {
+	deck := &data.Deck{
+		Cards: []data.Card{
+			{Name: "Lightning Bolt", DeckCard: data.DeckCard{IDBoard: data.MainBoard, Quantity: 3}},
+			{Name: "Lightning Bolt", DeckCard: data.DeckCard{IDBoard: data.MainBoard, Quantity: 1}},
+			{Name: "Pyroblast", DeckCard: data.DeckCard{IDBoard: data.SideBoard, Quantity: 2}},
+		},
+	}
+	var buffer bytes.Buffer
+	assert.Nil(t, deck.WriteDecklist(data.DecklistDek, &buffer))
+	assert.Contains(t, buffer.String(), `Quantity="4" Sideboard="false" Name="Lightning Bolt"`)
+
+	lines, invalid, err := data.ParseDecklist(data.DecklistDek, &buffer)
+	assert.Nil(t, err)
+	assert.Empty(t, invalid)
+	assert.Len(t, lines, 2)
+	assert.Equal(t, 4, lines[0].Quantity)
+	assert.Equal(t, data.SideBoard, lines[1].IDBoard)
+}
+
+func Test_WriteArenaDecklist(t *testing.T) {
+	deck := &data.Deck{
+		Cards: []data.Card{
+			{Name: "Lightning Bolt", Index: "146", Expansion: data.Expansion{Code: "m11"},
+				DeckCard: data.DeckCard{IDBoard: data.MainBoard, Quantity: 4}},
+			{Name: "Pyroblast", DeckCard: data.DeckCard{IDBoard: data.SideBoard, Quantity: 2}},
+		},
+	}
+	var arena, mtgo bytes.Buffer
+	assert.Nil(t, deck.WriteDecklist(data.DecklistArena, &arena))
+	assert.Equal(t, "Deck\n4 Lightning Bolt (M11) 146\n\nSideboard\n2 Pyroblast\n", arena.String())
+	assert.Nil(t, deck.WriteDecklist(data.DecklistMTGO, &mtgo))
+	assert.Equal(t, "4 Lightning Bolt\n\n2 Pyroblast\n", mtgo.String())
+	assert.Equal(t, data.ErrUnknownDecklistFormat, deck.WriteDecklist("csv", &mtgo))
+}
+
+func Test_ImportDecklistPrinting(t *testing.T) {
+	client := &fakeClient{}
+	deck := data.Deck{}
+	unresolved, err := deck.ImportDecklist(client, []data.DecklistLine{
+		{Quantity: 4, Name: "Lightning Bolt", SetCode: "M11", CollectorNumber: "146", IDBoard: data.MainBoard},
+		{Quantity: 2, Name: "Counterspell", IDBoard: data.SideBoard},
+	})
+	assert.Nil(t, err)
+	assert.Empty(t, unresolved)
+	assert.Len(t, client.commands, 2)
+	assert.Contains(t, client.commands[0], "where upper(e.code) = upper($1) and c.multiverse_number = $2")
+	assert.Equal(t, []interface{}{"M11", "146", 0}, client.params[0])
+	assert.Equal(t, "Counterspell", client.params[1][0])
+}
@@ -0,0 +1,207 @@
+package data
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/rjansen/l"
+	"github.com/rjansen/raizel"
+)
+
+const (
+	//ExportCSV identifies the Deckbox compatible CSV export
+	ExportCSV = "csv"
+	//ExportJSON identifies the JSON array export
+	ExportJSON = "json"
+	//ExportText identifies the "4 Card Name (SET) 123 *F*" text export
+	ExportText = "txt"
+)
+
+var (
+	//ErrUnknownExportFormat is raised when the export format is not csv, json or txt
+	ErrUnknownExportFormat = errors.New("data.Inventory.UnknownExportFormatErr: Message='Export format must be csv, json or txt'")
+
+	//exportHeader uses the Deckbox column names, they are read back by ParseCollectionCSV
+	exportHeader = []string{"Count", "Name", "Edition", "Edition Label", "Edition Code", "Card Number", "Rarity",
+		"Condition", "Language", "Foil"}
+	rarityNames = []string{"special", "common", "uncommon", "rare", "mythic"}
+)
+
+//ExportRow is the copies of one inventory card by finish, condition, language and graded
+type ExportRow struct {
+	Quantity       int    `json:"quantity"`
+	Name           string `json:"name"`
+	ExpansionName  string `json:"expansionName"`
+	ExpansionLabel string `json:"expansionLabel"`
+	ExpansionCode  string `json:"expansionCode,omitempty"`
+	Number         string `json:"number"`
+	Rarity         string `json:"rarity"`
+	Finish         string `json:"finish"`
+	Condition      string `json:"condition"`
+	Language       string `json:"language"`
+	Graded         string `json:"graded,omitempty"`
+}
+
+//RarityName returns the name of the id_rarity value
+func RarityName(idRarity int) string {
+	if idRarity < 0 || idRarity >= len(rarityNames) {
+		return ""
+	}
+	return rarityNames[idRarity]
+}
+
+//exportWriter writes the rows of one export format
+type exportWriter interface {
+	begin() error
+	write(row ExportRow) error
+	end() error
+}
+
+//Export writes every card with copies of the Inventory in the format as the rows are read from the database
+func (i *Inventory) Export(client raizel.Client, format string, w io.Writer) error {
+	if i.ID <= 0 {
+		return errors.New("data.Inventory.ExportErr: Message='Inventory.ID is empty'")
+	}
+	buffer := bufio.NewWriter(w)
+	var writer exportWriter
+	switch format {
+	case ExportCSV:
+		writer = &csvExport{writer: csv.NewWriter(buffer)}
+	case ExportJSON:
+		writer = &jsonExport{writer: buffer}
+	case ExportText:
+		writer = &textExport{writer: buffer}
+	default:
+		return ErrUnknownExportFormat
+	}
+	query := `
+		select i.quantity, c.name, coalesce(e.name, ''), coalesce(e.label, ''), coalesce(e.code, ''),
+			coalesce(c.multiverse_number, ''), c.id_rarity, i.finish, i.condition, i.language, i.graded
+		from inventory_card i
+			join card c on c.id = i.id_card
+			left join expansion e on c.id_expansion = e.id
+		where i.id_inventory = $1 and i.quantity > 0
+		order by coalesce(e.name, ''), ` + cardNumber + `, c.name, c.id, i.finish, i.condition, i.language, i.graded`
+	if err := writer.begin(); err != nil {
+		return err
+	}
+	var rows int
+	err := client.Query(query, func(iterable raizel.Iterable) error {
+		for iterable.Next() {
+			var (
+				row      ExportRow
+				idRarity int
+			)
+			if err := iterable.Scan(&row.Quantity, &row.Name, &row.ExpansionName, &row.ExpansionLabel,
+				&row.ExpansionCode, &row.Number, &idRarity, &row.Finish, &row.Condition, &row.Language, &row.Graded); err != nil {
+				return err
+			}
+			row.Rarity = RarityName(idRarity)
+			if err := writer.write(row); err != nil {
+				return err
+			}
+			rows++
+		}
+		return nil
+	}, i.ID)
+	if err != nil {
+		l.Error("data.Inventory.ExportErr", l.Int("ID", i.ID), l.Int("Rows", rows), l.Err(err))
+		return err
+	}
+	if err := writer.end(); err != nil {
+		return err
+	}
+	l.Debug("data.Inventory.Exported",
+		l.Int("ID", i.ID),
+		l.String("Format", format),
+		l.Int("Rows", rows),
+	)
+	return buffer.Flush()
+}
+
+type csvExport struct {
+	writer *csv.Writer
+}
+
+func (e *csvExport) begin() error {
+	return e.writer.Write(exportHeader)
+}
+
+func (e *csvExport) write(row ExportRow) error {
+	var foil string
+	if row.Finish != FinishNonFoil {
+		foil = row.Finish
+	}
+	return e.writer.Write([]string{strconv.Itoa(row.Quantity), row.Name, row.ExpansionName, row.ExpansionLabel,
+		row.ExpansionCode, row.Number, row.Rarity, row.Condition, row.Language, foil})
+}
+
+func (e *csvExport) end() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+type jsonExport struct {
+	writer *bufio.Writer
+	rows   int
+}
+
+func (e *jsonExport) begin() error {
+	_, err := e.writer.WriteString("[")
+	return err
+}
+
+func (e *jsonExport) write(row ExportRow) error {
+	if e.rows > 0 {
+		if _, err := e.writer.WriteString(",\n"); err != nil {
+			return err
+		}
+	}
+	e.rows++
+	raw, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+	_, err = e.writer.Write(raw)
+	return err
+}
+
+func (e *jsonExport) end() error {
+	_, err := e.writer.WriteString("]\n")
+	return err
+}
+
+type textExport struct {
+	writer *bufio.Writer
+}
+
+func (e *textExport) begin() error {
+	return nil
+}
+
+func (e *textExport) write(row ExportRow) error {
+	line := fmt.Sprintf("%d %s", row.Quantity, row.Name)
+	if row.ExpansionCode != "" {
+		line += fmt.Sprintf(" (%s)", row.ExpansionCode)
+		if row.Number != "" {
+			line += " " + row.Number
+		}
+	}
+	switch row.Finish {
+	case FinishFoil:
+		line += " *F*"
+	case FinishEtched:
+		line += " *E*"
+	}
+	_, err := fmt.Fprintln(e.writer, line)
+	return err
+}
+
+func (e *textExport) end() error {
+	return nil
+}
@@ -0,0 +1,57 @@
+package data_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rjansen/fivecolors/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func exportClient() *fakeClient {
+	return &fakeClient{rows: [][]interface{}{
+		{4, "Lightning Bolt", "Magic 2010", "M10", "M10", "146", 1, "nonfoil", "NM", "en", ""},
+		{1, "Fire // Ice", "Apocalypse", "APC", "", "", 2, "foil", "LP", "ja", "PSA 9"},
+	}}
+}
+
+func Test_InventoryExportCSV(t *testing.T) {
+	var out bytes.Buffer
+	inventory := data.Inventory{ID: 3}
+	client := exportClient()
+	assert.Nil(t, inventory.Export(client, data.ExportCSV, &out))
+	assert.Equal(t, "Count,Name,Edition,Edition Label,Edition Code,Card Number,Rarity,Condition,Language,Foil\n"+
+		"4,Lightning Bolt,Magic 2010,M10,M10,146,common,NM,en,\n"+
+		"1,Fire // Ice,Apocalypse,APC,,,uncommon,LP,ja,foil\n", out.String())
+	assert.Equal(t, []interface{}{3}, client.params[0])
+
+	rows, err := data.ParseCollectionCSV(&out, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, data.CollectionRow{Line: 2, Name: "Lightning Bolt", Set: "M10", Number: "146", Quantity: 4,
+		Finish: data.FinishNonFoil, Condition: "NM", Language: "en"}, rows[0])
+	assert.Equal(t, data.FinishFoil, rows[1].Finish)
+	assert.Equal(t, "LP", rows[1].Condition)
+	assert.Equal(t, "ja", rows[1].Language)
+}
+
+func Test_InventoryExportJSONAndText(t *testing.T) {
+	var out bytes.Buffer
+	inventory := data.Inventory{ID: 3}
+	assert.Nil(t, inventory.Export(exportClient(), data.ExportJSON, &out))
+	assert.JSONEq(t, `[
+		{"quantity":4,"name":"Lightning Bolt","expansionName":"Magic 2010","expansionLabel":"M10","expansionCode":"M10","number":"146","rarity":"common",
+			"finish":"nonfoil","condition":"NM","language":"en"},
+		{"quantity":1,"name":"Fire // Ice","expansionName":"Apocalypse","expansionLabel":"APC","number":"","rarity":"uncommon",
+			"finish":"foil","condition":"LP","language":"ja","graded":"PSA 9"}
+	]`, out.String())
+
+	out.Reset()
+	assert.Nil(t, inventory.Export(&fakeClient{}, data.ExportJSON, &out))
+	assert.Equal(t, "[]\n", out.String())
+
+	out.Reset()
+	assert.Nil(t, inventory.Export(exportClient(), data.ExportText, &out))
+	assert.Equal(t, "4 Lightning Bolt (M10) 146\n1 Fire // Ice *F*\n", out.String())
+
+	assert.Equal(t, data.ErrUnknownExportFormat, inventory.Export(exportClient(), "xml", &out))
+}
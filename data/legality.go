@@ -0,0 +1,375 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/rjansen/l"
+	"github.com/rjansen/raizel"
+)
+
+const (
+	//Standard identifies the Standard constructed format
+	Standard = "standard"
+	//Modern identifies the Modern constructed format
+	Modern = "modern"
+	//Legacy identifies the Legacy constructed format
+	Legacy = "legacy"
+	//Commander identifies the Commander (EDH) constructed format
+	Commander = "commander"
+	//Pauper identifies the Pauper constructed format
+	Pauper = "pauper"
+
+	//RarityCommon is the id_rarity value of common cards
+	RarityCommon = 1
+)
+
+var (
+	//ErrUnknownFormat is raised when a format name is not registered in Formats
+	ErrUnknownFormat = errors.New("data.Format.UnknownErr: Message='Format is not registered'")
+	//ErrUnknownDeckCard is raised when a Deck card ID is not a card of the catalog
+	ErrUnknownDeckCard = errors.New("data.Deck.UnknownCardErr: Message='Deck has a Card.ID not found'")
+
+	basicLandType = regexp.MustCompile(`(?i)\bbasic\b`)
+
+	//anyNumberCards lists the cards whose text overrides the copy limit, zero means no limit at all
+	anyNumberCards = map[string]int{
+		"Relentless Rats":         0,
+		"Shadowborn Apostle":      0,
+		"Rat Colony":              0,
+		"Persistent Petitioners":  0,
+		"Dragon's Approach":       0,
+		"Slime Against Humanity":  0,
+		"Hare Apparent":           0,
+		"Templar Knight":          0,
+		"Tempest Hawk":            0,
+		"Cid, Timeless Artificer": 0,
+		"Seven Dwarves":           7,
+		"Nazgûl":                  9,
+	}
+
+	//Formats holds the construction rules of every supported format by name
+	Formats = map[string]Format{
+		Standard: {
+			Name:         Standard,
+			MinMainBoard: 60,
+			MaxSideBoard: 15,
+			MaxCopies:    4,
+			Banned: cardSet(
+				"Heartfire Hero", "Monstrous Rage", "Up the Beanstalk",
+				"Cori-Steel Cutter", "Vivi Ornitier", "Abuelo's Awakening",
+				"Proft's Eidetic Memory", "Screaming Nemesis",
+			),
+		},
+		Modern: {
+			Name:         Modern,
+			MinMainBoard: 60,
+			MaxSideBoard: 15,
+			MaxCopies:    4,
+			Banned: cardSet(
+				"Ancient Den", "Arcum's Astrolabe", "Birthing Pod", "Blazing Shoal",
+				"Bridge from Below", "Chrome Mox", "Cloudpost", "Dark Depths",
+				"Deathrite Shaman", "Dig Through Time", "Dread Return", "Eye of Ugin",
+				"Faithless Looting", "Field of the Dead", "Fury", "Gitaxian Probe",
+				"Glimpse of Nature", "Golgari Grave-Troll", "Great Furnace",
+				"Green Sun's Zenith", "Hogaak, Arisen Necropolis", "Hypergenesis",
+				"Krark-Clan Ironworks", "Lurrus of the Dream-Den", "Mental Misstep",
+				"Mox Opal", "Mycosynth Lattice", "Mystic Sanctuary", "Nadu, Winged Wisdom",
+				"Oko, Thief of Crowns", "Once Upon a Time", "Ponder", "Preordain",
+				"Punishing Fire", "Rite of Flame", "Seat of the Synod", "Second Sunrise",
+				"Seething Song", "Sensei's Divining Top", "Simian Spirit Guide",
+				"Skullclamp", "Splinter Twin", "Summer Bloom", "The One Ring",
+				"Tibalt's Trickery", "Treasure Cruise", "Tree of Tales",
+				"Umezawa's Jitte", "Uro, Titan of Nature's Wrath", "Vault of Whispers",
+				"Violent Outburst", "Yorion, Sky Nomad",
+			),
+		},
+		Legacy: {
+			Name:         Legacy,
+			MinMainBoard: 60,
+			MaxSideBoard: 15,
+			MaxCopies:    4,
+			Banned: cardSet(
+				"Ancestral Recall", "Balance", "Bazaar of Baghdad", "Black Lotus",
+				"Channel", "Chaos Orb", "Demonic Consultation", "Demonic Tutor",
+				"Dig Through Time", "Earthcraft", "Falling Star", "Fastbond", "Flash",
+				"Frantic Search", "Gitaxian Probe", "Goblin Recruiter", "Gush",
+				"Hermit Druid", "Imperial Seal", "Library of Alexandria", "Mana Crypt",
+				"Mana Drain", "Mana Vault", "Memory Jar", "Mental Misstep", "Mind Twist",
+				"Mind's Desire", "Mishra's Workshop", "Mox Emerald", "Mox Jet",
+				"Mox Pearl", "Mox Ruby", "Mox Sapphire", "Mystical Tutor", "Necropotence",
+				"Oath of Druids", "Sensei's Divining Top", "Shahrazad", "Skullclamp",
+				"Sol Ring", "Strip Mine", "Survival of the Fittest", "Time Vault",
+				"Time Walk", "Timetwister", "Tinker", "Tolarian Academy",
+				"Treasure Cruise", "Vampiric Tutor", "Wheel of Fortune", "Windfall",
+				"Wrenn and Six", "Yawgmoth's Bargain", "Yawgmoth's Will", "Zirda, the Dawnwaker",
+			),
+		},
+		Commander: {
+			Name:         Commander,
+			MinMainBoard: 100,
+			MaxMainBoard: 100,
+			MaxSideBoard: 0,
+			MaxCopies:    1,
+			Banned: cardSet(
+				"Ancestral Recall", "Balance", "Biorhythm", "Black Lotus",
+				"Braids, Cabal Minion", "Channel", "Chaos Orb", "Coalition Victory",
+				"Dockside Extortionist", "Emrakul, the Aeons Torn", "Erayo, Soratami Ascendant",
+				"Falling Star", "Fastbond", "Flash", "Gifts Ungiven", "Griselbrand",
+				"Hullbreacher", "Iona, Shield of Emeria", "Jeweled Lotus", "Karakas",
+				"Leovold, Emissary of Trest", "Library of Alexandria", "Limited Resources",
+				"Lutri, the Spellchaser", "Mana Crypt", "Mox Emerald", "Mox Jet",
+				"Mox Pearl", "Mox Ruby", "Mox Sapphire", "Nadu, Winged Wisdom",
+				"Panoptic Mirror", "Paradox Engine", "Primeval Titan", "Prophet of Kruphix",
+				"Recurring Nightmare", "Rofellos, Llanowar Emissary", "Shahrazad",
+				"Sundering Titan", "Sway of the Stars", "Sylvan Primordial", "Time Vault",
+				"Time Walk", "Tinker", "Tolarian Academy", "Trade Secrets", "Upheaval",
+				"Worldfire", "Yawgmoth's Bargain",
+			),
+		},
+		Pauper: {
+			Name:         Pauper,
+			MinMainBoard: 60,
+			MaxSideBoard: 15,
+			MaxCopies:    4,
+			OnlyCommons:  true,
+			Banned: cardSet(
+				"Arcum's Astrolabe", "Atog", "Bonder's Ornament", "Chatterstorm",
+				"Cloud of Faeries", "Cloudpost", "Cranial Plating", "Daze",
+				"Empty the Warrens", "Fall from Favor", "Frantic Search",
+				"Galvanic Relay", "Gitaxian Probe", "Grapeshot", "Gush", "High Tide",
+				"Hymn to Tourach", "Invigorate", "Kuldotha Rebirth", "Monastery Swiftspear",
+				"Mystic Sanctuary", "Peregrine Drake", "Prophetic Prism", "Sinkhole",
+				"Sojourner's Companion", "Temporal Fissure", "Treasure Cruise",
+			),
+		},
+	}
+)
+
+func cardSet(names ...string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
+
+//Format holds the deck construction rules of a constructed format
+type Format struct {
+	Name         string
+	MinMainBoard int
+	//MaxMainBoard is the main board upper bound, zero means no limit
+	MaxMainBoard int
+	MaxSideBoard int
+	//MaxCopies is the copy limit by card name summing both boards, basic lands excluded
+	MaxCopies   int
+	OnlyCommons bool
+	Banned      map[string]bool
+	Restricted  map[string]bool
+}
+
+//Violation describes one broken rule and the cards responsible for it
+type Violation struct {
+	Rule    string   `json:"rule"`
+	Message string   `json:"message"`
+	Cards   []string `json:"cards,omitempty"`
+}
+
+//Legality is the verdict of a Deck against one Format
+type Legality struct {
+	Format     string      `json:"format"`
+	Legal      bool        `json:"legal"`
+	Violations []Violation `json:"violations"`
+}
+
+//GetFormat returns the registered Format for the provided name
+func GetFormat(name string) (Format, error) {
+	format, found := Formats[strings.ToLower(strings.TrimSpace(name))]
+	if !found {
+		return Format{}, ErrUnknownFormat
+	}
+	return format, nil
+}
+
+//FormatNames returns the registered format names sorted
+func FormatNames() []string {
+	names := make([]string, 0, len(Formats))
+	for name := range Formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//IsBasicLand checks if the card type label identifies a basic land
+func (c Card) IsBasicLand() bool {
+	return basicLandType.MatchString(c.TypeLabel)
+}
+
+//Validate checks the provided Deck against the format construction rules.
+//Deck.Cards must be hydrated with Name, TypeLabel and IDRarity
+func (f Format) Validate(d *Deck) Legality {
+	legality := Legality{Format: f.Name, Violations: []Violation{}}
+	var (
+		mainBoard, sideBoard int
+		copies               = map[string]int{}
+		basics               = map[string]bool{}
+		names                []string
+		banned               []string
+		notCommons           []string
+	)
+	for _, card := range d.Cards {
+		switch card.DeckCard.IDBoard {
+		case MainBoard:
+			mainBoard += card.DeckCard.Quantity
+		case SideBoard:
+			sideBoard += card.DeckCard.Quantity
+		}
+		if _, seen := copies[card.Name]; !seen {
+			names = append(names, card.Name)
+			if f.Banned[card.Name] {
+				banned = append(banned, card.Name)
+			}
+			if f.OnlyCommons && card.IDRarity > RarityCommon && !card.IsBasicLand() {
+				notCommons = append(notCommons, card.Name)
+			}
+		}
+		copies[card.Name] += card.DeckCard.Quantity
+		if card.IsBasicLand() {
+			basics[card.Name] = true
+		}
+	}
+
+	if mainBoard < f.MinMainBoard {
+		legality.Violations = append(legality.Violations, Violation{
+			Rule:    "mainBoardSize",
+			Message: fmt.Sprintf("main board has %d cards, minimum is %d", mainBoard, f.MinMainBoard),
+		})
+	}
+	if f.MaxMainBoard > 0 && mainBoard > f.MaxMainBoard {
+		legality.Violations = append(legality.Violations, Violation{
+			Rule:    "mainBoardSize",
+			Message: fmt.Sprintf("main board has %d cards, maximum is %d", mainBoard, f.MaxMainBoard),
+		})
+	}
+	if sideBoard > f.MaxSideBoard {
+		legality.Violations = append(legality.Violations, Violation{
+			Rule:    "sideBoardSize",
+			Message: fmt.Sprintf("side board has %d cards, maximum is %d", sideBoard, f.MaxSideBoard),
+		})
+	}
+
+	var (
+		overRestricted []string
+		limits         []int
+		//overLimit are the cards over each applied copy limit, an anyNumberCards limit differs from MaxCopies
+		overLimit = map[int][]string{}
+	)
+	for _, name := range names {
+		if basics[name] {
+			continue
+		}
+		if f.Restricted[name] {
+			if copies[name] > 1 {
+				overRestricted = append(overRestricted, name)
+			}
+			continue
+		}
+		limit := f.MaxCopies
+		if anyLimit, found := anyNumberCards[name]; found {
+			limit = anyLimit
+		}
+		if limit > 0 && copies[name] > limit {
+			if _, found := overLimit[limit]; !found {
+				limits = append(limits, limit)
+			}
+			overLimit[limit] = append(overLimit[limit], name)
+		}
+	}
+	sort.Ints(limits)
+	for _, limit := range limits {
+		rule, message := "copyLimit", fmt.Sprintf("more than %d copies of a card", limit)
+		if limit == 1 && f.MaxCopies == 1 {
+			rule, message = "singleton", "more than one copy of a non basic land card"
+		}
+		legality.Violations = append(legality.Violations, Violation{Rule: rule, Message: message, Cards: overLimit[limit]})
+	}
+	if len(banned) > 0 {
+		legality.Violations = append(legality.Violations, Violation{
+			Rule:    "banned",
+			Message: fmt.Sprintf("cards banned in %s", f.Name),
+			Cards:   banned,
+		})
+	}
+	if len(overRestricted) > 0 {
+		legality.Violations = append(legality.Violations, Violation{
+			Rule:    "restricted",
+			Message: fmt.Sprintf("cards restricted to one copy in %s", f.Name),
+			Cards:   overRestricted,
+		})
+	}
+	if len(notCommons) > 0 {
+		legality.Violations = append(legality.Violations, Violation{
+			Rule:    "rarity",
+			Message: "only common cards are allowed",
+			Cards:   notCommons,
+		})
+	}
+	legality.Legal = len(legality.Violations) == 0
+	return legality
+}
+
+//Legality validates the Deck against all registered formats
+func (d *Deck) Legality() []Legality {
+	var legalities []Legality
+	for _, name := range FormatNames() {
+		legalities = append(legalities, Formats[name].Validate(d))
+	}
+	return legalities
+}
+
+//ReadCardAttributes fills the Name, TypeLabel and IDRarity of Deck.Cards using the Card.ID values.
+//It allows the validation of decks that were not persisted yet
+func (d *Deck) ReadCardAttributes(client raizel.Client) error {
+	if len(d.Cards) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(d.Cards))
+	for i, card := range d.Cards {
+		ids[i] = int64(card.ID)
+	}
+	query := `
+		select c.id, c.name, c.type_label, c.id_rarity
+		from card c
+		where c.id = any($1)
+	`
+	attributes := make(map[int]Card, len(ids))
+	iterFunc := func(i raizel.Iterable) error {
+		for i.Next() {
+			var card Card
+			if err := i.Scan(&card.ID, &card.Name, &card.TypeLabel, &card.IDRarity); err != nil {
+				return err
+			}
+			attributes[card.ID] = card
+		}
+		return nil
+	}
+	if err := client.Query(query, iterFunc, pq.Array(ids)); err != nil {
+		return err
+	}
+	for i := range d.Cards {
+		card, found := attributes[d.Cards[i].ID]
+		if !found {
+			l.Info("data.Deck.UnknownCard", l.Int("ID", d.ID), l.Int("Card.ID", d.Cards[i].ID))
+			return ErrUnknownDeckCard
+		}
+		d.Cards[i].Name = card.Name
+		d.Cards[i].TypeLabel = card.TypeLabel
+		d.Cards[i].IDRarity = card.IDRarity
+	}
+	return nil
+}
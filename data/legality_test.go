@@ -0,0 +1,124 @@
+package data_test
+
+import (
+	"testing"
+
+	"github.com/rjansen/fivecolors/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func deckCard(name, typeLabel string, idRarity, idBoard, quantity int) data.Card {
+	return data.Card{
+		Name:      name,
+		TypeLabel: typeLabel,
+		IDRarity:  idRarity,
+		DeckCard:  data.DeckCard{IDBoard: idBoard, Quantity: quantity},
+	}
+}
+
+func legalModernDeck() *data.Deck {
+	return &data.Deck{
+		Name: "Test_LegalModernDeck",
+		Cards: []data.Card{
+			deckCard("Lightning Bolt", "Instant", 1, data.MainBoard, 4),
+			deckCard("Goblin Guide", "Creature — Goblin Scout", 3, data.MainBoard, 4),
+			deckCard("Monastery Swiftspear", "Creature — Human Monk", 2, data.MainBoard, 4),
+			deckCard("Mountain", "Basic Land — Mountain", 0, data.MainBoard, 48),
+			deckCard("Smash to Smithereens", "Instant", 1, data.SideBoard, 4),
+		},
+	}
+}
+
+func Test_LegalityModernLegal(t *testing.T) {
+	legality := data.Formats[data.Modern].Validate(legalModernDeck())
+	assert.True(t, legality.Legal)
+	assert.Empty(t, legality.Violations)
+}
+
+func Test_LegalityBoardSizes(t *testing.T) {
+	deck := legalModernDeck()
+	deck.Cards[3].DeckCard.Quantity = 20
+	deck.Cards[4].DeckCard.Quantity = 16
+	legality := data.Formats[data.Modern].Validate(deck)
+	assert.False(t, legality.Legal)
+	assert.Len(t, legality.Violations, 3)
+	assert.Equal(t, "mainBoardSize", legality.Violations[0].Rule)
+	assert.Equal(t, "sideBoardSize", legality.Violations[1].Rule)
+	assert.Equal(t, "copyLimit", legality.Violations[2].Rule)
+	assert.Equal(t, []string{"Smash to Smithereens"}, legality.Violations[2].Cards)
+}
+
+func Test_LegalityCopyLimitSumsBoards(t *testing.T) {
+	deck := legalModernDeck()
+	deck.Cards = append(deck.Cards, deckCard("Lightning Bolt", "Instant", 1, data.SideBoard, 1))
+	legality := data.Formats[data.Legacy].Validate(deck)
+	assert.False(t, legality.Legal)
+	assert.Equal(t, "copyLimit", legality.Violations[0].Rule)
+	assert.Equal(t, []string{"Lightning Bolt"}, legality.Violations[0].Cards)
+}
+
+func Test_LegalityBannedAndRarity(t *testing.T) {
+	deck := legalModernDeck()
+	deck.Cards = append(deck.Cards, deckCard("Ponder", "Sorcery", 1, data.SideBoard, 1))
+
+	modern := data.Formats[data.Modern].Validate(deck)
+	assert.False(t, modern.Legal)
+	assert.Equal(t, "banned", modern.Violations[0].Rule)
+	assert.Equal(t, []string{"Ponder"}, modern.Violations[0].Cards)
+
+	pauper := data.Formats[data.Pauper].Validate(deck)
+	assert.False(t, pauper.Legal)
+	assert.Equal(t, "banned", pauper.Violations[0].Rule)
+	assert.Equal(t, []string{"Monastery Swiftspear"}, pauper.Violations[0].Cards)
+	assert.Equal(t, "rarity", pauper.Violations[1].Rule)
+	assert.Equal(t, []string{"Goblin Guide", "Monastery Swiftspear"}, pauper.Violations[1].Cards)
+}
+
+func Test_LegalityCommanderSingleton(t *testing.T) {
+	deck := &data.Deck{
+		Cards: []data.Card{
+			deckCard("Sol Ring", "Artifact", 2, data.MainBoard, 1),
+			deckCard("Relentless Rats", "Creature — Rat", 2, data.MainBoard, 30),
+			deckCard("Counterspell", "Instant", 1, data.MainBoard, 2),
+			deckCard("Island", "Basic Land — Island", 0, data.MainBoard, 67),
+		},
+	}
+	legality := data.Formats[data.Commander].Validate(deck)
+	assert.False(t, legality.Legal)
+	assert.Len(t, legality.Violations, 1)
+	assert.Equal(t, "singleton", legality.Violations[0].Rule)
+	assert.Equal(t, []string{"Counterspell"}, legality.Violations[0].Cards)
+}
+
+func Test_LegalityAnyNumberLimit(t *testing.T) {
+	deck := legalModernDeck()
+	deck.Cards = append(deck.Cards,
+		deckCard("Seven Dwarves", "Creature — Dwarf", 1, data.MainBoard, 8),
+		deckCard("Counterspell", "Instant", 1, data.MainBoard, 5),
+	)
+	legality := data.Formats[data.Modern].Validate(deck)
+	assert.False(t, legality.Legal)
+	assert.Len(t, legality.Violations, 2)
+	assert.Equal(t, "more than 4 copies of a card", legality.Violations[0].Message)
+	assert.Equal(t, []string{"Counterspell"}, legality.Violations[0].Cards)
+	assert.Equal(t, "copyLimit", legality.Violations[1].Rule)
+	assert.Equal(t, "more than 7 copies of a card", legality.Violations[1].Message)
+	assert.Equal(t, []string{"Seven Dwarves"}, legality.Violations[1].Cards)
+}
+
+func Test_LegalityAllFormats(t *testing.T) {
+	legalities := legalModernDeck().Legality()
+	assert.Len(t, legalities, len(data.Formats))
+	for _, legality := range legalities {
+		_, err := data.GetFormat(legality.Format)
+		assert.Nil(t, err)
+	}
+	_, err := data.GetFormat("vintage")
+	assert.Equal(t, data.ErrUnknownFormat, err)
+}
+
+func Test_ReadCardAttributesUnknownCard(t *testing.T) {
+	client := &fakeClient{rows: [][]interface{}{{1, "Lightning Bolt", "Instant", 1}}}
+	deck := &data.Deck{Cards: []data.Card{{ID: 1}, {ID: 2}}}
+	assert.Equal(t, data.ErrUnknownDeckCard, deck.ReadCardAttributes(client))
+}
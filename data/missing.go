@@ -0,0 +1,94 @@
+package data
+
+import (
+	"errors"
+
+	"github.com/rjansen/l"
+	"github.com/rjansen/raizel"
+)
+
+//MissingCard compares the copies a deck needs, summing both boards, with the copies of the inventory
+type MissingCard struct {
+	IDCard  int    `json:"idCard"`
+	Name    string `json:"name"`
+	Needed  int    `json:"needed"`
+	Owned   int    `json:"owned"`
+	Missing int    `json:"missing"`
+}
+
+//DeckMissing is the buildability of a Deck with the cards of an Inventory
+type DeckMissing struct {
+	IDDeck      int  `json:"idDeck"`
+	IDInventory int  `json:"idInventory"`
+	Exact       bool `json:"exact"`
+	Buildable   bool `json:"buildable"`
+	Needed      int  `json:"needed"`
+	Missing     int  `json:"missing"`
+	//Cards are the cards with missing copies
+	Cards []MissingCard `json:"cards"`
+}
+
+const (
+	//missingByName matches the inventory copies of any printing with the card name
+	missingByName = `
+		select min(c.id), c.name, sum(dc.quantity),
+			coalesce((select sum(i.quantity) from inventory_card i join card o on o.id = i.id_card
+				where i.id_inventory = $2 and o.name = c.name), 0)
+		from deck_card dc
+			join card c on c.id = dc.id_card
+		where dc.id_deck = $1
+		group by c.name
+		order by c.name`
+	//missingByPrinting matches only the inventory copies of the same card
+	missingByPrinting = `
+		select c.id, c.name, sum(dc.quantity),
+			coalesce((select sum(i.quantity) from inventory_card i where i.id_inventory = $2 and i.id_card = c.id), 0)
+		from deck_card dc
+			join card c on c.id = dc.id_card
+		where dc.id_deck = $1
+		group by c.id, c.name
+		order by c.name, c.id`
+)
+
+//Missing compares the Deck cards with the copies of the inventory. Copies of other printings with the same name
+//count unless exact is set
+func (d *Deck) Missing(client raizel.Client, idInventory int, exact bool) (DeckMissing, error) {
+	missing := DeckMissing{IDDeck: d.ID, IDInventory: idInventory, Exact: exact, Cards: []MissingCard{}}
+	if d.ID <= 0 {
+		return missing, errors.New("data.Deck.MissingErr: Message='Deck.ID is empty'")
+	}
+	if err := client.QueryOne("select d.id, d.name, d.id_player from deck d where d.id = $1", d.FetchSmall, d.ID); err != nil {
+		return missing, err
+	}
+	query := missingByName
+	if exact {
+		query = missingByPrinting
+	}
+	err := client.Query(query, func(i raizel.Iterable) error {
+		for i.Next() {
+			var card MissingCard
+			if err := i.Scan(&card.IDCard, &card.Name, &card.Needed, &card.Owned); err != nil {
+				return err
+			}
+			missing.Needed += card.Needed
+			if card.Owned >= card.Needed {
+				continue
+			}
+			card.Missing = card.Needed - card.Owned
+			missing.Missing += card.Missing
+			missing.Cards = append(missing.Cards, card)
+		}
+		return nil
+	}, d.ID, idInventory)
+	if err != nil {
+		return missing, err
+	}
+	missing.Buildable = missing.Missing == 0
+	l.Debug("data.Deck.Missing",
+		l.Int("ID", d.ID),
+		l.Int("IDInventory", idInventory),
+		l.Bool("Exact", exact),
+		l.Int("Missing", missing.Missing),
+	)
+	return missing, nil
+}
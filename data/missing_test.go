@@ -0,0 +1,40 @@
+package data_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rjansen/fivecolors/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DeckMissing(t *testing.T) {
+	client := &fakeClient{rows: [][]interface{}{
+		{1, "Goblin Guide", 4, 4},
+		{2, "Lightning Bolt", 5, 2},
+		{3, "Mountain", 20, 0},
+	}}
+	deck := data.Deck{ID: 7}
+	missing, err := deck.Missing(client, 3, false)
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(client.commands[1], "group by c.name\n"))
+	assert.Equal(t, []interface{}{7, 3}, client.params[1])
+	assert.False(t, missing.Buildable)
+	assert.Equal(t, 29, missing.Needed)
+	assert.Equal(t, 23, missing.Missing)
+	assert.Equal(t, []data.MissingCard{
+		{IDCard: 2, Name: "Lightning Bolt", Needed: 5, Owned: 2, Missing: 3},
+		{IDCard: 3, Name: "Mountain", Needed: 20, Missing: 20},
+	}, missing.Cards)
+}
+
+func Test_DeckMissingExactBuildable(t *testing.T) {
+	client := &fakeClient{rows: [][]interface{}{{1, "Goblin Guide", 4, 5}}}
+	deck := data.Deck{ID: 7}
+	missing, err := deck.Missing(client, 3, true)
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(client.commands[1], "i.id_card = c.id"))
+	assert.True(t, missing.Buildable)
+	assert.True(t, missing.Exact)
+	assert.Empty(t, missing.Cards)
+}
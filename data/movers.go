@@ -0,0 +1,163 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/rjansen/l"
+	"github.com/rjansen/raizel"
+)
+
+const (
+	//DefaultMoversWindow is the days of the price change of the movers when MoversOptions.Window is not set
+	DefaultMoversWindow = 7
+	//MaxMoversWindow is the greatest MoversOptions.Window accepted
+	MaxMoversWindow = 365
+	//DefaultMoversLimit is the number of movers read when MoversOptions.Limit is not set
+	DefaultMoversLimit = 20
+)
+
+//ErrInvalidMovers is raised when the movers window or limit is out of bounds
+var ErrInvalidMovers = errors.New("data.Inventory.InvalidMoversErr: Message='Movers window must be 1 to 365 days and limit 1 to 100'")
+
+//MoversOptions are the parameters of Inventory.Movers. The price change is from Date minus Window days to Date
+type MoversOptions struct {
+	Date   time.Time
+	Window int
+	Limit  int
+}
+
+//Mover is an inventory card finish with its price change, Card.InventoryCard holds the finish and its copies
+type Mover struct {
+	Card
+	From    float64 `json:"from"`
+	To      float64 `json:"to"`
+	Change  float64 `json:"change"`
+	Percent float64 `json:"percent"`
+	//ValueChange is the Change of all the copies
+	ValueChange float64 `json:"valueChange"`
+}
+
+//InventoryMovers are the cards of an inventory whose price changed the most, by percent, from Since to Date
+type InventoryMovers struct {
+	IDInventory int       `json:"idInventory"`
+	Date        time.Time `json:"date"`
+	Since       time.Time `json:"since"`
+	Window      int       `json:"window"`
+	Cards       []Mover   `json:"cards"`
+}
+
+//ParseMoversWindow reads a window of days like 7d, 30d or 7
+func ParseMoversWindow(value string) (int, error) {
+	if len(value) > 0 && value[len(value)-1] == 'd' {
+		value = value[:len(value)-1]
+	}
+	window, err := strconv.Atoi(value)
+	if err != nil || window < 1 || window > MaxMoversWindow {
+		return 0, ErrInvalidMovers
+	}
+	return window, nil
+}
+
+//BuildMovers creates the query of the IDInventory card finishes matching the Build restrictions with a price
+//on or before the date and on or before the since parameters, the biggest percent changes first
+func (q *CardQuery) BuildMovers(date, since time.Time, limit int) error {
+	idxParam, err := q.buildRestrictions()
+	if err != nil {
+		return err
+	}
+	q.Restrictions = append(q.Restrictions, "i.quantity > 0", "pt.price > 0", "pn.price <> pt.price")
+	q.Values = append(q.Values, date.Format(PriceDateLayout), since.Format(PriceDateLayout), limit)
+	query := fmt.Sprintf(`
+		select %s, i.finish, pt.price, pn.price
+		from card c%s
+			join %s on i.id_card = c.id
+			%s
+			%s
+		%sorder by abs(pn.price - pt.price) / pt.price desc, abs(pn.price - pt.price) desc, c.name, c.id, i.finish
+		limit $%d`,
+		cardFields, cardJoins, inventoryCopies("$"+strconv.Itoa(idxParam), q.copyFilter, "finish"),
+		moverPrice("pn", idxParam+1), moverPrice("pt", idxParam+2),
+		q.where(),
+		idxParam+3)
+	q.SQL = query
+	l.Debug("data.CardQuery.BuiltMovers",
+		l.String("Query", q.SQL),
+		l.Struct("Params", q.Values),
+	)
+	return nil
+}
+
+//moverPrice is the lateral join of the last price of the card c and inventory i finish on or before the date parameter
+func moverPrice(alias string, idxParam int) string {
+	return fmt.Sprintf(`join lateral (
+				select cp.price from card_price cp
+				where cp.id_card = c.id and cp.finish = i.finish and cp.dt_price <= $%d::date
+				order by cp.dt_price desc limit 1
+			) %s on true`, idxParam, alias)
+}
+
+//moverFetchable scans the finish and the prices selected after the card fields
+type moverFetchable struct {
+	raizel.Fetchable
+	mover *Mover
+}
+
+func (f moverFetchable) Scan(dest ...interface{}) error {
+	return f.Fetchable.Scan(append(dest, &f.mover.InventoryCard.Finish, &f.mover.From, &f.mover.To)...)
+}
+
+//Movers reads the Inventory card finishes, matching the cardQuery restrictions, whose price changed the most by
+//percent in the options window
+func (i *Inventory) Movers(client raizel.Client, cardQuery *CardQuery, options MoversOptions) (InventoryMovers, error) {
+	movers := InventoryMovers{IDInventory: i.ID, Cards: []Mover{}}
+	if i.ID <= 0 {
+		return movers, errors.New("data.Inventory.MoversErr: Message='Inventory.ID is empty'")
+	}
+	if options.Window == 0 {
+		options.Window = DefaultMoversWindow
+	}
+	if options.Limit == 0 {
+		options.Limit = DefaultMoversLimit
+	}
+	if options.Window < 1 || options.Window > MaxMoversWindow || options.Limit < 1 || options.Limit > selectLimit {
+		return movers, ErrInvalidMovers
+	}
+	if options.Date.IsZero() {
+		options.Date = time.Now()
+	}
+	if cardQuery == nil {
+		cardQuery = &CardQuery{}
+	}
+	movers.Window = options.Window
+	movers.Date = time.Date(options.Date.Year(), options.Date.Month(), options.Date.Day(), 0, 0, 0, 0, time.UTC)
+	movers.Since = movers.Date.AddDate(0, 0, -options.Window)
+	cardQuery.IDInventory = i.ID
+	if err := cardQuery.BuildMovers(movers.Date, movers.Since, options.Limit); err != nil {
+		return movers, err
+	}
+	err := client.Query(cardQuery.SQL, func(iterable raizel.Iterable) error {
+		for iterable.Next() {
+			var mover Mover
+			if err := mover.Card.FetchFull(moverFetchable{Fetchable: iterable, mover: &mover}); err != nil {
+				return err
+			}
+			mover.Change = cents(mover.To - mover.From)
+			mover.Percent = cents(mover.Change / mover.From * 100)
+			mover.ValueChange = cents(mover.Change * float64(mover.InventoryCard.Quantity))
+			movers.Cards = append(movers.Cards, mover)
+		}
+		return nil
+	}, cardQuery.Values...)
+	if err != nil {
+		return movers, err
+	}
+	l.Debug("data.Inventory.Movers",
+		l.Int("ID", i.ID),
+		l.Int("Window", options.Window),
+		l.Int("Cards.Len", len(movers.Cards)),
+	)
+	return movers, nil
+}
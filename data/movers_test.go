@@ -0,0 +1,85 @@
+package data_test
+
+import (
+	"testing"
+
+	"github.com/rjansen/fivecolors/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func moverRow(id int, name, finish string, quantity int, from, to float64) []interface{} {
+	return []interface{}{id, "", "", name, name, "", "", "", "Instant", 1, "", "", nil, 0, 0,
+		2, "Magic 2010", "m10", 0, 3, quantity, finish, from, to}
+}
+
+func Test_ParseMoversWindow(t *testing.T) {
+	for value, expected := range map[string]int{"7d": 7, "30d": 30, "1": 1, "365d": 365} {
+		window, err := data.ParseMoversWindow(value)
+		assert.Nil(t, err, value)
+		assert.Equal(t, expected, window, value)
+	}
+	for _, value := range []string{"", "d", "0d", "366d", "7w", "-1"} {
+		_, err := data.ParseMoversWindow(value)
+		assert.Equal(t, data.ErrInvalidMovers, err, value)
+	}
+}
+
+func Test_BuildMovers(t *testing.T) {
+	cardQuery := data.CardQuery{IDInventory: 3, Finish: "foil"}
+	assert.Nil(t, cardQuery.BuildMovers(priceDate("2026-10-16"), priceDate("2026-10-09"), 20))
+	assert.Equal(t, []interface{}{"foil", 3, "2026-10-16", "2026-10-09", 20}, cardQuery.Values)
+	assert.Contains(t, cardQuery.SQL, "select v.id_inventory, v.id_card, v.finish, sum(v.quantity) quantity")
+	assert.Contains(t, cardQuery.SQL, "v.id_inventory = $2 and v.finish in ($1) group by v.id_inventory, v.id_card, v.finish")
+	assert.Contains(t, cardQuery.SQL, "cp.finish = i.finish and cp.dt_price <= $3::date")
+	assert.Contains(t, cardQuery.SQL, "cp.finish = i.finish and cp.dt_price <= $4::date")
+	assert.Contains(t, cardQuery.SQL, "where coalesce(i.quantity, 0) > 0 and i.quantity > 0 and pt.price > 0 and pn.price <> pt.price")
+	assert.Contains(t, cardQuery.SQL, "limit $5")
+}
+
+func Test_InventoryMovers(t *testing.T) {
+	client := &fakeClient{rows: [][]interface{}{
+		moverRow(1, "Lightning Bolt", "foil", 2, 4.0, 6.0),
+		moverRow(2, "Counterspell", "nonfoil", 4, 1.0, 0.75),
+	}}
+	inventory := data.Inventory{ID: 3}
+	movers, err := inventory.Movers(client, nil, data.MoversOptions{Date: priceDate("2026-10-16"), Window: 30})
+	assert.Nil(t, err)
+	assert.Equal(t, priceDate("2026-09-16"), movers.Since)
+	assert.Equal(t, 30, movers.Window)
+	assert.Len(t, movers.Cards, 2)
+	assert.Equal(t, "Lightning Bolt", movers.Cards[0].Name)
+	assert.Equal(t, data.FinishFoil, movers.Cards[0].InventoryCard.Finish)
+	assert.Equal(t, 2.0, movers.Cards[0].Change)
+	assert.Equal(t, 50.0, movers.Cards[0].Percent)
+	assert.Equal(t, 4.0, movers.Cards[0].ValueChange)
+	assert.Equal(t, -25.0, movers.Cards[1].Percent)
+	assert.Equal(t, -1.0, movers.Cards[1].ValueChange)
+	assert.Equal(t, []interface{}{3, "2026-10-16", "2026-09-16", data.DefaultMoversLimit}, client.params[0])
+
+	for _, options := range []data.MoversOptions{{Window: 400}, {Limit: 101}, {Window: -1}} {
+		_, err = inventory.Movers(&fakeClient{}, nil, options)
+		assert.Equal(t, data.ErrInvalidMovers, err)
+	}
+}
+
+func Test_CardPrices(t *testing.T) {
+	client := &fakeClient{rows: [][]interface{}{
+		{"foil", priceDate("2026-10-15"), 6.0},
+		{"foil", priceDate("2026-10-16"), 6.5},
+		{"nonfoil", priceDate("2026-10-16"), 1.75},
+	}}
+	card := data.Card{ID: 1}
+	prices, err := card.ReadPrices(client, priceDate("2026-10-01"), priceDate("2026-10-16"), "")
+	assert.Nil(t, err)
+	assert.Len(t, prices.Series, 2)
+	assert.Equal(t, data.FinishFoil, prices.Series[0].Finish)
+	assert.Len(t, prices.Series[0].Prices, 2)
+	assert.Equal(t, 1.75, prices.Series[1].Prices[0].Price)
+	assert.Equal(t, []interface{}{1, "", "2026-10-01", "2026-10-16"}, client.params[1])
+
+	client = &fakeClient{}
+	prices, err = card.ReadPrices(client, priceDate(""), priceDate("2026-10-16"), data.FinishEtched)
+	assert.Nil(t, err)
+	assert.Empty(t, prices.Series)
+	assert.Equal(t, []interface{}{1, data.FinishEtched, "2025-10-16", "2026-10-16"}, client.params[1])
+}
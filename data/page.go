@@ -0,0 +1,214 @@
+package data
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rjansen/raizel"
+)
+
+//MaxPageSize is the greatest Query.Limit accepted by PageSize
+const MaxPageSize = 500
+
+//ErrInvalidCursor is raised when the cursor was not created by a query with the same order
+var ErrInvalidCursor = errors.New("data.Query.InvalidCursorErr: Message='Cursor is invalid for this query order'")
+
+//PageSize returns the limit bounded to MaxPageSize, zero or negative limits use the default page size
+func PageSize(limit int) int {
+	if limit <= 0 {
+		return selectLimit
+	}
+	if limit > MaxPageSize {
+		return MaxPageSize
+	}
+	return limit
+}
+
+//sortColumn is one whitelisted order by expression
+type sortColumn struct {
+	expr string
+	desc bool
+}
+
+//build composes the SQL of the select fields and the from clause with the restrictions, the keyset
+//restriction of Query.Cursor, the whitelisted order ending with the unique idColumn and the page limit.
+//CountSQL counts the rows matching the restrictions without the page
+func (q *Query) build(selectFields, from string, columns map[string]string, defaultOrder, idColumn string, idxParam int) error {
+	q.CountSQL = "select count(1) " + from + q.where()
+	q.countValues = append([]interface{}(nil), q.Values...)
+
+	spec := ParseSortSpec(q.Order)
+	if len(spec) == 0 {
+		spec = ParseSortSpec(defaultOrder)
+	}
+	orderSQL, err := spec.SQL(columns)
+	if err != nil {
+		return err
+	}
+	q.sort = q.sort[:0]
+	hasID := false
+	for _, key := range spec {
+		q.sort = append(q.sort, sortColumn{expr: columns[key.Field], desc: key.Desc})
+		hasID = hasID || columns[key.Field] == idColumn
+	}
+	if !hasID {
+		q.sort = append(q.sort, sortColumn{expr: idColumn})
+		orderSQL += ", " + idColumn
+	}
+	q.orderSQL = orderSQL
+	if q.Cursor != "" {
+		if idxParam, err = q.keyset(idxParam); err != nil {
+			return err
+		}
+	}
+	cursorFields := make([]string, len(q.sort))
+	for i, column := range q.sort {
+		cursorFields[i] = column.expr
+	}
+	query := "select " + selectFields + ", " + strings.Join(cursorFields, ", ") + "\n" + from + q.where() + " order by " + orderSQL
+	if q.Limit > 0 {
+		//One more row tells whether there is a next page
+		idxParam++
+		query += fmt.Sprintf(" limit $%d", idxParam)
+		q.Values = append(q.Values, q.Limit+1)
+		if q.Cursor == "" && q.Offset > 0 {
+			idxParam++
+			query += fmt.Sprintf(" offset $%d", idxParam)
+			q.Values = append(q.Values, q.Offset)
+		}
+	}
+	q.SQL = query
+	return nil
+}
+
+func (q *Query) where() string {
+	if len(q.Restrictions) == 0 {
+		return ""
+	}
+	return "where " + strings.Join(q.Restrictions, " and ") + "\n"
+}
+
+//keyset restricts the rows to the ones after the cursor row: (k1 > v1) or (k1 = v1 and k2 > v2) ...
+//using < for the descending keys
+func (q *Query) keyset(idxParam int) (int, error) {
+	values, err := decodeCursor(q.Cursor, q.orderSQL)
+	if err != nil || len(values) != len(q.sort) {
+		return idxParam, ErrInvalidCursor
+	}
+	params := make([]string, len(values))
+	for i, value := range values {
+		idxParam++
+		params[i] = fmt.Sprintf("$%d", idxParam)
+		q.Values = append(q.Values, value)
+	}
+	var alternatives []string
+	for i, column := range q.sort {
+		var terms []string
+		for j := 0; j < i; j++ {
+			terms = append(terms, fmt.Sprintf("%s = %s", q.sort[j].expr, params[j]))
+		}
+		operator := ">"
+		if column.desc {
+			operator = "<"
+		}
+		terms = append(terms, fmt.Sprintf("%s %s %s", column.expr, operator, params[i]))
+		alternatives = append(alternatives, "("+strings.Join(terms, " and ")+")")
+	}
+	q.Restrictions = append(q.Restrictions, "("+strings.Join(alternatives, " or ")+")")
+	return idxParam, nil
+}
+
+//cursor is the encoded form of Query.NextCursor, the order binds it to the query that created it
+type cursor struct {
+	Order  string        `json:"o"`
+	Values []interface{} `json:"v"`
+}
+
+func encodeCursor(order string, values []interface{}) string {
+	for i, value := range values {
+		if raw, isBytes := value.([]byte); isBytes {
+			values[i] = string(raw)
+		}
+	}
+	raw, err := json.Marshal(cursor{Order: order, Values: values})
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(encoded, order string) ([]interface{}, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var decoded cursor
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, err
+	}
+	if decoded.Order != order {
+		return nil, ErrInvalidCursor
+	}
+	values := decoded.Values
+	for i, value := range values {
+		switch typedValue := value.(type) {
+		case json.Number:
+			values[i] = typedValue.String()
+		case string, bool:
+		default:
+			return nil, ErrInvalidCursor
+		}
+	}
+	return values, nil
+}
+
+//cursorFetchable scans the sort columns selected after the entity fields into the cursor row
+type cursorFetchable struct {
+	raizel.Fetchable
+	q *Query
+}
+
+func (f cursorFetchable) Scan(dest ...interface{}) error {
+	row := make([]interface{}, len(f.q.sort))
+	for i := range row {
+		dest = append(dest, &row[i])
+	}
+	if err := f.Fetchable.Scan(dest...); err != nil {
+		return err
+	}
+	f.q.cursorRow = row
+	return nil
+}
+
+//fetchable wraps the iterable to keep the sort columns of the fetched row
+func (q *Query) fetchable(i raizel.Iterable) raizel.Fetchable {
+	return cursorFetchable{Fetchable: i, q: q}
+}
+
+//pageFull tells whether the page already holds Query.Limit rows and, when it does, fills Query.NextCursor
+//with the last fetched row. The remaining row is only iterated so the rows are released
+func (q *Query) pageFull(fetched int) bool {
+	if q.Limit <= 0 || fetched < q.Limit {
+		return false
+	}
+	if q.NextCursor == "" {
+		q.NextCursor = encodeCursor(q.orderSQL, q.cursorRow)
+	}
+	return true
+}
+
+//count reads the number of rows matching the restrictions into Query.Total when Query.WithTotal is set
+func (q *Query) count(client raizel.Client) error {
+	if !q.WithTotal {
+		return nil
+	}
+	return client.QueryOne(q.CountSQL, func(f raizel.Fetchable) error {
+		return f.Scan(&q.Total)
+	}, q.countValues...)
+}
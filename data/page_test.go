@@ -0,0 +1,93 @@
+package data_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rjansen/fivecolors/data"
+	"github.com/stretchr/testify/assert"
+)
+
+//pageRows is a raizel.Iterable over in memory rows
+type pageRows struct {
+	rows [][]interface{}
+	next int
+}
+
+func (r *pageRows) Next() bool {
+	r.next++
+	return r.next <= len(r.rows)
+}
+
+func (r *pageRows) Scan(dest ...interface{}) error {
+	for i, value := range r.rows[r.next-1] {
+		switch target := dest[i].(type) {
+		case *int:
+			*target = value.(int)
+		case *string:
+			*target = value.(string)
+		case *float64:
+			*target = value.(float64)
+		case *bool:
+			*target = value.(bool)
+		case *time.Time:
+			*target = value.(time.Time)
+		case *interface{}:
+			*target = value
+		}
+	}
+	return nil
+}
+
+func Test_DeckQueryCursor(t *testing.T) {
+	deckQuery := data.DeckQuery{Query: data.Query{Limit: 2, Order: "-name"}}
+	assert.Nil(t, deckQuery.Build())
+	assert.Equal(t, "select d.id, d.name, d.id_player, d.name, d.id\nfrom deck d\n order by d.name desc, d.id limit $1", deckQuery.SQL)
+	assert.Equal(t, []interface{}{3}, deckQuery.Values)
+
+	rows := &pageRows{rows: [][]interface{}{
+		{3, "Burn", 1, "Burn", int64(3)},
+		{1, "Affinity", 1, []byte("Affinity"), int64(1)},
+		{2, "Affinity", 2, "Affinity", int64(2)},
+	}}
+	assert.Nil(t, deckQuery.Fetch(rows))
+	assert.Len(t, deckQuery.Result, 2)
+	assert.Equal(t, 4, rows.next)
+	assert.NotEmpty(t, deckQuery.NextCursor)
+
+	next := data.DeckQuery{Query: data.Query{Limit: 2, Order: "-name", Cursor: deckQuery.NextCursor}, RegexName: "a"}
+	assert.Nil(t, next.Build())
+	assert.Contains(t, next.SQL, "where d.name ~* $1 and ((d.name < $2) or (d.name = $2 and d.id > $3))")
+	assert.Equal(t, []interface{}{"a", "Affinity", "1", 3}, next.Values)
+	assert.Equal(t, "select count(1) from deck d\nwhere d.name ~* $1\n", next.CountSQL)
+
+	next.Order = "name"
+	assert.Equal(t, data.ErrInvalidCursor, next.Build())
+	next.Order, next.Cursor = "-name", "bm90IGpzb24"
+	assert.Equal(t, data.ErrInvalidCursor, next.Build())
+}
+
+func Test_QueryLastPage(t *testing.T) {
+	expansionQuery := data.ExpansionQuery{Query: data.Query{Limit: 2}, Hydrate: "small"}
+	assert.Nil(t, expansionQuery.Build())
+	rows := &pageRows{rows: [][]interface{}{{1, "Alpha", 10, "Alpha", int64(1)}, {2, "Beta", 11, "Beta", int64(2)}}}
+	assert.Nil(t, expansionQuery.Fetch(rows))
+	assert.Len(t, expansionQuery.Result, 2)
+	assert.Empty(t, expansionQuery.NextCursor)
+	assert.Equal(t, 100, data.PageSize(0))
+	assert.Equal(t, data.MaxPageSize, data.PageSize(10000))
+}
+
+func Test_InventoryReadCardsPage(t *testing.T) {
+	client := &fakeClient{}
+	inventory := data.Inventory{ID: 3}
+	cardQuery := data.CardQuery{Query: data.Query{Limit: 50, WithTotal: true}}
+	assert.Nil(t, inventory.ReadCards(client, &cardQuery))
+	assert.Len(t, client.commands, 3)
+	assert.Contains(t, client.commands[0], "limit $3")
+	assert.Equal(t, []interface{}{"1", 3, 51}, client.params[0])
+	assert.Contains(t, client.commands[1], "select count(1)")
+	assert.Equal(t, 7, cardQuery.Total)
+	assert.Empty(t, cardQuery.NextCursor)
+	assert.NotNil(t, inventory.Totals)
+}
@@ -0,0 +1,370 @@
+package data
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/rjansen/l"
+	"github.com/rjansen/raizel"
+)
+
+const (
+	//PriceDateLayout is the layout of the price dates
+	PriceDateLayout = "2006-01-02"
+	//DefaultPriceProvider is the MTGJSON paper provider read from the AllPrices files
+	DefaultPriceProvider = "tcgplayer"
+	//PriceUUID is the MTGJSON uuid column of a price CSV
+	PriceUUID = "uuid"
+	//PriceIDCard is the card id column of a price CSV
+	PriceIDCard = "idCard"
+	//PriceSet is the set code column of a price CSV
+	PriceSet = "set"
+	//PriceNumber is the collector number column of a price CSV
+	PriceNumber = "number"
+	//PriceFinish is the finish column of a price CSV, an empty finish is nonfoil
+	PriceFinish = "finish"
+	//PriceDate is the date column of a price CSV, without it the prices are dated today
+	PriceDate = "date"
+	//PriceValue is the price column of a price CSV
+	PriceValue = "price"
+	//priceBatchCards is the number of AllPrices cards sent at once to the pricesFunc
+	priceBatchCards = 500
+)
+
+var (
+	//ErrInvalidPrices is raised when the file is not a MTGJSON AllPrices file
+	ErrInvalidPrices = errors.New("data.Prices.InvalidErr: Message='File is not a MTGJSON AllPrices file'")
+	//ErrInvalidPriceColumns is raised when the price CSV has no price column or no uuid, card id nor set and number columns
+	ErrInvalidPriceColumns = errors.New("data.Prices.InvalidColumnsErr: Message='Price CSV requires a price column and uuid, card id or set and number columns'")
+
+	//priceHeaders are the header names, lower case, of the price CSV fields
+	priceHeaders = map[string][]string{
+		PriceUUID:   {"uuid", "mtgjson uuid"},
+		PriceIDCard: {"id_card", "idcard", "card id", "id"},
+		PriceSet:    {"set code", "edition code", "set"},
+		PriceNumber: {"collector number", "card number", "number"},
+		PriceFinish: {"finish", "foil", "printing"},
+		PriceDate:   {"date", "price date"},
+		PriceValue:  {"price", "retail", "market price"},
+	}
+	//mtgjsonFinishes maps the MTGJSON price finishes to the inventory finishes
+	mtgjsonFinishes = map[string]string{"normal": FinishNonFoil, "foil": FinishFoil, "etched": FinishEtched}
+)
+
+//PriceRow is one dated price of a card finish read from a price file and the card it resolved to
+type PriceRow struct {
+	Line   int       `json:"line,omitempty"`
+	UUID   string    `json:"uuid,omitempty"`
+	Set    string    `json:"set,omitempty"`
+	Number string    `json:"number,omitempty"`
+	IDCard int       `json:"idCard"`
+	Finish string    `json:"finish"`
+	Date   time.Time `json:"date"`
+	Price  float64   `json:"price"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+//mtgjsonPrices is the price entry of one card uuid of a MTGJSON AllPrices file
+type mtgjsonPrices struct {
+	Paper map[string]struct {
+		Retail map[string]map[string]float64 `json:"retail"`
+	} `json:"paper"`
+}
+
+//ReadAllPrices streams the paper retail prices of the provider in a MTGJSON AllPrices or AllPricesToday file
+//to the pricesFunc, priceBatchCards cards at a time, so the whole file is never held in memory
+func ReadAllPrices(r io.Reader, provider string, pricesFunc func([]PriceRow) error) error {
+	if provider == "" {
+		provider = DefaultPriceProvider
+	}
+	decoder := json.NewDecoder(r)
+	if err := expectDelim(decoder, '{'); err != nil {
+		return ErrInvalidPrices
+	}
+	for decoder.More() {
+		key, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		if key != "data" {
+			var skip json.RawMessage
+			if err := decoder.Decode(&skip); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := expectDelim(decoder, '{'); err != nil {
+			return ErrInvalidPrices
+		}
+		var (
+			rows  []PriceRow
+			cards int
+		)
+		for decoder.More() {
+			token, err := decoder.Token()
+			if err != nil {
+				return err
+			}
+			uuid, _ := token.(string)
+			var prices mtgjsonPrices
+			if err := decoder.Decode(&prices); err != nil {
+				return err
+			}
+			for finish, dates := range prices.Paper[provider].Retail {
+				for date, price := range dates {
+					row := PriceRow{UUID: uuid, Finish: mtgjsonFinishes[finish], Price: price}
+					if row.Finish == "" {
+						continue
+					}
+					if row.Date, err = time.Parse(PriceDateLayout, date); err != nil {
+						return ErrInvalidPrices
+					}
+					rows = append(rows, row)
+				}
+			}
+			if cards++; cards == priceBatchCards {
+				if err := pricesFunc(rows); err != nil {
+					return err
+				}
+				rows, cards = nil, 0
+			}
+		}
+		if len(rows) > 0 {
+			return pricesFunc(rows)
+		}
+		return nil
+	}
+	return ErrInvalidPrices
+}
+
+//ReadPriceCSV reads the price rows of the CSV. Rows without a date are dated today and rows with an invalid
+//finish, date or price carry the Reason
+func ReadPriceCSV(r io.Reader) ([]PriceRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, ErrInvalidPriceColumns
+		}
+		return nil, err
+	}
+	indexes := headerIndexes(header, priceHeaders, nil)
+	_, hasPrice := indexes[PriceValue]
+	_, hasUUID := indexes[PriceUUID]
+	_, hasIDCard := indexes[PriceIDCard]
+	_, hasSet := indexes[PriceSet]
+	_, hasNumber := indexes[PriceNumber]
+	if !hasPrice || (!hasUUID && !hasIDCard && (!hasSet || !hasNumber)) {
+		return nil, ErrInvalidPriceColumns
+	}
+	today, _ := time.Parse(PriceDateLayout, time.Now().Format(PriceDateLayout))
+	var rows []PriceRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		field := func(name string) string {
+			if index, found := indexes[name]; found && index < len(record) {
+				return strings.TrimSpace(record[index])
+			}
+			return ""
+		}
+		row := PriceRow{Line: line, UUID: field(PriceUUID), Set: field(PriceSet), Number: field(PriceNumber), Date: today}
+		price := strings.TrimPrefix(field(PriceValue), "$")
+		if price == "" {
+			continue
+		}
+		var validFinish bool
+		row.Finish, validFinish = ParseFinish(field(PriceFinish))
+		if id := field(PriceIDCard); id != "" {
+			if row.IDCard, err = strconv.Atoi(id); err != nil {
+				row.Reason = "invalid card id"
+			}
+		}
+		if date := field(PriceDate); date != "" && row.Reason == "" {
+			if row.Date, err = time.Parse(PriceDateLayout, date); err != nil {
+				row.Reason = "invalid date"
+			}
+		}
+		if row.Reason == "" {
+			if row.Price, err = strconv.ParseFloat(price, 64); err != nil || row.Price < 0 {
+				row.Reason = "invalid price"
+			} else if !validFinish {
+				row.Reason = "invalid finish"
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+//ResolvePrices finds the Card ID of the rows without one by MTGJSON uuid or by set code and collector number.
+//The rows not found get the Reason
+func ResolvePrices(client raizel.Client, rows []PriceRow) error {
+	cards := map[string]int{}
+	var uuids []string
+	for _, row := range rows {
+		if row.IDCard == 0 && row.UUID != "" && row.Reason == "" {
+			uuids = append(uuids, row.UUID)
+		}
+	}
+	if len(uuids) > 0 {
+		err := client.Query("select c.uuid, c.id from card c where c.uuid = any($1)", func(i raizel.Iterable) error {
+			for i.Next() {
+				var (
+					uuid string
+					id   int
+				)
+				if err := i.Scan(&uuid, &id); err != nil {
+					return err
+				}
+				cards[uuid] = id
+			}
+			return nil
+		}, pq.Array(uuids))
+		if err != nil {
+			return err
+		}
+	}
+	for index := range rows {
+		row := &rows[index]
+		if row.IDCard != 0 || row.Reason != "" {
+			continue
+		}
+		if row.UUID != "" {
+			if row.IDCard = cards[row.UUID]; row.IDCard == 0 {
+				row.Reason = "card not found"
+			}
+			continue
+		}
+		if row.Set == "" || row.Number == "" {
+			row.Reason = "uuid, card id or set and number required"
+			continue
+		}
+		var err error
+		if row.IDCard, err = resolveCard(client, "", row.Set, row.Number); err != nil {
+			if err != raizel.ErrNotFound {
+				return err
+			}
+			row.Reason = "card not found"
+		}
+	}
+	return nil
+}
+
+//PersistPrices upserts the resolved rows without Reason into card_price and returns the number of prices persisted.
+//The last price of a repeated card, finish and date wins
+func PersistPrices(client raizel.Client, rows []PriceRow) (int, error) {
+	type priceKey struct {
+		idCard int
+		finish string
+		date   time.Time
+	}
+	values := make([][]interface{}, 0, len(rows))
+	valueByKey := make(map[priceKey]int, len(rows))
+	for _, row := range rows {
+		if row.IDCard <= 0 || row.Reason != "" {
+			continue
+		}
+		key := priceKey{row.IDCard, row.Finish, row.Date}
+		value := []interface{}{row.IDCard, row.Finish, row.Date.Format(PriceDateLayout), row.Price}
+		if index, repeated := valueByKey[key]; repeated {
+			values[index] = value
+			continue
+		}
+		valueByKey[key] = len(values)
+		values = append(values, value)
+	}
+	insert := `
+		insert into card_price (id_card, finish, dt_price, price)
+		values %s
+		on conflict(id_card, finish, dt_price)
+		do update set price = excluded.price
+	`
+	if err := batchInsert(client, insert, values); err != nil {
+		return 0, err
+	}
+	l.Debug("data.Prices.Persisted",
+		l.Int("Rows.Len", len(rows)),
+		l.Int("Prices.Len", len(values)),
+	)
+	return len(values), nil
+}
+
+//PricePoint is the price of a card finish on a date
+type PricePoint struct {
+	Date  time.Time `json:"date"`
+	Price float64   `json:"price"`
+}
+
+//PriceSeries is the price history of one finish of a card
+type PriceSeries struct {
+	Finish string       `json:"finish"`
+	Prices []PricePoint `json:"prices"`
+}
+
+//CardPrices is the price history of a card by finish from From to To
+type CardPrices struct {
+	IDCard int           `json:"idCard"`
+	From   time.Time     `json:"from"`
+	To     time.Time     `json:"to"`
+	Series []PriceSeries `json:"series"`
+}
+
+//ReadPrices reads the Card prices from the from to the to dates, only of the finish when it is set.
+//The to date defaults to today and the from date to one year before the to date
+func (c *Card) ReadPrices(client raizel.Client, from, to time.Time, finish string) (CardPrices, error) {
+	prices := CardPrices{IDCard: c.ID, Series: []PriceSeries{}}
+	if c.ID <= 0 {
+		return prices, errors.New("data.Card.ReadPricesErr: Message='Card.ID is empty'")
+	}
+	if to.IsZero() {
+		to = time.Now()
+	}
+	prices.To = time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	prices.From = prices.To.AddDate(-1, 0, 0)
+	if !from.IsZero() {
+		prices.From = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	var id int
+	if err := client.QueryOne("select c.id from card c where c.id = $1", fetchInt(&id), c.ID); err != nil {
+		return prices, err
+	}
+	query := `
+		select cp.finish, cp.dt_price, cp.price
+		from card_price cp
+		where cp.id_card = $1 and ($2 = '' or cp.finish = $2) and cp.dt_price between $3::date and $4::date
+		order by cp.finish, cp.dt_price`
+	err := client.Query(query, func(i raizel.Iterable) error {
+		for i.Next() {
+			var (
+				seriesFinish string
+				point        PricePoint
+			)
+			if err := i.Scan(&seriesFinish, &point.Date, &point.Price); err != nil {
+				return err
+			}
+			last := len(prices.Series) - 1
+			if last < 0 || prices.Series[last].Finish != seriesFinish {
+				prices.Series = append(prices.Series, PriceSeries{Finish: seriesFinish})
+				last++
+			}
+			prices.Series[last].Prices = append(prices.Series[last].Prices, point)
+		}
+		return nil
+	}, c.ID, finish, prices.From.Format(PriceDateLayout), prices.To.Format(PriceDateLayout))
+	return prices, err
+}
@@ -0,0 +1,136 @@
+package data_test
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rjansen/fivecolors/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func priceDate(value string) time.Time {
+	date, _ := time.Parse(data.PriceDateLayout, value)
+	return date
+}
+
+func Test_ReadAllPrices(t *testing.T) {
+	prices := `{"meta": {"date": "2026-10-16"}, "data": {
+		"uuid-bolt": {"paper": {
+			"tcgplayer": {"currency": "USD", "retail": {
+				"normal": {"2026-10-15": 1.5, "2026-10-16": 1.75},
+				"foil": {"2026-10-16": 6.2}
+			}, "buylist": {"normal": {"2026-10-16": 0.9}}},
+			"cardkingdom": {"retail": {"normal": {"2026-10-16": 1.99}}}
+		}, "mtgo": {"cardhoarder": {"retail": {"normal": {"2026-10-16": 0.03}}}}},
+		"uuid-ice": {"paper": {"cardmarket": {"retail": {"normal": {"2026-10-16": 0.5}}}}}
+	}}`
+	var rows []data.PriceRow
+	err := data.ReadAllPrices(strings.NewReader(prices), "", func(batch []data.PriceRow) error {
+		rows = append(rows, batch...)
+		return nil
+	})
+	assert.Nil(t, err)
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Finish != rows[j].Finish {
+			return rows[i].Finish < rows[j].Finish
+		}
+		return rows[i].Date.Before(rows[j].Date)
+	})
+	assert.Equal(t, []data.PriceRow{
+		{UUID: "uuid-bolt", Finish: data.FinishFoil, Date: priceDate("2026-10-16"), Price: 6.2},
+		{UUID: "uuid-bolt", Finish: data.FinishNonFoil, Date: priceDate("2026-10-15"), Price: 1.5},
+		{UUID: "uuid-bolt", Finish: data.FinishNonFoil, Date: priceDate("2026-10-16"), Price: 1.75},
+	}, rows)
+
+	err = data.ReadAllPrices(strings.NewReader(`[]`), "", func([]data.PriceRow) error { return nil })
+	assert.Equal(t, data.ErrInvalidPrices, err)
+}
+
+func Test_ReadPriceCSV(t *testing.T) {
+	csv := "Set Code,Collector Number,Finish,Date,Price\n" +
+		"M10,146,,2026-10-16,$1.75\n" +
+		"M10,146,foil,2026-10-16,6.20\n" +
+		"M10,147,,16/10/2026,1\n" +
+		"M10,148,shiny,2026-10-16,1\n" +
+		"M10,149,,2026-10-16,\n"
+	rows, err := data.ReadPriceCSV(strings.NewReader(csv))
+	assert.Nil(t, err)
+	assert.Len(t, rows, 4)
+	assert.Equal(t, data.PriceRow{Line: 2, Set: "M10", Number: "146", Finish: data.FinishNonFoil,
+		Date: priceDate("2026-10-16"), Price: 1.75}, rows[0])
+	assert.Equal(t, data.FinishFoil, rows[1].Finish)
+	assert.Equal(t, "invalid date", rows[2].Reason)
+	assert.Equal(t, "invalid finish", rows[3].Reason)
+
+	_, err = data.ReadPriceCSV(strings.NewReader("Name,Price\nLightning Bolt,1\n"))
+	assert.Equal(t, data.ErrInvalidPriceColumns, err)
+}
+
+func Test_ResolveAndPersistPrices(t *testing.T) {
+	rows := []data.PriceRow{
+		{UUID: "uuid-bolt", Finish: data.FinishNonFoil, Date: priceDate("2026-10-16"), Price: 1.5},
+		{UUID: "uuid-bolt", Finish: data.FinishNonFoil, Date: priceDate("2026-10-16"), Price: 1.75},
+		{UUID: "uuid-unknown", Finish: data.FinishNonFoil, Date: priceDate("2026-10-16"), Price: 3},
+		{Set: "M10", Number: "146", Finish: data.FinishFoil, Date: priceDate("2026-10-16"), Price: 6.2},
+		{Finish: data.FinishFoil, Date: priceDate("2026-10-16"), Price: 6.2},
+	}
+	client := &fakeClient{rows: [][]interface{}{{"uuid-bolt", 11}}}
+	assert.Nil(t, data.ResolvePrices(client, rows))
+	assert.Equal(t, 11, rows[0].IDCard)
+	assert.Equal(t, "card not found", rows[2].Reason)
+	assert.Equal(t, 7, rows[3].IDCard)
+	assert.Equal(t, "uuid, card id or set and number required", rows[4].Reason)
+
+	client = &fakeClient{}
+	persisted, err := data.PersistPrices(client, rows)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, persisted)
+	assert.Contains(t, client.commands[0], "on conflict(id_card, finish, dt_price)")
+	assert.Equal(t, []interface{}{11, "nonfoil", "2026-10-16", 1.75, 7, "foil", "2026-10-16", 6.2}, client.params[0])
+}
+
+func Test_InventoryValue(t *testing.T) {
+	client := &fakeClient{rows: [][]interface{}{
+		{1, "Lightning Bolt", "nonfoil", 4, 1.75, true},
+		{2, "Fire // Ice", "nonfoil", 1, 0.0, false},
+		{1, "Lightning Bolt", "foil", 1, 6.2, true},
+	}}
+	inventory := data.Inventory{ID: 3}
+	value, err := inventory.Value(client, data.ValueOptions{Date: priceDate("2026-10-16")})
+	assert.Nil(t, err)
+	assert.Equal(t, 13.2, value.Value)
+	assert.Equal(t, 6, value.Copies)
+	assert.Equal(t, 1, value.Unpriced)
+	assert.Equal(t, 7.0, value.Cards[0].Value)
+	assert.Equal(t, data.FinishFoil, value.Cards[1].Finish)
+	assert.Equal(t, "Fire // Ice", value.Cards[2].Name)
+	assert.Equal(t, []interface{}{3, "2026-10-16"}, client.params[0])
+	assert.Nil(t, value.History)
+
+	client = &fakeClient{}
+	_, err = inventory.Value(client, data.ValueOptions{Date: priceDate("2026-10-16"), From: priceDate("2026-09-16"), Interval: "week"})
+	assert.Nil(t, err)
+	assert.Contains(t, client.commands[1], "generate_series($2::date, $3::date, interval '1 week')")
+	assert.Equal(t, []interface{}{3, "2026-09-16", "2026-10-16"}, client.params[1])
+
+	for _, options := range []data.ValueOptions{
+		{Date: priceDate("2026-10-16"), From: priceDate("2026-10-17")},
+		{Date: priceDate("2026-10-16"), From: priceDate("2026-09-16"), Interval: "hour"},
+		{Date: priceDate("2026-10-16"), From: priceDate("2024-01-01")},
+	} {
+		_, err = inventory.Value(&fakeClient{}, options)
+		assert.Equal(t, data.ErrInvalidValueHistory, err)
+	}
+}
+
+func Test_DeckValue(t *testing.T) {
+	client := &fakeClient{rows: [][]interface{}{{1, "Lightning Bolt", "nonfoil", 4, 1.75, true}}}
+	deck := data.Deck{ID: 5}
+	value, err := deck.Value(client, data.ValueOptions{Date: priceDate("2026-10-16")})
+	assert.Nil(t, err)
+	assert.Equal(t, 5, value.IDDeck)
+	assert.Equal(t, 7.0, value.Value)
+	assert.Contains(t, client.commands[1], "'nonfoil' finish, sum(quantity) quantity from deck_card")
+}
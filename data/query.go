@@ -1,6 +1,7 @@
 package data
 
 import (
+	"errors"
 	"fmt"
 	"github.com/rjansen/l"
 	"github.com/rjansen/raizel"
@@ -8,13 +9,49 @@ import (
 	"strings"
 )
 
+//ErrInvalidInventoryQtd is raised when the minimum inventory quantity is not a number
+var ErrInvalidInventoryQtd = errors.New("data.CardQuery.InvalidInventoryQtdErr: Message='InventoryQtd is not a number'")
+
 type Query struct {
 	Restrictions []string
 	Values       []interface{}
 	SQL          string
-	Order        string
+	//CountSQL counts the rows matching the restrictions, it is executed when WithTotal is set
+	CountSQL string
+	//Order is the sort spec, see ParseSortSpec, checked against the whitelist of the entity
+	Order string
+	//Limit is the page size, zero reads all rows. Cursor is the NextCursor of the previous page
+	//and Offset skips rows when there is no Cursor
+	Limit     int
+	Cursor    string
+	Offset    int
+	WithTotal bool
+	//NextCursor is filled by Fetch when there are rows after the page
+	NextCursor string
+	//Total is filled when WithTotal is set
+	Total int
+
+	countValues []interface{}
+	sort        []sortColumn
+	orderSQL    string
+	cursorRow   []interface{}
 }
 
+const (
+	//cardFields are the Card.FetchFull fields of the card c, expansion e, expansion_asset a and inventory i aliases
+	cardFields = `c.id, c.multiverseid, c.multiverse_number, c.name, c.label, coalesce(c.text, ''),
+                coalesce(c.manacost_label, ''), coalesce(c.combatpower_label, ''), c.type_label,
+                c.id_rarity, coalesce(c.flavor, ''), c.artist, c.rate, c.rate_votes, c.id_asset,
+                e.id, e.name, e.label, a.id_asset,
+				coalesce(i.id_inventory, 0), coalesce(i.quantity, 0)`
+	//fullTextConfig is the text search configuration of the card.search_vector column
+	fullTextConfig = "english"
+	//cardJoins are the expansion e and expansion_asset a joins of the card c
+	cardJoins = `
+                left join expansion e on c.id_expansion = e.id
+                left join expansion_asset a on a.id_expansion = e.id and a.id_rarity = c.id_rarity`
+)
+
 type CardQuery struct {
 	Query
 	//Result Fields
@@ -30,10 +67,33 @@ type CardQuery struct {
 	NotRegexText string
 	IDExpansion  string
 	Number       string
+	//InventoryQtd is the minimum quantity of the IDInventory copies
 	InventoryQtd string
+	//Search is a card search query, see ParseCardSearch
+	Search string
+	//FullText is a web search query, with "quoted phrases", or and -excluded words, over the stemmed
+	//name, type, rules text and flavor. Without an order the matches are sorted by the rank order field
+	FullText string
+	//IDInventory is the inventory whose quantities are reported and filtered by InventoryQtd
+	IDInventory int
+	//Finish, Condition and Language are comma separated lists that filter the summed inventory copies,
+	//Graded "true" sums only the graded copies and "false" only the raw ones
+	Finish    string
+	Condition string
+	Language  string
+	Graded    string
+	//Totals is the BuildTotals result
+	Totals *InventoryTotals
+
+	copyFilter    []string
+	inventoryFrom string
+	rankColumn    string
 }
 
-func (q *CardQuery) Build() error {
+//buildRestrictions resets and fills the query restrictions and values and the inventory_card join
+//and returns the last parameter index, which is the inventory one used by the join
+func (q *CardQuery) buildRestrictions() (int, error) {
+	q.Restrictions, q.Values = nil, nil
 	idxParam := 0
 	if q.RegexName != "" {
 		idxParam++
@@ -85,43 +145,125 @@ func (q *CardQuery) Build() error {
 		q.Values = append(q.Values, q.Number)
 	}
 	if q.InventoryQtd != "" {
+		if _, err := strconv.Atoi(q.InventoryQtd); err != nil {
+			return idxParam, ErrInvalidInventoryQtd
+		}
 		idxParam++
 		q.Restrictions = append(q.Restrictions, fmt.Sprintf("coalesce(i.quantity, 0) >= $%d", idxParam))
 		q.Values = append(q.Values, q.InventoryQtd)
 	}
+	if strings.TrimSpace(q.Search) != "" {
+		node, err := ParseCardSearch(q.Search)
+		if err != nil {
+			return idxParam, err
+		}
+		restriction, values, lastParam, err := CompileCardSearch(q.Search, node, idxParam)
+		if err != nil {
+			return idxParam, err
+		}
+		idxParam = lastParam
+		q.Restrictions = append(q.Restrictions, restriction)
+		q.Values = append(q.Values, values...)
+	}
+	q.rankColumn = ""
+	if fullText := strings.TrimSpace(q.FullText); fullText != "" {
+		idxParam++
+		tsQuery := fmt.Sprintf("websearch_to_tsquery('%s', $%d)", fullTextConfig, idxParam)
+		q.Restrictions = append(q.Restrictions, "c.search_vector @@ "+tsQuery)
+		q.Values = append(q.Values, fullText)
+		q.rankColumn = "ts_rank_cd(c.search_vector, " + tsQuery + ")"
+	}
+	copyRestrictions, copyValues, idxParam, err := q.copyRestrictions(idxParam)
+	if err != nil {
+		return idxParam, err
+	}
+	if len(copyRestrictions) > 0 {
+		q.Restrictions = append(q.Restrictions, "coalesce(i.quantity, 0) > 0")
+		q.Values = append(q.Values, copyValues...)
+	}
+	idxParam++
+	q.Values = append(q.Values, q.IDInventory)
+	q.copyFilter = copyRestrictions
+	q.inventoryFrom = inventoryCopies("$"+strconv.Itoa(idxParam), copyRestrictions)
+	return idxParam, nil
+}
 
-	query :=
-		`
-            select c.id, c.multiverseid, c.multiverse_number, c.name, c.label, coalesce(c.text, ''),
-                coalesce(c.manacost_label, ''), coalesce(c.combatpower_label, ''), c.type_label,
-                c.id_rarity, coalesce(c.flavor, ''), c.artist, c.rate, c.rate_votes, c.id_asset,
-                e.id, e.name, e.label, a.id_asset,
-				coalesce(i.id_inventory, 0), coalesce(i.quantity, 0)
-            from card c
-                left join expansion e on c.id_expansion = e.id
-                left join expansion_asset a on a.id_expansion = e.id and a.id_rarity = c.id_rarity
-				left join inventory_card i on i.id_inventory = 0 and i.id_card = c.id
-            where ` + strings.Join(q.Restrictions, " and ")
+func (q *CardQuery) Build() error {
+	idxParam, err := q.buildRestrictions()
+	if err != nil {
+		return err
+	}
+	from := `
+            from card c` + cardJoins + `
+				left join ` + q.inventoryFrom + ` on i.id_card = c.id
+			`
+	columns, defaultOrder := CardSortFields, "expansion,number,name"
+	if q.rankColumn != "" {
+		//The rank order field is only accepted with a full text query
+		columns = make(map[string]string, len(CardSortFields)+1)
+		for field, column := range CardSortFields {
+			columns[field] = column
+		}
+		columns["rank"] = q.rankColumn
+		defaultOrder = "-rank,name"
+	}
+	if err := q.build(cardFields, from, columns, defaultOrder, "c.id", idxParam); err != nil {
+		return err
+	}
+	l.Debug("data.CardQuery.Built",
+		l.String("Query", q.SQL),
+		l.Struct("Params", q.Values),
+	)
+	return nil
+}
 
-	if q.Order != "" {
-		query += " order by " + q.Order
-	} else {
-		query += ` order by e.name, NULLIF(regexp_replace(multiverse_number, '\D', '', 'g'), '')::int, c.name`
+//BuildTotals creates the query that sums, per expansion, the distinct cards and the copies
+//of the IDInventory inventory matching the same restrictions of Build
+func (q *CardQuery) BuildTotals() error {
+	if _, err := q.buildRestrictions(); err != nil {
+		return err
 	}
+	query := `
+        select e.id, coalesce(e.code, ''), e.name, count(distinct c.id), coalesce(sum(i.quantity), 0)
+        from card c
+            left join expansion e on c.id_expansion = e.id
+            join ` + q.inventoryFrom + ` on i.id_card = c.id
+		`
+	query += q.where() + " group by e.id, e.code, e.name order by e.name"
 
 	q.SQL = query
-	l.Debug("data.CardQuery.Built",
+	l.Debug("data.CardQuery.BuiltTotals",
 		l.String("Query", q.SQL),
 		l.Struct("Params", q.Values),
 	)
 	return nil
 }
 
+//FetchTotals reads the BuildTotals result into CardQuery.Totals
+func (q *CardQuery) FetchTotals(i raizel.Iterable) error {
+	totals := InventoryTotals{Expansions: []ExpansionTotal{}}
+	for i.Next() {
+		var expansionTotal ExpansionTotal
+		if fetchErr := i.Scan(&expansionTotal.Expansion.ID, &expansionTotal.Expansion.Code,
+			&expansionTotal.Expansion.Name, &expansionTotal.DistinctCards, &expansionTotal.Copies); fetchErr != nil {
+			return fetchErr
+		}
+		totals.DistinctCards += expansionTotal.DistinctCards
+		totals.Copies += expansionTotal.Copies
+		totals.Expansions = append(totals.Expansions, expansionTotal)
+	}
+	q.Totals = &totals
+	return nil
+}
+
 func (q *CardQuery) Fetch(i raizel.Iterable) error {
-	var resultCards []Card
+	resultCards := []Card{}
 	for i.Next() {
+		if q.pageFull(len(resultCards)) {
+			continue
+		}
 		var card Card
-		if fetchErr := card.FetchFull(i); fetchErr != nil {
+		if fetchErr := card.FetchFull(q.fetchable(i)); fetchErr != nil {
 			return fetchErr
 		}
 		resultCards = append(resultCards, card)
@@ -143,6 +285,7 @@ type TokenQuery struct {
 }
 
 func (q *TokenQuery) Build() error {
+	q.Restrictions, q.Values = nil, nil
 	idxParam := 0
 	if q.RegexName != "" {
 		idxParam++
@@ -168,28 +311,18 @@ func (q *TokenQuery) Build() error {
 			l.Warn("TokenQuery.Build.ExpansionParamErr", l.String("Parameter", q.IDExpansion), l.Err(convertErr))
 		}
 	}
-	query :=
-		`
-		select t.id, t.name, t.label, coalesce(t.text, ''), coalesce(t.color, ''), 
+	selectFields := `t.id, t.name, t.label, coalesce(t.text, ''), coalesce(t.color, ''), 
 			coalesce(t.combat_power, ''), coalesce(t.power, ''), coalesce(t.toughness, ''),
 			t.type, t.artist, t.id_asset,
-            e.id, e.name, e.label, a.id_asset
+            e.id, e.name, e.label, a.id_asset`
+	from := `
         from token t
             left join expansion e on t.id_expansion = e.id
             left join expansion_asset a on a.id_expansion = e.id and a.id_rarity = 0
 		`
-
-	if len(q.Restrictions) > 0 {
-		query += "where " + strings.Join(q.Restrictions, " and ") + "\n"
-	}
-
-	if q.Order != "" {
-		query += " order by " + q.Order
-	} else {
-		query += " order by e.name, t.type, t.name"
+	if err := q.build(selectFields, from, TokenSortFields, "expansion,type,name", "t.id", idxParam); err != nil {
+		return err
 	}
-
-	q.SQL = query
 	l.Debug("data.TokenQuery.Built",
 		l.String("Query", q.SQL),
 		l.Struct("Params", q.Values),
@@ -198,10 +331,13 @@ func (q *TokenQuery) Build() error {
 }
 
 func (q *TokenQuery) Fetch(i raizel.Iterable) error {
-	var resultTokens []Token
+	resultTokens := []Token{}
 	for i.Next() {
+		if q.pageFull(len(resultTokens)) {
+			continue
+		}
 		var token Token
-		if fetchErr := token.FetchFull(i); fetchErr != nil {
+		if fetchErr := token.FetchFull(q.fetchable(i)); fetchErr != nil {
 			return fetchErr
 		}
 		resultTokens = append(resultTokens, token)
@@ -220,6 +356,7 @@ type ExpansionQuery struct {
 }
 
 func (q *ExpansionQuery) Build() error {
+	q.Restrictions, q.Values = nil, nil
 	idxParam := 0
 	if q.RegexName != "" {
 		idxParam++
@@ -233,21 +370,13 @@ func (q *ExpansionQuery) Build() error {
 	default:
 		selectFields = "e.id, e.name, e.label, a.id_asset"
 	}
-	query :=
-		`
-        select ` + selectFields + `
+	from := `
         from expansion e
         left join expansion_asset a on e.id = a.id_expansion and (a.id_rarity = 0 or a.id_rarity = 4)
         `
-	if len(q.Restrictions) > 0 {
-		query += "where " + strings.Join(q.Restrictions, " and ") + "\n"
-	}
-	if q.Order != "" {
-		query += " order by " + q.Order
-	} else {
-		query += " order by e.name"
+	if err := q.build(selectFields, from, ExpansionSortFields, "name", "e.id", idxParam); err != nil {
+		return err
 	}
-	q.SQL = query
 	l.Debug("data.ExpansionQuery.Built",
 		l.String("Query", q.SQL),
 		l.Struct("Params", q.Values),
@@ -256,8 +385,11 @@ func (q *ExpansionQuery) Build() error {
 }
 
 func (q *ExpansionQuery) Fetch(i raizel.Iterable) error {
-	var resultExpansions []Expansion
+	resultExpansions := []Expansion{}
 	for i.Next() {
+		if q.pageFull(len(resultExpansions)) {
+			continue
+		}
 		var expansion Expansion
 		var fetchFunc func(raizel.Fetchable) error
 		switch q.Hydrate {
@@ -266,7 +398,7 @@ func (q *ExpansionQuery) Fetch(i raizel.Iterable) error {
 		default:
 			fetchFunc = expansion.FetchFull
 		}
-		if fetchErr := fetchFunc(i); fetchErr != nil {
+		if fetchErr := fetchFunc(q.fetchable(i)); fetchErr != nil {
 			return fetchErr
 		}
 		resultExpansions = append(resultExpansions, expansion)
@@ -285,6 +417,7 @@ type DeckQuery struct {
 }
 
 func (q *DeckQuery) Build() error {
+	q.Restrictions, q.Values = nil, nil
 	idxParam := 0
 	if q.RegexName != "" {
 		idxParam++
@@ -299,19 +432,9 @@ func (q *DeckQuery) Build() error {
 	// default:
 	// 	selectFields = "e.id, e.name, e.label, a.id_asset"
 	// }
-	query := `
-    select d.id, d.name, d.id_player from deck d
-    `
-	if len(q.Restrictions) > 0 {
-		query += "where " + strings.Join(q.Restrictions, " and ") + "\n"
-	}
-	if q.Order != "" {
-		query += " order by " + q.Order
-	} else {
-		query += " order by d.name"
+	if err := q.build("d.id, d.name, d.id_player", "from deck d\n", DeckSortFields, "name", "d.id", idxParam); err != nil {
+		return err
 	}
-
-	q.SQL = query
 	l.Debug("data.DeckQuery.Built",
 		l.String("Query", q.SQL),
 		l.Struct("Params", q.Values),
@@ -320,11 +443,14 @@ func (q *DeckQuery) Build() error {
 }
 
 func (q *DeckQuery) Fetch(i raizel.Iterable) error {
-	var resultDecks []Deck
+	resultDecks := []Deck{}
 	for i.Next() {
+		if q.pageFull(len(resultDecks)) {
+			continue
+		}
 		var deck Deck
 		//TODO: Think better to fetch by hydrate parameter
-		if fetchErr := deck.FetchSmall(i); fetchErr != nil {
+		if fetchErr := deck.FetchSmall(q.fetchable(i)); fetchErr != nil {
 			return fetchErr
 		}
 		resultDecks = append(resultDecks, deck)
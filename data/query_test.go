@@ -0,0 +1,61 @@
+package data_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rjansen/fivecolors/data"
+	"github.com/rjansen/l"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	l.Setup(new(l.Configuration))
+}
+
+func Test_CardQueryInventory(t *testing.T) {
+	cardQuery := data.CardQuery{RegexName: "bolt", InventoryQtd: "1", IDInventory: 7}
+	assert.Nil(t, cardQuery.Build())
+	assert.Contains(t, cardQuery.SQL, "where v.id_inventory = $3 group by v.id_inventory, v.id_card) i on i.id_card = c.id")
+	assert.Contains(t, cardQuery.SQL, "where c.name ~* $1 and coalesce(i.quantity, 0) >= $2")
+	assert.Equal(t, []interface{}{"bolt", "1", 7}, cardQuery.Values)
+
+	invalid := data.CardQuery{InventoryQtd: "lightning", IDInventory: 7}
+	assert.Equal(t, data.ErrInvalidInventoryQtd, invalid.Build())
+}
+
+func Test_CardQueryWithoutRestrictions(t *testing.T) {
+	cardQuery := data.CardQuery{IDInventory: 3}
+	assert.Nil(t, cardQuery.Build())
+	assert.Empty(t, cardQuery.Restrictions)
+	assert.Equal(t, []interface{}{3}, cardQuery.Values)
+}
+
+func Test_CardQueryPageAndTotals(t *testing.T) {
+	cardQuery := data.CardQuery{Query: data.Query{Limit: 50, Offset: 100}, RegexType: "creature", IDInventory: 5}
+	assert.Nil(t, cardQuery.Build())
+	assert.True(t, strings.HasSuffix(cardQuery.SQL, "limit $3 offset $4"))
+	assert.Equal(t, []interface{}{"creature", 5, 51, 100}, cardQuery.Values)
+
+	assert.Nil(t, cardQuery.BuildTotals())
+	assert.Contains(t, cardQuery.SQL, "join (select v.id_inventory, v.id_card, sum(v.quantity) quantity from inventory_card v")
+	assert.Contains(t, cardQuery.SQL, "where v.id_inventory = $2 group by v.id_inventory, v.id_card) i on i.id_card = c.id")
+	assert.Contains(t, cardQuery.SQL, "group by e.id")
+	assert.Equal(t, []interface{}{"creature", 5}, cardQuery.Values)
+}
+
+func Test_CardQueryFullText(t *testing.T) {
+	cardQuery := data.CardQuery{FullText: ` "deals damage" -player`, RegexType: "instant", IDInventory: 3}
+	assert.Nil(t, cardQuery.Build())
+	assert.Contains(t, cardQuery.SQL, "where c.type_label ~* $1 and c.search_vector @@ websearch_to_tsquery('english', $2)")
+	assert.Contains(t, cardQuery.SQL, "order by ts_rank_cd(c.search_vector, websearch_to_tsquery('english', $2)) desc, c.name, c.id")
+	assert.Equal(t, []interface{}{"instant", `"deals damage" -player`, 3}, cardQuery.Values)
+
+	cardQuery = data.CardQuery{FullText: "goblin", Query: data.Query{Order: "cmc,-rank"}}
+	assert.Nil(t, cardQuery.Build())
+	assert.Contains(t, cardQuery.SQL, "ts_rank_cd(c.search_vector, websearch_to_tsquery('english', $1)) desc, c.id")
+
+	cardQuery = data.CardQuery{Query: data.Query{Order: "rank"}}
+	_, isSortErr := cardQuery.Build().(*data.SortError)
+	assert.True(t, isSortErr)
+}
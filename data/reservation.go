@@ -0,0 +1,175 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/rjansen/l"
+	"github.com/rjansen/raizel"
+)
+
+//Reservation is the copies of an inventory card committed to one deck board
+type Reservation struct {
+	IDDeck   int    `json:"idDeck"`
+	DeckName string `json:"deckName"`
+	IDBoard  int    `json:"idBoard"`
+	Quantity int    `json:"quantity"`
+}
+
+//ReservedCard is an inventory card with the copies committed to decks and the free copies
+type ReservedCard struct {
+	IDCard    int           `json:"idCard"`
+	Name      string        `json:"name"`
+	Quantity  int           `json:"quantity"`
+	Committed int           `json:"committed"`
+	Free      int           `json:"free"`
+	Decks     []Reservation `json:"decks"`
+}
+
+//ReservationWarning reports an inventory card committed beyond its copies
+type ReservationWarning struct {
+	IDCard    int    `json:"idCard"`
+	Name      string `json:"name"`
+	Quantity  int    `json:"quantity"`
+	Committed int    `json:"committed"`
+	Message   string `json:"message"`
+}
+
+//InventoryReservations are the commitments of the cards of an inventory
+type InventoryReservations struct {
+	IDInventory int                  `json:"idInventory"`
+	Cards       []ReservedCard       `json:"cards"`
+	Warnings    []ReservationWarning `json:"warnings"`
+}
+
+//readOwnedID confirms the Deck belongs to Deck.IDPlayer, raizel.ErrNotFound is returned for the deck of another player
+func (d *Deck) readOwnedID(client raizel.Client) error {
+	var idDeck int
+	return client.QueryOne("select d.id from deck d where d.id = $1 and d.id_player = $2", fetchInt(&idDeck), d.ID, d.IDPlayer)
+}
+
+//Reserve commits the inventory copies of every card of the Deck of Deck.IDPlayer, replacing the previous reservations
+//of the deck in the inventory. Commitments beyond the inventory copies are accepted and reported by ReadReservations.
+//raizel.ErrNotFound is returned when the deck does not belong to the player
+func (d *Deck) Reserve(client raizel.Client, idInventory int) error {
+	if d.ID <= 0 || d.IDPlayer <= 0 || idInventory <= 0 {
+		return errors.New("data.Deck.ReserveErr: Message='Deck.ID, Deck.IDPlayer or IDInventory is empty'")
+	}
+	return InTransaction(client, func(tx raizel.Client) error {
+		if err := d.readOwnedID(tx); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("delete from deck_reservation where id_deck = $1 and id_inventory = $2", d.ID, idInventory); err != nil {
+			return err
+		}
+		result, err := tx.Exec(`
+			insert into deck_reservation (id_deck, id_card, id_board, id_inventory, quantity)
+			select id_deck, id_card, id_board, $2, quantity from deck_card where id_deck = $1 and quantity > 0`,
+			d.ID, idInventory)
+		if err != nil {
+			return err
+		}
+		reserved, _ := result.RowsAffected()
+		l.Info("data.Deck.Reserved",
+			l.Int("ID", d.ID),
+			l.Int("IDInventory", idInventory),
+			l.Int64("Cards", reserved),
+		)
+		return nil
+	})
+}
+
+//Release removes the reservations of the Deck of Deck.IDPlayer in the inventory, an empty idInventory releases every
+//inventory. raizel.ErrNotFound is returned when the deck does not belong to the player
+func (d *Deck) Release(client raizel.Client, idInventory int) error {
+	if d.ID <= 0 || d.IDPlayer <= 0 {
+		return errors.New("data.Deck.ReleaseErr: Message='Deck.ID or Deck.IDPlayer is empty'")
+	}
+	return InTransaction(client, func(tx raizel.Client) error {
+		if err := d.readOwnedID(tx); err != nil {
+			return err
+		}
+		_, err := tx.Exec("delete from deck_reservation where id_deck = $1 and ($2 = 0 or id_inventory = $2)", d.ID, idInventory)
+		return err
+	})
+}
+
+//trimReservations keeps the reservations of the Deck within its deck_card quantities after a persist
+func (d *Deck) trimReservations(client raizel.Client) error {
+	_, err := client.Exec(`
+		delete from deck_reservation r
+		where r.id_deck = $1 and not exists (
+			select 1 from deck_card dc
+			where dc.id_deck = r.id_deck and dc.id_card = r.id_card and dc.id_board = r.id_board and dc.quantity > 0)`, d.ID)
+	if err != nil {
+		return err
+	}
+	_, err = client.Exec(`
+		update deck_reservation r set quantity = dc.quantity
+		from deck_card dc
+		where r.id_deck = $1 and dc.id_deck = r.id_deck and dc.id_card = r.id_card and dc.id_board = r.id_board
+			and r.quantity > dc.quantity`, d.ID)
+	return err
+}
+
+//ReadReservations reads the Inventory cards with copies or reservations, the decks using them and the free copies
+func (i *Inventory) ReadReservations(client raizel.Client) (InventoryReservations, error) {
+	reservations := InventoryReservations{IDInventory: i.ID, Cards: []ReservedCard{}, Warnings: []ReservationWarning{}}
+	if i.ID <= 0 {
+		return reservations, errors.New("data.Inventory.ReadReservationsErr: Message='Inventory.ID is empty'")
+	}
+	query := `
+		select c.id, c.name, coalesce(i.quantity, 0), coalesce(r.id_deck, 0), coalesce(d.name, ''),
+			coalesce(r.id_board, 0), coalesce(r.quantity, 0)
+		from (
+			select id_card from inventory_card where id_inventory = $1 and quantity > 0
+			union
+			select id_card from deck_reservation where id_inventory = $1
+		) k
+			join card c on c.id = k.id_card
+			left join ` + inventoryCopies("$1", nil) + ` on i.id_card = k.id_card
+			left join deck_reservation r on r.id_inventory = $1 and r.id_card = k.id_card
+			left join deck d on d.id = r.id_deck
+		order by c.name, c.id, d.name, r.id_deck, r.id_board`
+	err := client.Query(query, func(iterable raizel.Iterable) error {
+		for iterable.Next() {
+			var (
+				card        ReservedCard
+				reservation Reservation
+			)
+			if err := iterable.Scan(&card.IDCard, &card.Name, &card.Quantity,
+				&reservation.IDDeck, &reservation.DeckName, &reservation.IDBoard, &reservation.Quantity); err != nil {
+				return err
+			}
+			last := len(reservations.Cards) - 1
+			if last < 0 || reservations.Cards[last].IDCard != card.IDCard {
+				card.Decks = []Reservation{}
+				reservations.Cards = append(reservations.Cards, card)
+				last++
+			}
+			if reservation.IDDeck > 0 {
+				reservations.Cards[last].Decks = append(reservations.Cards[last].Decks, reservation)
+				reservations.Cards[last].Committed += reservation.Quantity
+			}
+		}
+		return nil
+	}, i.ID)
+	if err != nil {
+		return reservations, err
+	}
+	for index := range reservations.Cards {
+		card := &reservations.Cards[index]
+		if card.Committed > card.Quantity {
+			reservations.Warnings = append(reservations.Warnings, ReservationWarning{
+				IDCard:    card.IDCard,
+				Name:      card.Name,
+				Quantity:  card.Quantity,
+				Committed: card.Committed,
+				Message:   fmt.Sprintf("%d copies committed to decks but %d owned", card.Committed, card.Quantity),
+			})
+			continue
+		}
+		card.Free = card.Quantity - card.Committed
+	}
+	return reservations, nil
+}
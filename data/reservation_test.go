@@ -0,0 +1,62 @@
+package data_test
+
+import (
+	"testing"
+
+	"github.com/rjansen/fivecolors/data"
+	"github.com/rjansen/raizel"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_InventoryReadReservations(t *testing.T) {
+	client := &fakeClient{rows: [][]interface{}{
+		{1, "Goblin Guide", 4, 10, "Burn", data.MainBoard, 4},
+		{1, "Goblin Guide", 4, 11, "Zoo", data.MainBoard, 2},
+		{2, "Lightning Bolt", 8, 10, "Burn", data.MainBoard, 4},
+		{2, "Lightning Bolt", 8, 10, "Burn", data.SideBoard, 1},
+		{3, "Mountain", 30, 0, "", 0, 0},
+	}}
+	inventory := data.Inventory{ID: 3}
+	reservations, err := inventory.ReadReservations(client)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{3}, client.params[0])
+	assert.Len(t, reservations.Cards, 3)
+
+	assert.Equal(t, 6, reservations.Cards[0].Committed)
+	assert.Equal(t, 0, reservations.Cards[0].Free)
+	assert.Equal(t, []data.Reservation{
+		{IDDeck: 10, DeckName: "Burn", IDBoard: data.MainBoard, Quantity: 4},
+		{IDDeck: 11, DeckName: "Zoo", IDBoard: data.MainBoard, Quantity: 2},
+	}, reservations.Cards[0].Decks)
+
+	assert.Equal(t, 5, reservations.Cards[1].Committed)
+	assert.Equal(t, 3, reservations.Cards[1].Free)
+	assert.Equal(t, data.ReservedCard{IDCard: 3, Name: "Mountain", Quantity: 30, Free: 30, Decks: []data.Reservation{}},
+		reservations.Cards[2])
+
+	assert.Equal(t, []data.ReservationWarning{{IDCard: 1, Name: "Goblin Guide", Quantity: 4, Committed: 6,
+		Message: "6 copies committed to decks but 4 owned"}}, reservations.Warnings)
+}
+
+func Test_DeckReserve(t *testing.T) {
+	client := &fakeClient{}
+	deck := data.Deck{ID: 10, IDPlayer: 2}
+	assert.Nil(t, deck.Reserve(client, 3))
+	assert.True(t, client.committed)
+	assert.Len(t, client.commands, 3)
+	assert.Equal(t, []interface{}{10, 2}, client.params[0])
+	assert.Equal(t, []interface{}{10, 3}, client.params[2])
+	assert.NotNil(t, deck.Reserve(client, 0))
+}
+
+func Test_DeckReserveNotOwned(t *testing.T) {
+	client := &fakeClient{notFound: "d.id_player = $2"}
+	deck := data.Deck{ID: 10, IDPlayer: 9}
+	assert.Equal(t, raizel.ErrNotFound, deck.Reserve(client, 3))
+	assert.Len(t, client.commands, 1)
+	assert.True(t, client.rolledBack)
+
+	client = &fakeClient{notFound: "d.id_player = $2"}
+	assert.Equal(t, raizel.ErrNotFound, deck.Release(client, 3))
+	assert.Len(t, client.commands, 1)
+}
@@ -0,0 +1,265 @@
+package data
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/rjansen/l"
+	"github.com/rjansen/raizel"
+)
+
+//ErrRevisionNotFound is raised when the deck has no revision with the provided number
+var ErrRevisionNotFound = errors.New("data.Deck.RevisionNotFoundErr: Message='Deck revision not found'")
+
+//DeckRevision is one immutable save of the Deck
+type DeckRevision struct {
+	ID       int                `json:"id"`
+	IDDeck   int                `json:"idDeck"`
+	Revision int                `json:"revision"`
+	Name     string             `json:"name"`
+	Created  time.Time          `json:"created"`
+	Copies   int                `json:"copies"`
+	Cards    []DeckRevisionCard `json:"cards,omitempty"`
+}
+
+//DeckRevisionCard is one deck_card row of a DeckRevision
+type DeckRevisionCard struct {
+	IDCard   int    `json:"idCard"`
+	Name     string `json:"name"`
+	IDBoard  int    `json:"idBoard"`
+	Quantity int    `json:"quantity"`
+}
+
+//DeckChange is a card added, removed or changed in quantity between two revisions
+type DeckChange struct {
+	IDCard int    `json:"idCard"`
+	Name   string `json:"name"`
+	From   int    `json:"from"`
+	To     int    `json:"to"`
+}
+
+//BoardDiff holds the changes of one board
+type BoardDiff struct {
+	IDBoard int          `json:"idBoard"`
+	Added   []DeckChange `json:"added"`
+	Removed []DeckChange `json:"removed"`
+	Changed []DeckChange `json:"changed"`
+}
+
+//DeckDiff is the difference between the From and To revisions of a Deck
+type DeckDiff struct {
+	IDDeck int         `json:"idDeck"`
+	From   int         `json:"from"`
+	To     int         `json:"to"`
+	Boards []BoardDiff `json:"boards"`
+}
+
+func (r *DeckRevision) Fetch(fetchable raizel.Fetchable) error {
+	return fetchable.Scan(&r.ID, &r.IDDeck, &r.Revision, &r.Name, &r.Created, &r.Copies)
+}
+
+const selectRevision = `select r.id, r.id_deck, r.revision, r.name, r.dt_created,
+		coalesce((select sum(rc.quantity) from deck_revision_card rc where rc.id_deck_revision = r.id), 0)
+	from deck_revision r`
+
+//recordRevision saves the current deck_card rows of the Deck as its next revision
+func (d *Deck) recordRevision(client raizel.Client) error {
+	var revision DeckRevision
+	fetchRevision := func(f raizel.Fetchable) error {
+		return f.Scan(&revision.ID, &revision.Revision)
+	}
+	err := client.QueryOne(`
+		insert into deck_revision (id, id_deck, revision, name, dt_created)
+		values (nextval('sq_deck_revision'), $1,
+			coalesce((select max(revision) from deck_revision where id_deck = $1), 0) + 1, $2, now())
+		returning id, revision`, fetchRevision, d.ID, d.Name)
+	if err != nil {
+		return err
+	}
+	_, err = client.Exec(`
+		insert into deck_revision_card (id_deck_revision, id_card, id_board, quantity)
+		select $1, id_card, id_board, quantity from deck_card where id_deck = $2`, revision.ID, d.ID)
+	if err != nil {
+		return err
+	}
+	l.Debug("data.Deck.RecordedRevision",
+		l.Int("ID", d.ID),
+		l.Int("Revision", revision.Revision),
+	)
+	return nil
+}
+
+//ReadRevisions reads the Deck revisions, newest first, without the cards
+func (d *Deck) ReadRevisions(client raizel.Client) ([]DeckRevision, error) {
+	if d.ID <= 0 {
+		return nil, errors.New("data.Deck.ReadRevisionsErr: Message='Deck.ID is empty'")
+	}
+	revisions := []DeckRevision{}
+	err := client.Query(selectRevision+" where r.id_deck = $1 order by r.revision desc", func(i raizel.Iterable) error {
+		for i.Next() {
+			var revision DeckRevision
+			if err := revision.Fetch(i); err != nil {
+				return err
+			}
+			revisions = append(revisions, revision)
+		}
+		return nil
+	}, d.ID)
+	return revisions, err
+}
+
+//ReadRevision reads the Deck revision with its cards, a revision lower than one reads the last revision
+func (d *Deck) ReadRevision(client raizel.Client, number int) (DeckRevision, error) {
+	var revision DeckRevision
+	if d.ID <= 0 {
+		return revision, errors.New("data.Deck.ReadRevisionErr: Message='Deck.ID is empty'")
+	}
+	var err error
+	if number < 1 {
+		err = client.QueryOne(selectRevision+" where r.id_deck = $1 order by r.revision desc limit 1", revision.Fetch, d.ID)
+	} else {
+		err = client.QueryOne(selectRevision+" where r.id_deck = $1 and r.revision = $2", revision.Fetch, d.ID, number)
+	}
+	if err != nil {
+		if err == raizel.ErrNotFound {
+			return revision, ErrRevisionNotFound
+		}
+		return revision, err
+	}
+	revision.Cards = []DeckRevisionCard{}
+	err = client.Query(`
+		select rc.id_card, c.name, rc.id_board, rc.quantity
+		from deck_revision_card rc
+			join card c on c.id = rc.id_card
+		where rc.id_deck_revision = $1
+		order by rc.id_board, c.name`, func(i raizel.Iterable) error {
+		for i.Next() {
+			var card DeckRevisionCard
+			if err := i.Scan(&card.IDCard, &card.Name, &card.IDBoard, &card.Quantity); err != nil {
+				return err
+			}
+			revision.Cards = append(revision.Cards, card)
+		}
+		return nil
+	}, revision.ID)
+	return revision, err
+}
+
+//Diff reads the revisions from and to of the Deck and compares their cards.
+//A to lower than one is the last revision and a from lower than one is the revision before to
+func (d *Deck) Diff(client raizel.Client, from, to int) (DeckDiff, error) {
+	toRevision, err := d.ReadRevision(client, to)
+	if err != nil {
+		return DeckDiff{}, err
+	}
+	if from < 1 {
+		from = toRevision.Revision - 1
+	}
+	fromRevision := DeckRevision{Revision: from}
+	if from > 0 {
+		if fromRevision, err = d.ReadRevision(client, from); err != nil {
+			return DeckDiff{}, err
+		}
+	}
+	return DeckDiff{
+		IDDeck: d.ID,
+		From:   fromRevision.Revision,
+		To:     toRevision.Revision,
+		Boards: DiffRevisionCards(fromRevision.Cards, toRevision.Cards),
+	}, nil
+}
+
+//DiffRevisionCards compares the card lists by board and card, the boards are sorted by id and the changes by name
+func DiffRevisionCards(from, to []DeckRevisionCard) []BoardDiff {
+	type boardCard struct {
+		idBoard, idCard int
+	}
+	changes := map[boardCard]*DeckChange{}
+	change := func(card DeckRevisionCard) *DeckChange {
+		key := boardCard{card.IDBoard, card.IDCard}
+		if _, found := changes[key]; !found {
+			changes[key] = &DeckChange{IDCard: card.IDCard, Name: card.Name}
+		}
+		return changes[key]
+	}
+	for _, card := range from {
+		change(card).From += card.Quantity
+	}
+	for _, card := range to {
+		change(card).To += card.Quantity
+	}
+	boards := map[int]*BoardDiff{}
+	for key, cardChange := range changes {
+		if cardChange.From == cardChange.To {
+			continue
+		}
+		board, found := boards[key.idBoard]
+		if !found {
+			board = &BoardDiff{IDBoard: key.idBoard, Added: []DeckChange{}, Removed: []DeckChange{}, Changed: []DeckChange{}}
+			boards[key.idBoard] = board
+		}
+		switch {
+		case cardChange.From == 0:
+			board.Added = append(board.Added, *cardChange)
+		case cardChange.To == 0:
+			board.Removed = append(board.Removed, *cardChange)
+		default:
+			board.Changed = append(board.Changed, *cardChange)
+		}
+	}
+	result := make([]BoardDiff, 0, len(boards))
+	for _, board := range boards {
+		for _, boardChanges := range [][]DeckChange{board.Added, board.Removed, board.Changed} {
+			sort.Slice(boardChanges, func(i, j int) bool {
+				if boardChanges[i].Name != boardChanges[j].Name {
+					return boardChanges[i].Name < boardChanges[j].Name
+				}
+				return boardChanges[i].IDCard < boardChanges[j].IDCard
+			})
+		}
+		result = append(result, *board)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].IDBoard < result[j].IDBoard })
+	return result
+}
+
+//Restore replaces the Deck name and cards with the ones of the revision and persists it as a new revision, the
+//deck of a player other than Deck.IDPlayer is not found
+func (d *Deck) Restore(client raizel.Client, number int) error {
+	if d.ID <= 0 || d.IDPlayer <= 0 {
+		return errors.New("data.Deck.RestoreErr: Message='Deck.ID or Deck.IDPlayer is empty'")
+	}
+	return InTransaction(client, func(tx raizel.Client) error {
+		return d.restore(tx, number)
+	})
+}
+
+func (d *Deck) restore(client raizel.Client, number int) error {
+	var owned Deck
+	if err := client.QueryOne("select d.id, d.name, d.id_player from deck d where d.id = $1", owned.FetchSmall, d.ID); err != nil {
+		return err
+	}
+	if owned.IDPlayer != d.IDPlayer {
+		l.Info("data.Deck.NotOwned",
+			l.Int("ID", d.ID),
+			l.Int("IDPlayer", d.IDPlayer),
+		)
+		return raizel.ErrNotFound
+	}
+	revision, err := d.ReadRevision(client, number)
+	if err != nil {
+		return err
+	}
+	d.Name = revision.Name
+	d.Cards = make([]Card, len(revision.Cards))
+	for i, card := range revision.Cards {
+		d.Cards[i] = Card{ID: card.IDCard, Name: card.Name,
+			DeckCard: DeckCard{IDDeck: d.ID, IDBoard: card.IDBoard, Quantity: card.Quantity}}
+	}
+	l.Info("data.Deck.Restoring",
+		l.Int("ID", d.ID),
+		l.Int("Revision", revision.Revision),
+	)
+	return d.Persist(client)
+}
@@ -0,0 +1,49 @@
+package data_test
+
+import (
+	"testing"
+
+	"github.com/rjansen/fivecolors/data"
+	"github.com/rjansen/raizel"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DiffRevisionCards(t *testing.T) {
+	from := []data.DeckRevisionCard{
+		{IDCard: 1, Name: "Lightning Bolt", IDBoard: data.MainBoard, Quantity: 4},
+		{IDCard: 2, Name: "Goblin Guide", IDBoard: data.MainBoard, Quantity: 4},
+		{IDCard: 3, Name: "Mountain", IDBoard: data.MainBoard, Quantity: 20},
+		{IDCard: 4, Name: "Smash to Smithereens", IDBoard: data.SideBoard, Quantity: 3},
+	}
+	to := []data.DeckRevisionCard{
+		{IDCard: 1, Name: "Lightning Bolt", IDBoard: data.MainBoard, Quantity: 4},
+		{IDCard: 3, Name: "Mountain", IDBoard: data.MainBoard, Quantity: 18},
+		{IDCard: 5, Name: "Monastery Swiftspear", IDBoard: data.MainBoard, Quantity: 4},
+		{IDCard: 2, Name: "Goblin Guide", IDBoard: data.SideBoard, Quantity: 2},
+		{IDCard: 4, Name: "Smash to Smithereens", IDBoard: data.SideBoard, Quantity: 3},
+	}
+	boards := data.DiffRevisionCards(from, to)
+	assert.Len(t, boards, 2)
+	assert.Equal(t, data.MainBoard, boards[0].IDBoard)
+	assert.Equal(t, []data.DeckChange{{IDCard: 5, Name: "Monastery Swiftspear", To: 4}}, boards[0].Added)
+	assert.Equal(t, []data.DeckChange{{IDCard: 2, Name: "Goblin Guide", From: 4}}, boards[0].Removed)
+	assert.Equal(t, []data.DeckChange{{IDCard: 3, Name: "Mountain", From: 20, To: 18}}, boards[0].Changed)
+	assert.Equal(t, data.SideBoard, boards[1].IDBoard)
+	assert.Equal(t, []data.DeckChange{{IDCard: 2, Name: "Goblin Guide", To: 2}}, boards[1].Added)
+	assert.Empty(t, boards[1].Removed)
+	assert.Empty(t, boards[1].Changed)
+}
+
+func Test_DiffRevisionCardsUnchanged(t *testing.T) {
+	cards := []data.DeckRevisionCard{{IDCard: 1, Name: "Lightning Bolt", IDBoard: data.MainBoard, Quantity: 4}}
+	assert.Empty(t, data.DiffRevisionCards(cards, cards))
+	assert.Len(t, data.DiffRevisionCards(nil, cards)[0].Added, 1)
+}
+
+func Test_DeckRestoreNotOwned(t *testing.T) {
+	client := &fakeClient{one: map[string][]interface{}{"from deck d": {3, "Test_DeckRestoreNotOwned", 9}}}
+	deck := &data.Deck{ID: 3, IDPlayer: 2}
+	assert.Equal(t, raizel.ErrNotFound, deck.Restore(client, 1))
+	assert.Len(t, client.commands, 1)
+	assert.True(t, client.rolledBack)
+}
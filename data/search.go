@@ -0,0 +1,474 @@
+package data
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	//CardCMC is the SQL expression of the converted mana cost computed from the manacost_label tokens:
+	//numbers count their value, X counts zero and every other symbol counts one
+	CardCMC = `(select coalesce(sum(case when m ~ '^\d+$' then m::int when m in ('X', 'Y', 'Z') then 0 else 1 end), 0)
+		from unnest(string_to_array(nullif(c.manacost_label, ''), ', ')) m)`
+
+	cardPower     = `nullif(regexp_replace(split_part(coalesce(c.combatpower_label, ''), '/', 1), '[^0-9-]', '', 'g'), '')::int`
+	cardToughness = `nullif(regexp_replace(split_part(coalesce(c.combatpower_label, ''), '/', 2), '[^0-9-]', '', 'g'), '')::int`
+)
+
+var (
+	searchTermPattern = regexp.MustCompile(`^([A-Za-z]+)(:|!=|<=|>=|=|<|>)(.*)$`)
+	searchColors      = map[rune]string{'w': "White", 'u': "Blue", 'b': "Black", 'r': "Red", 'g': "Green"}
+	searchColorNames  = map[string]string{
+		"white": "w", "blue": "u", "black": "b", "red": "r", "green": "g", "colorless": "c",
+	}
+	searchRarities = map[string]int{
+		"s": 0, "special": 0, "c": 1, "common": 1, "u": 2, "uncommon": 2, "r": 3, "rare": 3, "m": 4, "mythic": 4,
+	}
+	searchTextColumns = map[string]string{
+		"name":   "c.name",
+		"t":      "c.type_label",
+		"type":   "c.type_label",
+		"o":      "coalesce(c.text, '')",
+		"oracle": "coalesce(c.text, '')",
+		"text":   "coalesce(c.text, '')",
+		"a":      "coalesce(c.artist, '')",
+		"artist": "coalesce(c.artist, '')",
+		"ft":     "coalesce(c.flavor, '')",
+		"flavor": "coalesce(c.flavor, '')",
+	}
+	searchNumberColumns = map[string]string{
+		"cmc":       CardCMC,
+		"mv":        CardCMC,
+		"pow":       cardPower,
+		"power":     cardPower,
+		"tou":       cardToughness,
+		"toughness": cardToughness,
+		"qty":       "coalesce(i.quantity, 0)",
+		"have":      "coalesce(i.quantity, 0)",
+	}
+	searchOperators = map[string]string{":": "=", "=": "=", "!=": "<>", "<": "<", "<=": "<=", ">": ">", ">=": ">="}
+)
+
+//SearchError is a card search syntax or semantic error at the 1-based Position of the query
+type SearchError struct {
+	Query    string `json:"query"`
+	Position int    `json:"position"`
+	Message  string `json:"message"`
+}
+
+func (e *SearchError) Error() string {
+	return fmt.Sprintf("data.CardSearch.InvalidErr: Position=%d Message='%s'", e.Position, e.Message)
+}
+
+//SearchNode is a node of the card search AST
+type SearchNode interface {
+	compile(compiler *searchCompiler) (string, error)
+}
+
+//SearchAnd matches the cards matched by every node
+type SearchAnd struct {
+	Nodes []SearchNode
+}
+
+//SearchOr matches the cards matched by any node
+type SearchOr struct {
+	Nodes []SearchNode
+}
+
+//SearchNot matches the cards not matched by the node
+type SearchNot struct {
+	Node SearchNode
+}
+
+//SearchTerm is a key, operator and value restriction. Bare words use the name key and the contains operator
+type SearchTerm struct {
+	Key      string
+	Operator string
+	Value    string
+	Position int
+}
+
+type searchTokenKind int
+
+const (
+	searchEOF searchTokenKind = iota
+	searchLParen
+	searchRParen
+	searchNegate
+	searchOrKeyword
+	searchAndKeyword
+	searchWord
+)
+
+type searchToken struct {
+	kind     searchTokenKind
+	text     string
+	quoted   bool
+	phrase   bool
+	position int
+}
+
+//lexCardSearch splits the query in parenthesis, negations, keywords and words. Quoted values keep their spaces
+func lexCardSearch(query string) ([]searchToken, error) {
+	var tokens []searchToken
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, searchToken{kind: searchLParen, text: "(", position: i + 1})
+			i++
+		case r == ')':
+			tokens = append(tokens, searchToken{kind: searchRParen, text: ")", position: i + 1})
+			i++
+		case r == '-' && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) && runes[i+1] != ')':
+			tokens = append(tokens, searchToken{kind: searchNegate, text: "-", position: i + 1})
+			i++
+		default:
+			start := i
+			var word strings.Builder
+			quoted := false
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '(' && runes[i] != ')' {
+				if runes[i] != '"' {
+					word.WriteRune(runes[i])
+					i++
+					continue
+				}
+				quoteStart := i
+				quoted = true
+				i++
+				for i < len(runes) && runes[i] != '"' {
+					word.WriteRune(runes[i])
+					i++
+				}
+				if i >= len(runes) {
+					return nil, &SearchError{Query: query, Position: quoteStart + 1, Message: "unterminated quoted value"}
+				}
+				i++
+			}
+			token := searchToken{kind: searchWord, text: word.String(), quoted: quoted, phrase: runes[start] == '"', position: start + 1}
+			if !quoted {
+				switch strings.ToLower(token.text) {
+				case "or":
+					token.kind = searchOrKeyword
+				case "and":
+					token.kind = searchAndKeyword
+				case "not":
+					token.kind = searchNegate
+				}
+			}
+			tokens = append(tokens, token)
+		}
+	}
+	return append(tokens, searchToken{kind: searchEOF, position: len(runes) + 1}), nil
+}
+
+type searchParser struct {
+	query  string
+	tokens []searchToken
+	next   int
+}
+
+func (p *searchParser) peek() searchToken {
+	return p.tokens[p.next]
+}
+
+func (p *searchParser) consume() searchToken {
+	token := p.tokens[p.next]
+	if token.kind != searchEOF {
+		p.next++
+	}
+	return token
+}
+
+func (p *searchParser) errorAt(token searchToken, message string) error {
+	return &SearchError{Query: p.query, Position: token.position, Message: message}
+}
+
+//ParseCardSearch parses a Scryfall-like card search into its AST:
+//terms are key:value or key<op>value, bare words match the name, - or not negates,
+//terms are joined by and (implicit) and or, and parenthesis group terms
+func ParseCardSearch(query string) (SearchNode, error) {
+	tokens, err := lexCardSearch(query)
+	if err != nil {
+		return nil, err
+	}
+	parser := &searchParser{query: query, tokens: tokens}
+	if parser.peek().kind == searchEOF {
+		return nil, parser.errorAt(parser.peek(), "empty query")
+	}
+	node, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if token := parser.peek(); token.kind != searchEOF {
+		return nil, parser.errorAt(token, fmt.Sprintf("unexpected %q", token.text))
+	}
+	return node, nil
+}
+
+func (p *searchParser) parseOr() (SearchNode, error) {
+	node, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	nodes := []SearchNode{node}
+	for p.peek().kind == searchOrKeyword {
+		p.consume()
+		if node, err = p.parseAnd(); err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return &SearchOr{Nodes: nodes}, nil
+}
+
+func (p *searchParser) parseAnd() (SearchNode, error) {
+	var nodes []SearchNode
+	for {
+		token := p.peek()
+		if token.kind == searchEOF || token.kind == searchRParen || token.kind == searchOrKeyword {
+			if len(nodes) == 0 {
+				return nil, p.errorAt(token, "missing term")
+			}
+			break
+		}
+		if token.kind == searchAndKeyword {
+			if len(nodes) == 0 {
+				return nil, p.errorAt(token, "missing term before and")
+			}
+			p.consume()
+			if next := p.peek(); next.kind == searchEOF || next.kind == searchRParen || next.kind == searchOrKeyword {
+				return nil, p.errorAt(next, "missing term after and")
+			}
+			continue
+		}
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return &SearchAnd{Nodes: nodes}, nil
+}
+
+func (p *searchParser) parseUnary() (SearchNode, error) {
+	token := p.consume()
+	switch token.kind {
+	case searchNegate:
+		if next := p.peek(); next.kind == searchEOF || next.kind == searchRParen {
+			return nil, p.errorAt(next, "missing term after "+token.text)
+		}
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &SearchNot{Node: node}, nil
+	case searchLParen:
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().kind != searchRParen {
+			return nil, p.errorAt(token, "missing closing parenthesis")
+		}
+		p.consume()
+		return node, nil
+	case searchWord:
+		return p.parseTerm(token)
+	}
+	return nil, p.errorAt(token, fmt.Sprintf("unexpected %q", token.text))
+}
+
+func (p *searchParser) parseTerm(token searchToken) (SearchNode, error) {
+	match := searchTermPattern.FindStringSubmatch(token.text)
+	if match == nil || token.phrase {
+		return &SearchTerm{Key: "name", Operator: ":", Value: token.text, Position: token.position}, nil
+	}
+	term := &SearchTerm{Key: strings.ToLower(match[1]), Operator: match[2], Value: match[3], Position: token.position}
+	if term.Value == "" {
+		return nil, p.errorAt(token, "missing value for "+term.Key)
+	}
+	return term, nil
+}
+
+//searchCompiler numbers the SQL parameters after the already used ones
+type searchCompiler struct {
+	query    string
+	idxParam int
+	values   []interface{}
+}
+
+func (c *searchCompiler) param(value interface{}) string {
+	c.idxParam++
+	c.values = append(c.values, value)
+	return fmt.Sprintf("$%d", c.idxParam)
+}
+
+func (c *searchCompiler) errorAt(term *SearchTerm, message string) error {
+	return &SearchError{Query: c.query, Position: term.Position, Message: message}
+}
+
+//CompileCardSearch compiles the AST into a restriction over the card c, expansion e and inventory_card i aliases
+//of CardQuery. Parameters are numbered from idxParam + 1, the last used index and the values are returned
+func CompileCardSearch(query string, node SearchNode, idxParam int) (string, []interface{}, int, error) {
+	compiler := &searchCompiler{query: query, idxParam: idxParam}
+	restriction, err := node.compile(compiler)
+	if err != nil {
+		return "", nil, idxParam, err
+	}
+	return restriction, compiler.values, compiler.idxParam, nil
+}
+
+func compileNodes(compiler *searchCompiler, nodes []SearchNode, separator string) (string, error) {
+	restrictions := make([]string, len(nodes))
+	for i, node := range nodes {
+		restriction, err := node.compile(compiler)
+		if err != nil {
+			return "", err
+		}
+		restrictions[i] = restriction
+	}
+	return "(" + strings.Join(restrictions, separator) + ")", nil
+}
+
+func (n *SearchAnd) compile(compiler *searchCompiler) (string, error) {
+	return compileNodes(compiler, n.Nodes, " and ")
+}
+
+func (n *SearchOr) compile(compiler *searchCompiler) (string, error) {
+	return compileNodes(compiler, n.Nodes, " or ")
+}
+
+func (n *SearchNot) compile(compiler *searchCompiler) (string, error) {
+	restriction, err := n.Node.compile(compiler)
+	if err != nil {
+		return "", err
+	}
+	return "not " + restriction, nil
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+func (t *SearchTerm) compile(compiler *searchCompiler) (string, error) {
+	if column, found := searchTextColumns[t.Key]; found {
+		switch t.Operator {
+		case ":":
+			return fmt.Sprintf("(%s ilike %s)", column, compiler.param("%"+escapeLike(t.Value)+"%")), nil
+		case "=":
+			return fmt.Sprintf("(lower(%s) = lower(%s))", column, compiler.param(t.Value)), nil
+		case "!=":
+			return fmt.Sprintf("(lower(%s) <> lower(%s))", column, compiler.param(t.Value)), nil
+		}
+		return "", compiler.errorAt(t, fmt.Sprintf("operator %s is not supported by %s", t.Operator, t.Key))
+	}
+	if column, found := searchNumberColumns[t.Key]; found {
+		value, err := strconv.Atoi(t.Value)
+		if err != nil {
+			return "", compiler.errorAt(t, fmt.Sprintf("%s expects a number, found %q", t.Key, t.Value))
+		}
+		return fmt.Sprintf("(%s %s %s)", column, searchOperators[t.Operator], compiler.param(value)), nil
+	}
+	switch t.Key {
+	case "c", "color", "colors":
+		return t.compileColors(compiler)
+	case "r", "rarity":
+		idRarity, found := searchRarities[strings.ToLower(t.Value)]
+		if !found {
+			return "", compiler.errorAt(t, fmt.Sprintf("unknown rarity %q", t.Value))
+		}
+		return fmt.Sprintf("(c.id_rarity %s %s)", searchOperators[t.Operator], compiler.param(idRarity)), nil
+	case "e", "s", "set", "expansion":
+		if t.Operator != ":" && t.Operator != "=" && t.Operator != "!=" {
+			return "", compiler.errorAt(t, fmt.Sprintf("operator %s is not supported by %s", t.Operator, t.Key))
+		}
+		restriction := fmt.Sprintf("(upper(coalesce(e.code, '')) = upper(%s))", compiler.param(t.Value))
+		if t.Operator == "!=" {
+			restriction = "not " + restriction
+		}
+		return restriction, nil
+	case "n", "number":
+		if t.Operator != ":" && t.Operator != "=" {
+			return "", compiler.errorAt(t, fmt.Sprintf("operator %s is not supported by %s", t.Operator, t.Key))
+		}
+		return fmt.Sprintf("(c.multiverse_number = %s)", compiler.param(t.Value)), nil
+	}
+	return "", compiler.errorAt(t, fmt.Sprintf("unknown key %q", t.Key))
+}
+
+//compileColors matches the colors of the mana cost: c:rg and c>=rg include red and green, c=rg is exactly
+//red and green, c<=rg has no color besides red and green and c:c is colorless
+func (t *SearchTerm) compileColors(compiler *searchCompiler) (string, error) {
+	value := strings.ToLower(t.Value)
+	if letter, found := searchColorNames[value]; found {
+		value = letter
+	}
+	var included, excluded []string
+	colorless := false
+	seen := map[rune]bool{}
+	for _, letter := range value {
+		if letter == 'c' {
+			colorless = true
+			continue
+		}
+		name, found := searchColors[letter]
+		if !found {
+			return "", compiler.errorAt(t, fmt.Sprintf("unknown color %q", string(letter)))
+		}
+		seen[letter] = true
+		included = append(included, name)
+	}
+	if colorless && len(included) > 0 {
+		return "", compiler.errorAt(t, "colorless can not be combined with colors")
+	}
+	for _, letter := range "wubrg" {
+		if !seen[letter] {
+			excluded = append(excluded, searchColors[letter])
+		}
+	}
+	manacost := "coalesce(c.manacost_label, '')"
+	hasAll := func() []string {
+		var restrictions []string
+		for _, name := range included {
+			restrictions = append(restrictions, fmt.Sprintf("%s ~ %s", manacost, compiler.param(name)))
+		}
+		return restrictions
+	}
+	hasNone := func() string {
+		if len(excluded) == 0 {
+			return "true"
+		}
+		return fmt.Sprintf("not %s ~ %s", manacost, compiler.param("("+strings.Join(excluded, "|")+")"))
+	}
+	var restrictions []string
+	switch t.Operator {
+	case ":", ">=":
+		if colorless {
+			restrictions = []string{hasNone()}
+		} else {
+			restrictions = hasAll()
+		}
+	case "=":
+		restrictions = append(hasAll(), hasNone())
+	case "<=":
+		restrictions = []string{hasNone()}
+	case "!=":
+		return "not (" + strings.Join(append(hasAll(), hasNone()), " and ") + ")", nil
+	default:
+		return "", compiler.errorAt(t, fmt.Sprintf("operator %s is not supported by %s", t.Operator, t.Key))
+	}
+	return "(" + strings.Join(restrictions, " and ") + ")", nil
+}
@@ -0,0 +1,93 @@
+package data_test
+
+import (
+	"testing"
+
+	"github.com/rjansen/fivecolors/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func compileSearch(t *testing.T, query string) (string, []interface{}) {
+	node, err := data.ParseCardSearch(query)
+	if !assert.Nil(t, err) {
+		return "", nil
+	}
+	restriction, values, _, err := data.CompileCardSearch(query, node, 0)
+	assert.Nil(t, err)
+	return restriction, values
+}
+
+func Test_CardSearchTerms(t *testing.T) {
+	restriction, values := compileSearch(t, `t:creature o:"draw a card" -t:legendary (e:dom or e:m19)`)
+	assert.Equal(t, "((c.type_label ilike $1) and (coalesce(c.text, '') ilike $2) and not (c.type_label ilike $3)"+
+		" and ((upper(coalesce(e.code, '')) = upper($4)) or (upper(coalesce(e.code, '')) = upper($5))))", restriction)
+	assert.Equal(t, []interface{}{"%creature%", "%draw a card%", "%legendary%", "dom", "m19"}, values)
+}
+
+func Test_CardSearchNumbersAndColors(t *testing.T) {
+	restriction, values := compileSearch(t, `c:rg cmc<=3 bolt`)
+	assert.Contains(t, restriction, "coalesce(c.manacost_label, '') ~ $1 and coalesce(c.manacost_label, '') ~ $2")
+	assert.Contains(t, restriction, "<= $3")
+	assert.Contains(t, restriction, "(c.name ilike $4)")
+	assert.Equal(t, []interface{}{"Red", "Green", 3, "%bolt%"}, values)
+
+	_, values = compileSearch(t, `c=w r>=rare`)
+	assert.Equal(t, []interface{}{"White", "(Blue|Black|Red|Green)", 3}, values)
+
+	_, values = compileSearch(t, `"fire // ice" not c:c`)
+	assert.Equal(t, []interface{}{"%fire // ice%", "(White|Blue|Black|Red|Green)"}, values)
+}
+
+func Test_CardSearchCompileWithOffset(t *testing.T) {
+	node, err := data.ParseCardSearch("pow>2")
+	assert.Nil(t, err)
+	restriction, values, lastParam, err := data.CompileCardSearch("pow>2", node, 4)
+	assert.Nil(t, err)
+	assert.Contains(t, restriction, "> $5")
+	assert.Equal(t, []interface{}{2}, values)
+	assert.Equal(t, 5, lastParam)
+}
+
+func Test_CardSearchErrors(t *testing.T) {
+	cases := []struct {
+		query    string
+		position int
+		message  string
+	}{
+		{"", 1, "empty query"},
+		{"t:creature (e:dom", 12, "missing closing parenthesis"},
+		{`o:"draw`, 3, "unterminated quoted value"},
+		{"t:creature or", 14, "missing term"},
+		{"t:goblin )", 10, `unexpected ")"`},
+		{"t:", 1, "missing value for t"},
+	}
+	for _, c := range cases {
+		_, err := data.ParseCardSearch(c.query)
+		searchErr, ok := err.(*data.SearchError)
+		if assert.True(t, ok, c.query) {
+			assert.Equal(t, c.position, searchErr.Position, c.query)
+			assert.Equal(t, c.message, searchErr.Message, c.query)
+		}
+	}
+
+	for query, position := range map[string]int{"t:elf foo:bar": 7, "cmc>=x": 1, "c:rgx": 1, "r:epic": 1, "t<2": 1} {
+		node, err := data.ParseCardSearch(query)
+		assert.Nil(t, err, query)
+		_, _, _, err = data.CompileCardSearch(query, node, 0)
+		searchErr, ok := err.(*data.SearchError)
+		if assert.True(t, ok, query) {
+			assert.Equal(t, position, searchErr.Position, query)
+		}
+	}
+}
+
+func Test_CardQuerySearch(t *testing.T) {
+	cardQuery := data.CardQuery{RegexName: "goblin", Search: "cmc<=2 or t:instant", IDInventory: 2}
+	assert.Nil(t, cardQuery.Build())
+	assert.Contains(t, cardQuery.SQL, "v.id_inventory = $4")
+	assert.Equal(t, []interface{}{"goblin", 2, "%instant%", 2}, cardQuery.Values)
+
+	cardQuery.Search = "t:(elf"
+	_, isSearchErr := cardQuery.Build().(*data.SearchError)
+	assert.True(t, isSearchErr)
+}
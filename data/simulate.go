@@ -0,0 +1,240 @@
+package data
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+)
+
+const (
+	//OpeningHandSize is the number of cards of the opening hand
+	OpeningHandSize = 7
+	//DefaultIterations is the number of simulated games when SimulationOptions.Iterations is not set
+	DefaultIterations = 10000
+	//MaxIterations is the greatest SimulationOptions.Iterations accepted
+	MaxIterations = 100000
+	//DefaultTurns is the last simulated turn when SimulationOptions.Turns is not set
+	DefaultTurns = 6
+	//MaxTurns is the greatest SimulationOptions.Turns accepted
+	MaxTurns = 15
+	//maxMulligans is the number of mulligans taken before keeping any hand, the fourth hand keeps 4 cards
+	maxMulligans = 3
+)
+
+var (
+	//ErrEmptyMainBoard is raised when the simulated deck has no main board cards
+	ErrEmptyMainBoard = errors.New("data.Deck.SimulateErr: Message='Deck main board is empty'")
+	//ErrInvalidSimulation is raised when the simulation options are out of bounds
+	ErrInvalidSimulation = errors.New("data.Deck.SimulateErr: Message='Simulation options are invalid'")
+)
+
+//SimulationOptions are the parameters of Deck.Simulate, the same options and seed reproduce the same result
+type SimulationOptions struct {
+	Seed       int64 `json:"seed"`
+	Iterations int   `json:"iterations"`
+	Turns      int   `json:"turns"`
+	//OnTheDraw draws one more card by turn
+	OnTheDraw bool `json:"onTheDraw"`
+	//MinLands and MaxLands are the land counts of a keepable hand, defaults to 2 and 5
+	MinLands int `json:"minLands"`
+	MaxLands int `json:"maxLands"`
+}
+
+//LandsByTurn holds the probability of having seen at least N lands by the turn, AtLeast index is N
+type LandsByTurn struct {
+	Turn    int       `json:"turn"`
+	AtLeast []float64 `json:"atLeast"`
+}
+
+//OnCurve is the probability of drawing the spell and the lands to cast it on the turn of its mana value
+type OnCurve struct {
+	Name        string  `json:"name"`
+	ManaValue   int     `json:"manaValue"`
+	Turn        int     `json:"turn"`
+	Probability float64 `json:"probability"`
+}
+
+//Simulation is the result of the Monte Carlo draws over the deck main board.
+//The land and curve probabilities use the first seven cards as the opening hand, without mulligans
+type Simulation struct {
+	SimulationOptions
+	DeckSize int `json:"deckSize"`
+	Lands    int `json:"lands"`
+	//OpeningHandLands is the distribution of lands in the opening hand, the index is the land count
+	OpeningHandLands []float64     `json:"openingHandLands"`
+	LandsByTurn      []LandsByTurn `json:"landsByTurn"`
+	OnCurve          []OnCurve     `json:"onCurve"`
+	//Mulligans is the rate of kept hands by mulligans taken under the London mulligan, the index is the mulligans count
+	Mulligans []float64 `json:"mulligans"`
+}
+
+//simulatedCard is one copy of the library, spell is the OnCurve index or -1 for lands
+type simulatedCard struct {
+	land  bool
+	spell int
+}
+
+//keepable checks if the seven drawn cards can become a hand with MinLands to MaxLands lands
+//after putting the mulligans count cards on the bottom
+func (o SimulationOptions) keepable(lands, mulligans int) bool {
+	handSize := OpeningHandSize - mulligans
+	fewestLands := lands - mulligans
+	if fewestLands < 0 {
+		fewestLands = 0
+	}
+	mostLands := lands
+	if mostLands > handSize {
+		mostLands = handSize
+	}
+	return fewestLands <= o.MaxLands && mostLands >= o.MinLands
+}
+
+//Simulate runs the seeded Monte Carlo draws over the Deck main board.
+//Deck.Cards must be hydrated with Name, ManacostLabel, TypeLabel and DeckCard
+func (d *Deck) Simulate(options SimulationOptions) (Simulation, error) {
+	if options.Iterations == 0 {
+		options.Iterations = DefaultIterations
+	}
+	if options.Turns == 0 {
+		options.Turns = DefaultTurns
+	}
+	if options.MinLands == 0 && options.MaxLands == 0 {
+		options.MinLands, options.MaxLands = 2, 5
+	}
+	if options.Iterations < 0 || options.Iterations > MaxIterations || options.Turns < 0 || options.Turns > MaxTurns ||
+		options.MinLands < 0 || options.MaxLands < options.MinLands {
+		return Simulation{}, ErrInvalidSimulation
+	}
+	simulation := Simulation{SimulationOptions: options, OnCurve: []OnCurve{}}
+	//The library is built by card id so the same seed shuffles the same library whatever the order of Deck.Cards
+	cards := make([]Card, 0, len(d.Cards))
+	for _, card := range d.Cards {
+		if card.DeckCard.IDBoard == MainBoard {
+			cards = append(cards, card)
+		}
+	}
+	sort.SliceStable(cards, func(i, j int) bool { return cards[i].ID < cards[j].ID })
+	spells := map[string]int{}
+	var library []simulatedCard
+	for _, card := range cards {
+		simulated := simulatedCard{land: card.IsLand(), spell: -1}
+		if simulated.land {
+			simulation.Lands += card.DeckCard.Quantity
+		} else {
+			spell, found := spells[card.Name]
+			if !found {
+				spell = len(simulation.OnCurve)
+				spells[card.Name] = spell
+				onCurve := OnCurve{Name: card.Name, ManaValue: card.ManaValue()}
+				onCurve.Turn = onCurve.ManaValue
+				if onCurve.Turn < 1 {
+					onCurve.Turn = 1
+				}
+				simulation.OnCurve = append(simulation.OnCurve, onCurve)
+			}
+			simulated.spell = spell
+		}
+		for i := 0; i < card.DeckCard.Quantity; i++ {
+			library = append(library, simulated)
+		}
+	}
+	simulation.DeckSize = len(library)
+	if simulation.DeckSize == 0 {
+		return Simulation{}, ErrEmptyMainBoard
+	}
+
+	var (
+		random       = rand.New(rand.NewSource(options.Seed))
+		openingLands = make([]int, OpeningHandSize+1)
+		landsByTurn  = make([][]int, options.Turns)
+		onCurve      = make([]int, len(simulation.OnCurve))
+		mulligans    = make([]int, maxMulligans+1)
+		//landsSeen[n] is the number of lands in the first n cards of the library
+		landsSeen = make([]int, len(library)+1)
+		firstSeen = make([]int, len(simulation.OnCurve))
+		handSize  = OpeningHandSize
+	)
+	if handSize > len(library) {
+		handSize = len(library)
+	}
+	for turn := range landsByTurn {
+		landsByTurn[turn] = make([]int, turn+2)
+	}
+	//seen returns the number of cards drawn by the turn
+	seen := func(turn int) int {
+		cards := OpeningHandSize + turn - 1
+		if options.OnTheDraw {
+			cards++
+		}
+		if cards > len(library) {
+			cards = len(library)
+		}
+		return cards
+	}
+	for iteration := 0; iteration < options.Iterations; iteration++ {
+		random.Shuffle(len(library), func(i, j int) { library[i], library[j] = library[j], library[i] })
+		for spell := range firstSeen {
+			firstSeen[spell] = len(library)
+		}
+		for i, card := range library {
+			landsSeen[i+1] = landsSeen[i]
+			if card.land {
+				landsSeen[i+1]++
+			} else if firstSeen[card.spell] > i {
+				firstSeen[card.spell] = i
+			}
+		}
+		openingLands[landsSeen[handSize]]++
+		for turn := range landsByTurn {
+			lands := landsSeen[seen(turn+1)]
+			for n := range landsByTurn[turn] {
+				if lands >= n {
+					landsByTurn[turn][n]++
+				}
+			}
+		}
+		for spell, curve := range simulation.OnCurve {
+			cards := seen(curve.Turn)
+			if firstSeen[spell] < cards && landsSeen[cards] >= curve.ManaValue {
+				onCurve[spell]++
+			}
+		}
+		//London mulligan: every hand draws seven cards from the shuffled library and bottoms one card by mulligan
+		taken := 0
+		for ; taken < maxMulligans; taken++ {
+			if taken > 0 {
+				random.Shuffle(len(library), func(i, j int) { library[i], library[j] = library[j], library[i] })
+			}
+			lands := 0
+			for _, card := range library[:handSize] {
+				if card.land {
+					lands++
+				}
+			}
+			if options.keepable(lands, taken) {
+				break
+			}
+		}
+		mulligans[taken]++
+	}
+
+	iterations := float64(options.Iterations)
+	simulation.OpeningHandLands = rates(openingLands, iterations)
+	simulation.LandsByTurn = make([]LandsByTurn, options.Turns)
+	for turn := range landsByTurn {
+		simulation.LandsByTurn[turn] = LandsByTurn{Turn: turn + 1, AtLeast: rates(landsByTurn[turn], iterations)}
+	}
+	for spell := range simulation.OnCurve {
+		simulation.OnCurve[spell].Probability = float64(onCurve[spell]) / iterations
+	}
+	simulation.Mulligans = rates(mulligans, iterations)
+	return simulation, nil
+}
+
+func rates(counts []int, total float64) []float64 {
+	result := make([]float64, len(counts))
+	for i, count := range counts {
+		result[i] = float64(count) / total
+	}
+	return result
+}
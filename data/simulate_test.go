@@ -0,0 +1,79 @@
+package data_test
+
+import (
+	"testing"
+
+	"github.com/rjansen/fivecolors/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func simulatedDeck() *data.Deck {
+	return &data.Deck{Cards: []data.Card{
+		{Name: "Mountain", TypeLabel: "Basic Land — Mountain", DeckCard: data.DeckCard{IDBoard: data.MainBoard, Quantity: 20}},
+		{Name: "Lightning Bolt", TypeLabel: "Instant", ManacostLabel: "Red", DeckCard: data.DeckCard{IDBoard: data.MainBoard, Quantity: 20}},
+		{Name: "Ball Lightning", TypeLabel: "Creature — Elemental", ManacostLabel: "Red, Red, Red", DeckCard: data.DeckCard{IDBoard: data.MainBoard, Quantity: 20}},
+		{Name: "Smash to Smithereens", TypeLabel: "Instant", ManacostLabel: "1, Red", DeckCard: data.DeckCard{IDBoard: data.SideBoard, Quantity: 4}},
+	}}
+}
+
+func Test_DeckSimulateSeeded(t *testing.T) {
+	deck := simulatedDeck()
+	simulation, err := deck.Simulate(data.SimulationOptions{Seed: 42, Iterations: 2000, Turns: 3})
+	assert.Nil(t, err)
+	assert.Equal(t, 60, simulation.DeckSize)
+	assert.Equal(t, 20, simulation.Lands)
+	assert.Len(t, simulation.OpeningHandLands, 8)
+	assert.Len(t, simulation.LandsByTurn, 3)
+	assert.Len(t, simulation.LandsByTurn[2].AtLeast, 4)
+	assert.Equal(t, 1.0, simulation.LandsByTurn[0].AtLeast[0])
+	assert.Len(t, simulation.OnCurve, 2)
+	assert.Equal(t, "Ball Lightning", simulation.OnCurve[1].Name)
+	assert.Equal(t, 3, simulation.OnCurve[1].Turn)
+	assert.True(t, simulation.OnCurve[0].Probability > simulation.OnCurve[1].Probability)
+	assert.Len(t, simulation.Mulligans, 4)
+
+	var sum float64
+	for _, rate := range simulation.OpeningHandLands {
+		sum += rate
+	}
+	assert.InDelta(t, 1.0, sum, 1e-9)
+	//The hypergeometric mean of lands in seven cards from 20 of 60 is 2.33
+	var mean float64
+	for lands, rate := range simulation.OpeningHandLands {
+		mean += float64(lands) * rate
+	}
+	assert.InDelta(t, 2.33, mean, 0.1)
+
+	again, err := deck.Simulate(data.SimulationOptions{Seed: 42, Iterations: 2000, Turns: 3})
+	assert.Nil(t, err)
+	assert.Equal(t, simulation, again)
+
+	onTheDraw, err := deck.Simulate(data.SimulationOptions{Seed: 42, Iterations: 2000, Turns: 3, OnTheDraw: true})
+	assert.Nil(t, err)
+	assert.True(t, onTheDraw.OnCurve[1].Probability > simulation.OnCurve[1].Probability)
+}
+
+func Test_DeckSimulateCardOrder(t *testing.T) {
+	deck := simulatedDeck()
+	for i := range deck.Cards {
+		deck.Cards[i].ID = i + 1
+	}
+	options := data.SimulationOptions{Seed: 7, Iterations: 500, Turns: 4}
+	simulation, err := deck.Simulate(options)
+	assert.Nil(t, err)
+
+	cards := deck.Cards
+	permuted := &data.Deck{Cards: []data.Card{cards[3], cards[2], cards[0], cards[1]}}
+	permutedSimulation, err := permuted.Simulate(options)
+	assert.Nil(t, err)
+	assert.Equal(t, simulation, permutedSimulation)
+}
+
+func Test_DeckSimulateInvalid(t *testing.T) {
+	_, err := new(data.Deck).Simulate(data.SimulationOptions{})
+	assert.Equal(t, data.ErrEmptyMainBoard, err)
+	_, err = simulatedDeck().Simulate(data.SimulationOptions{Iterations: data.MaxIterations + 1})
+	assert.Equal(t, data.ErrInvalidSimulation, err)
+	_, err = simulatedDeck().Simulate(data.SimulationOptions{MinLands: 4, MaxLands: 3})
+	assert.Equal(t, data.ErrInvalidSimulation, err)
+}
@@ -0,0 +1,111 @@
+package data
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	//The nullable sort columns are coalesced, nulls last, so the keyset pagination can compare them
+	cardNumber = `coalesce(NULLIF(regexp_replace(c.multiverse_number, '\D', '', 'g'), '')::int, 2147483647)`
+
+	//CardSortFields is the whitelist of the CardQuery order fields
+	CardSortFields = map[string]string{
+		"id":        "c.id",
+		"name":      "c.name",
+		"cmc":       CardCMC,
+		"type":      "c.type_label",
+		"rarity":    "c.id_rarity",
+		"power":     "coalesce(" + cardPower + ", -1)",
+		"toughness": "coalesce(" + cardToughness + ", -1)",
+		"rate":      "c.rate",
+		"number":    cardNumber,
+		"expansion": "coalesce(e.name, '')",
+		"set":       "coalesce(e.code, '')",
+		"quantity":  "coalesce(i.quantity, 0)",
+	}
+	//TokenSortFields is the whitelist of the TokenQuery order fields
+	TokenSortFields = map[string]string{
+		"id":        "t.id",
+		"name":      "t.name",
+		"type":      "t.type",
+		"color":     "coalesce(t.color, '')",
+		"expansion": "coalesce(e.name, '')",
+	}
+	//ExpansionSortFields is the whitelist of the ExpansionQuery order fields
+	ExpansionSortFields = map[string]string{
+		"id":   "e.id",
+		"name": "e.name",
+		"code": "coalesce(e.code, '')",
+	}
+	//DeckSortFields is the whitelist of the DeckQuery order fields
+	DeckSortFields = map[string]string{
+		"id":     "d.id",
+		"name":   "d.name",
+		"player": "d.id_player",
+	}
+)
+
+//SortError is raised when the order parameter has a field out of the entity whitelist
+type SortError struct {
+	Field  string   `json:"field"`
+	Fields []string `json:"fields"`
+}
+
+func (e *SortError) Error() string {
+	return fmt.Sprintf("data.Query.InvalidOrderErr: Field='%s' Message='Order field is not one of %s'",
+		e.Field, strings.Join(e.Fields, ", "))
+}
+
+//SortKey is one field of a sort spec
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+//SortSpec is the parsed order parameter: comma separated fields, a - prefix sorts descending
+type SortSpec []SortKey
+
+//ParseSortSpec parses an order parameter like name,-cmc,expansion
+func ParseSortSpec(order string) SortSpec {
+	var spec SortSpec
+	for _, field := range strings.Split(order, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		key := SortKey{}
+		switch field[0] {
+		case '-':
+			key.Desc = true
+			field = field[1:]
+		case '+':
+			field = field[1:]
+		}
+		key.Field = strings.ToLower(strings.TrimSpace(field))
+		spec = append(spec, key)
+	}
+	return spec
+}
+
+//SQL maps the spec fields to the whitelisted columns and returns the order by list
+func (s SortSpec) SQL(columns map[string]string) (string, error) {
+	orderBy := make([]string, len(s))
+	for i, key := range s {
+		column, found := columns[key.Field]
+		if !found {
+			fields := make([]string, 0, len(columns))
+			for field := range columns {
+				fields = append(fields, field)
+			}
+			sort.Strings(fields)
+			return "", &SortError{Field: key.Field, Fields: fields}
+		}
+		if key.Desc {
+			column += " desc"
+		}
+		orderBy[i] = column
+	}
+	return strings.Join(orderBy, ", "), nil
+}
@@ -0,0 +1,47 @@
+package data_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rjansen/fivecolors/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SortSpec(t *testing.T) {
+	spec := data.ParseSortSpec("name, -cmc,+Expansion,")
+	assert.Equal(t, data.SortSpec{{Field: "name"}, {Field: "cmc", Desc: true}, {Field: "expansion"}}, spec)
+
+	orderBy, err := data.ParseSortSpec("-name,id").SQL(data.DeckSortFields)
+	assert.Nil(t, err)
+	assert.Equal(t, "d.name desc, d.id", orderBy)
+}
+
+func Test_SortSpecRejectsUnknownFields(t *testing.T) {
+	_, err := data.ParseSortSpec("name,c.id; drop table card").SQL(data.CardSortFields)
+	sortErr, ok := err.(*data.SortError)
+	if assert.True(t, ok) {
+		assert.Equal(t, "c.id; drop table card", sortErr.Field)
+		assert.Contains(t, sortErr.Fields, "cmc")
+	}
+
+	for _, query := range []interface{ Build() error }{
+		&data.CardQuery{Query: data.Query{Order: "1"}},
+		&data.TokenQuery{Query: data.Query{Order: "cmc"}},
+		&data.ExpansionQuery{Query: data.Query{Order: "name desc"}},
+		&data.DeckQuery{Query: data.Query{Order: "-"}},
+	} {
+		_, ok := query.Build().(*data.SortError)
+		assert.True(t, ok)
+	}
+}
+
+func Test_SortSpecBuild(t *testing.T) {
+	cardQuery := data.CardQuery{Query: data.Query{Order: "-quantity,name"}}
+	assert.Nil(t, cardQuery.Build())
+	assert.True(t, strings.HasSuffix(cardQuery.SQL, " order by coalesce(i.quantity, 0) desc, c.name, c.id"))
+
+	expansionQuery := data.ExpansionQuery{}
+	assert.Nil(t, expansionQuery.Build())
+	assert.True(t, strings.HasSuffix(expansionQuery.SQL, " order by e.name, e.id"))
+}
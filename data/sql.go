@@ -0,0 +1,141 @@
+package data
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/rjansen/l"
+	"github.com/rjansen/raizel"
+	raizelSQL "github.com/rjansen/raizel/sql"
+)
+
+//SetupSQL opens the configured database and sets a raizel pool whose clients are Transactional.
+//It replaces raizel/sql.Setup, that hides the sql.DB and can not begin transactions
+func SetupSQL(cfg *raizelSQL.Configuration) error {
+	l.Info("data.SetupSQL",
+		l.String("Driver", cfg.Driver),
+		l.String("URL", cfg.URL),
+	)
+	db, err := sql.Open(cfg.Driver, cfg.URL)
+	if err != nil {
+		return err
+	}
+	if cfg.NumConns > 0 {
+		db.SetMaxOpenConns(cfg.NumConns)
+	}
+	if cfg.KeepAlive > 0 {
+		db.SetConnMaxLifetime(cfg.KeepAlive)
+	}
+	if err = raizel.Setup(&sqlPool{db: db}); err != nil {
+		db.Close()
+		return err
+	}
+	raizelSQL.Config = cfg
+	return nil
+}
+
+//sqlPool is the raizel.ClientPool of SetupSQL
+type sqlPool struct {
+	db *sql.DB
+}
+
+func (p *sqlPool) Get() (raizel.Client, error) {
+	if err := p.db.Ping(); err != nil {
+		return nil, err
+	}
+	return &sqlClient{sqlSupport: sqlSupport{executor: p.db}, db: p.db}, nil
+}
+
+func (p *sqlPool) Close() error {
+	return p.db.Close()
+}
+
+//sqlExecutor is the common behavior of sql.DB and sql.Tx
+type sqlExecutor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+//sqlSupport implements the raizel.Client read and exec actions over a sqlExecutor
+type sqlSupport struct {
+	executor sqlExecutor
+}
+
+func (s sqlSupport) QueryOne(query string, fetchFunc func(raizel.Fetchable) error, params ...interface{}) error {
+	if strings.TrimSpace(query) == "" || fetchFunc == nil {
+		return errors.New("data.SQL.QueryOneErr: Message='Query and fetch function are required'")
+	}
+	if err := fetchFunc(s.executor.QueryRow(query, params...)); err != nil {
+		if err == sql.ErrNoRows {
+			return raizel.ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+//Query closes the rows after the iter function so a Transaction can run the next command
+func (s sqlSupport) Query(query string, iterFunc func(raizel.Iterable) error, params ...interface{}) error {
+	if strings.TrimSpace(query) == "" || iterFunc == nil {
+		return errors.New("data.SQL.QueryErr: Message='Query and iter function are required'")
+	}
+	rows, err := s.executor.Query(query, params...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if err = iterFunc(rows); err != nil {
+		return err
+	}
+	return rows.Err()
+}
+
+func (s sqlSupport) Exec(command string, params ...interface{}) (raizel.Result, error) {
+	if strings.TrimSpace(command) == "" {
+		return nil, errors.New("data.SQL.ExecErr: Message='Command is required'")
+	}
+	result, err := s.executor.Exec(command, params...)
+	if err != nil {
+		l.Error("data.SQL.ExecErr",
+			l.String("SQL", command),
+			l.Struct("Parameters", params),
+			l.Err(err),
+		)
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s sqlSupport) Close() error {
+	return nil
+}
+
+//sqlClient is the Transactional client of the sqlPool
+type sqlClient struct {
+	sqlSupport
+	db *sql.DB
+}
+
+func (c *sqlClient) Begin() (Transaction, error) {
+	tx, err := c.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return &sqlTransaction{sqlSupport: sqlSupport{executor: tx}, tx: tx}, nil
+}
+
+//sqlTransaction is the Transaction begun by a sqlClient
+type sqlTransaction struct {
+	sqlSupport
+	tx *sql.Tx
+}
+
+func (t *sqlTransaction) Commit() error {
+	return t.tx.Commit()
+}
+
+func (t *sqlTransaction) Rollback() error {
+	return t.tx.Rollback()
+}
@@ -0,0 +1,112 @@
+package data
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+//cardTypes are the card types counted by the deck type breakdown, supertypes like Basic and Legendary are not types
+var cardTypes = []string{"Creature", "Instant", "Sorcery", "Artifact", "Enchantment", "Planeswalker", "Battle", "Land"}
+
+//DeckStats is the statistics of the deck main board used by the deck charts
+type DeckStats struct {
+	//ManaCurve holds the nonland copies by mana value, the index is the mana value
+	ManaCurve []int `json:"manaCurve"`
+	//Colors holds the mana symbols by color, a hybrid symbol counts for both colors
+	Colors map[string]int `json:"colors"`
+	//Types holds the copies by card type, an Artifact Creature counts for both types
+	Types      map[string]int `json:"types"`
+	AverageCMC float64        `json:"averageCmc"`
+	MainBoard  int            `json:"mainBoard"`
+	SideBoard  int            `json:"sideBoard"`
+}
+
+//ManaValue returns the converted mana cost of the manacost_label, the same rule of CardCMC:
+//numbers are generic mana, X, Y and Z are zero and every other symbol is one
+func (c Card) ManaValue() int {
+	var value int
+	for _, symbol := range manacostSymbols(c.ManacostLabel) {
+		if generic, err := strconv.Atoi(symbol); err == nil {
+			value += generic
+			continue
+		}
+		switch symbol {
+		case "X", "Y", "Z":
+		default:
+			value++
+		}
+	}
+	return value
+}
+
+//IsLand checks if the card type label has the Land type
+func (c Card) IsLand() bool {
+	return strings.Contains(cardTypeLine(c.TypeLabel), "Land")
+}
+
+func manacostSymbols(manacostLabel string) []string {
+	if strings.TrimSpace(manacostLabel) == "" {
+		return nil
+	}
+	symbols := strings.Split(manacostLabel, ",")
+	for i, symbol := range symbols {
+		symbols[i] = strings.TrimSpace(symbol)
+	}
+	return symbols
+}
+
+//cardTypeLine returns the types of the type label, the part before the subtypes dash
+func cardTypeLine(typeLabel string) string {
+	if dash := strings.IndexAny(typeLabel, "—-"); dash >= 0 {
+		return typeLabel[:dash]
+	}
+	return typeLabel
+}
+
+//Stats computes the main board statistics of the Deck.
+//Deck.Cards must be hydrated with ManacostLabel, TypeLabel and DeckCard
+func (d *Deck) Stats() DeckStats {
+	stats := DeckStats{ManaCurve: []int{}, Colors: map[string]int{}, Types: map[string]int{}}
+	var nonlands, manaValues int
+	for _, card := range d.Cards {
+		quantity := card.DeckCard.Quantity
+		switch card.DeckCard.IDBoard {
+		case MainBoard:
+			stats.MainBoard += quantity
+		case SideBoard:
+			stats.SideBoard += quantity
+			continue
+		default:
+			continue
+		}
+		typeLine := cardTypeLine(card.TypeLabel)
+		for _, cardType := range cardTypes {
+			if strings.Contains(typeLine, cardType) {
+				stats.Types[cardType] += quantity
+			}
+		}
+		for _, symbol := range manacostSymbols(card.ManacostLabel) {
+			for _, half := range strings.Split(symbol, "/") {
+				switch half {
+				case "White", "Blue", "Black", "Red", "Green", "Colorless":
+					stats.Colors[half] += quantity
+				}
+			}
+		}
+		if card.IsLand() {
+			continue
+		}
+		manaValue := card.ManaValue()
+		for len(stats.ManaCurve) <= manaValue {
+			stats.ManaCurve = append(stats.ManaCurve, 0)
+		}
+		stats.ManaCurve[manaValue] += quantity
+		nonlands += quantity
+		manaValues += manaValue * quantity
+	}
+	if nonlands > 0 {
+		stats.AverageCMC = math.Round(float64(manaValues)/float64(nonlands)*100) / 100
+	}
+	return stats
+}
@@ -0,0 +1,45 @@
+package data_test
+
+import (
+	"testing"
+
+	"github.com/rjansen/fivecolors/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CardManaValue(t *testing.T) {
+	assert.Equal(t, 0, data.Card{}.ManaValue())
+	assert.Equal(t, 4, data.Card{ManacostLabel: "2, Black, Black"}.ManaValue())
+	assert.Equal(t, 2, data.Card{ManacostLabel: "X, White/Blue, Green/Phyrexian"}.ManaValue())
+	assert.Equal(t, 12, data.Card{ManacostLabel: "12"}.ManaValue())
+}
+
+func Test_DeckStats(t *testing.T) {
+	deck := legalModernDeck()
+	deck.Cards[0].ManacostLabel = "Red"
+	deck.Cards[1].ManacostLabel = "Red"
+	deck.Cards[2].ManacostLabel = "Red"
+	deck.Cards[3].DeckCard.Quantity = 20
+	deck.Cards[4].ManacostLabel = "1, Red"
+	deck.Cards = append(deck.Cards,
+		data.Card{Name: "Arcbound Ravager", TypeLabel: "Artifact Creature — Beast", ManacostLabel: "2",
+			DeckCard: data.DeckCard{IDBoard: data.MainBoard, Quantity: 2}},
+		data.Card{Name: "Boros Charm", TypeLabel: "Instant", ManacostLabel: "Red, White",
+			DeckCard: data.DeckCard{IDBoard: data.MainBoard, Quantity: 2}},
+		data.Card{Name: "Manamorphose", TypeLabel: "Instant", ManacostLabel: "1, Red/Green",
+			DeckCard: data.DeckCard{IDBoard: data.MainBoard, Quantity: 2}},
+	)
+	stats := deck.Stats()
+	assert.Equal(t, 38, stats.MainBoard)
+	assert.Equal(t, 4, stats.SideBoard)
+	assert.Equal(t, []int{0, 12, 6}, stats.ManaCurve)
+	assert.Equal(t, map[string]int{"Red": 16, "White": 2, "Green": 2}, stats.Colors)
+	assert.Equal(t, map[string]int{"Instant": 8, "Creature": 10, "Artifact": 2, "Land": 20}, stats.Types)
+	assert.Equal(t, 1.33, stats.AverageCMC)
+}
+
+func Test_DeckStatsEmpty(t *testing.T) {
+	stats := new(data.Deck).Stats()
+	assert.Equal(t, []int{}, stats.ManaCurve)
+	assert.Equal(t, 0.0, stats.AverageCMC)
+}
@@ -0,0 +1,501 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/rjansen/l"
+	"github.com/rjansen/raizel"
+)
+
+const (
+	//TradeProposed is the status of a trade waiting for the answer of its receiver
+	TradeProposed = "proposed"
+	//TradeAccepted is the status of a trade whose copies were exchanged
+	TradeAccepted = "accepted"
+	//TradeCountered is the status of a trade answered with a counter proposal
+	TradeCountered = "countered"
+	//TradeDeclined is the status of a trade refused by its receiver
+	TradeDeclined = "declined"
+	//maxTradeMessage is the size of the trade and trade_status message columns
+	maxTradeMessage = 500
+)
+
+var (
+	//ErrInvalidTrade is raised when a trade is not between two players, has no cards, a copy without a positive
+	//quantity or a message too long
+	ErrInvalidTrade = errors.New("data.Trade.InvalidErr: Message='Trade requires another player, cards with positive quantities and a message up to 500 characters'")
+	//ErrTradeClosed is raised when a trade already accepted, countered or declined is answered
+	ErrTradeClosed = errors.New("data.Trade.ClosedErr: Message='Trade was already accepted, countered or declined'")
+	//ErrTradeNotReceiver is raised when a player other than the receiver answers a trade
+	ErrTradeNotReceiver = errors.New("data.Trade.NotReceiverErr: Message='Only the trade receiver can accept, counter or decline it'")
+)
+
+//TradeCard is the copies of a card, by finish, condition, language and graded, offered or requested in a trade
+type TradeCard struct {
+	IDCard    int    `json:"idCard"`
+	Name      string `json:"name,omitempty"`
+	Finish    string `json:"finish"`
+	Condition string `json:"condition"`
+	Language  string `json:"language"`
+	Graded    string `json:"graded,omitempty"`
+	Quantity  int    `json:"quantity"`
+}
+
+//TradeStatus is one status of the history of a trade and the player who set it
+type TradeStatus struct {
+	Status   string    `json:"status"`
+	IDPlayer int       `json:"idPlayer"`
+	Message  string    `json:"message,omitempty"`
+	Date     time.Time `json:"date"`
+}
+
+//Trade is the exchange of the Offered copies of the proposer inventory for the Requested copies of the receiver
+//inventory. A counter proposal is a new Trade, with the players swapped, whose IDParent is the countered Trade
+type Trade struct {
+	ID                  int           `json:"id"`
+	IDParent            int           `json:"idParent,omitempty"`
+	IDProposer          int           `json:"idProposer"`
+	Proposer            string        `json:"proposer,omitempty"`
+	IDProposerInventory int           `json:"idProposerInventory"`
+	IDReceiver          int           `json:"idReceiver"`
+	Receiver            string        `json:"receiver,omitempty"`
+	IDReceiverInventory int           `json:"idReceiverInventory"`
+	Status              string        `json:"status"`
+	Message             string        `json:"message,omitempty"`
+	Created             time.Time     `json:"created"`
+	Updated             time.Time     `json:"updated"`
+	Offered             []TradeCard   `json:"offered,omitempty"`
+	Requested           []TradeCard   `json:"requested,omitempty"`
+	History             []TradeStatus `json:"history,omitempty"`
+}
+
+//TradeShortage is a trade copy missing in the inventory, Available is the quantity of the copy in the inventory
+//not reserved to decks
+type TradeShortage struct {
+	IDInventory int `json:"idInventory"`
+	TradeCard
+	Available int `json:"available"`
+}
+
+//TradeUnavailableError is raised when the inventories of a trade do not have its copies
+type TradeUnavailableError struct {
+	Missing []TradeShortage `json:"missing"`
+}
+
+func (e *TradeUnavailableError) Error() string {
+	return fmt.Sprintf("data.Trade.UnavailableErr: Missing=%d Message='The inventories do not have the trade copies'", len(e.Missing))
+}
+
+//tradeSide is the copies of a trade moving from one inventory to the other
+type tradeSide struct {
+	offered  bool
+	cards    []TradeCard
+	from, to int
+}
+
+func (t *Trade) sides() []tradeSide {
+	return []tradeSide{
+		{offered: true, cards: t.Offered, from: t.IDProposerInventory, to: t.IDReceiverInventory},
+		{offered: false, cards: t.Requested, from: t.IDReceiverInventory, to: t.IDProposerInventory},
+	}
+}
+
+const selectTrade = `select t.id, coalesce(t.id_parent, 0), t.id_proposer, pp.username, t.id_proposer_inventory,
+		t.id_receiver, pr.username, t.id_receiver_inventory, t.status, t.message, t.dt_created, t.dt_updated
+	from trade t
+		join player pp on pp.id = t.id_proposer
+		join player pr on pr.id = t.id_receiver`
+
+func (t *Trade) Fetch(fetchable raizel.Fetchable) error {
+	return fetchable.Scan(&t.ID, &t.IDParent, &t.IDProposer, &t.Proposer, &t.IDProposerInventory,
+		&t.IDReceiver, &t.Receiver, &t.IDReceiverInventory, &t.Status, &t.Message, &t.Created, &t.Updated)
+}
+
+//normalizeTradeCards validates the copies, fills the defaults of their finish, condition and language and sums
+//the repeated ones
+func normalizeTradeCards(cards []TradeCard) ([]TradeCard, error) {
+	normalized := make([]TradeCard, 0, len(cards))
+	cardIndex := make(map[TradeCard]int, len(cards))
+	for _, card := range cards {
+		if card.IDCard <= 0 || card.Quantity <= 0 {
+			return nil, ErrInvalidTrade
+		}
+		inventoryCard := InventoryCard{Finish: card.Finish, Condition: card.Condition, Language: card.Language, Graded: card.Graded}
+		if err := inventoryCard.Normalize(); err != nil {
+			return nil, err
+		}
+		key := TradeCard{IDCard: card.IDCard, Finish: inventoryCard.Finish, Condition: inventoryCard.Condition,
+			Language: inventoryCard.Language, Graded: inventoryCard.Graded}
+		if index, repeated := cardIndex[key]; repeated {
+			normalized[index].Quantity += card.Quantity
+			continue
+		}
+		cardIndex[key] = len(normalized)
+		key.Quantity = card.Quantity
+		normalized = append(normalized, key)
+	}
+	return normalized, nil
+}
+
+//Propose records the Trade from the IDProposer to the IDReceiver with the proposed status. Empty inventories are
+//the players default inventories, the inventories must belong to the players and have the offered and requested copies
+//not reserved to decks
+func (t *Trade) Propose(client raizel.Client) error {
+	if t.IDProposer <= 0 || t.IDReceiver <= 0 || t.IDProposer == t.IDReceiver {
+		return ErrInvalidTrade
+	}
+	return InTransaction(client, func(tx raizel.Client) error {
+		query := `
+			select pi.id, ri.id
+			from inventory pi, inventory ri
+			where pi.id = coalesce(nullif($1, 0), (select min(id) from inventory where id_player = $2)) and pi.id_player = $2
+				and ri.id = coalesce(nullif($3, 0), (select min(id) from inventory where id_player = $4)) and ri.id_player = $4`
+		if err := tx.QueryOne(query, func(f raizel.Fetchable) error {
+			return f.Scan(&t.IDProposerInventory, &t.IDReceiverInventory)
+		}, t.IDProposerInventory, t.IDProposer, t.IDReceiverInventory, t.IDReceiver); err != nil {
+			return err
+		}
+		return t.propose(tx)
+	})
+}
+
+//propose normalizes the Trade cards, checks the copies are in the inventories and inserts the Trade
+func (t *Trade) propose(client raizel.Client) error {
+	var err error
+	if t.Offered, err = normalizeTradeCards(t.Offered); err != nil {
+		return err
+	}
+	if t.Requested, err = normalizeTradeCards(t.Requested); err != nil {
+		return err
+	}
+	t.Message = strings.TrimSpace(t.Message)
+	if len(t.Offered)+len(t.Requested) == 0 || len(t.Message) > maxTradeMessage {
+		return ErrInvalidTrade
+	}
+	if err = t.checkCopies(client, false); err != nil {
+		return err
+	}
+	insert := `
+		insert into trade (id, id_parent, id_proposer, id_proposer_inventory, id_receiver, id_receiver_inventory,
+			status, message, dt_created, dt_updated)
+		values (nextval('sq_trade'), nullif($1, 0), $2, $3, $4, $5, $6, $7, now(), now())
+		returning id, dt_created`
+	if err = client.QueryOne(insert, func(f raizel.Fetchable) error {
+		return f.Scan(&t.ID, &t.Created)
+	}, t.IDParent, t.IDProposer, t.IDProposerInventory, t.IDReceiver, t.IDReceiverInventory, TradeProposed, t.Message); err != nil {
+		return err
+	}
+	t.Status, t.Updated = TradeProposed, t.Created
+	rows := make([][]interface{}, 0, len(t.Offered)+len(t.Requested))
+	for _, side := range t.sides() {
+		for _, card := range side.cards {
+			rows = append(rows, []interface{}{t.ID, side.offered, card.IDCard, card.Finish, card.Condition, card.Language, card.Graded, card.Quantity})
+		}
+	}
+	cardInsert := `insert into trade_card (id_trade, offered, id_card, finish, condition, language, graded, quantity) values %s`
+	if err = batchInsert(client, cardInsert, rows); err != nil {
+		return err
+	}
+	if err = t.recordStatus(client, t.IDProposer, TradeProposed, t.Message); err != nil {
+		return err
+	}
+	l.Info("data.Trade.Proposed",
+		l.Int("ID", t.ID),
+		l.Int("IDParent", t.IDParent),
+		l.Int("IDProposer", t.IDProposer),
+		l.Int("IDReceiver", t.IDReceiver),
+		l.Int("Offered.Len", len(t.Offered)),
+		l.Int("Requested.Len", len(t.Requested)),
+	)
+	return nil
+}
+
+//checkCopies reads the inventory copies of the Trade cards, locking them when lock is set, and returns a
+//*TradeUnavailableError with the copies missing in the inventory they move from. The copies reserved to decks of the
+//inventory are not available: the copies of a card traded from an inventory can not exceed its copies beyond the
+//deck_reservation of the card, whatever their finish
+func (t *Trade) checkCopies(client raizel.Client, lock bool) error {
+	type cardKey struct {
+		idInventory, idCard int
+	}
+	type copyKey struct {
+		idInventory, idCard                 int
+		finish, condition, language, graded string
+	}
+	ids := make([]int, 0, len(t.Offered)+len(t.Requested))
+	for _, side := range t.sides() {
+		for _, card := range side.cards {
+			ids = append(ids, card.IDCard)
+		}
+	}
+	query := `
+		select v.id_inventory, v.id_card, v.finish, v.condition, v.language, v.graded, v.quantity, coalesce(r.reserved, 0)
+		from inventory_card v
+			left join (
+				select id_inventory, id_card, sum(quantity) reserved
+				from deck_reservation
+				where id_inventory in ($1, $2) and id_card = any($3)
+				group by id_inventory, id_card
+			) r on r.id_inventory = v.id_inventory and r.id_card = v.id_card
+		where v.id_inventory in ($1, $2) and v.id_card = any($3)`
+	if lock {
+		query += " for update of v"
+	}
+	available := make(map[copyKey]int)
+	//spare are the copies of a card beyond its reservations, every finish, condition and language included
+	spare := make(map[cardKey]int)
+	err := client.Query(query, func(iterable raizel.Iterable) error {
+		for iterable.Next() {
+			var (
+				key                copyKey
+				quantity, reserved int
+			)
+			if err := iterable.Scan(&key.idInventory, &key.idCard, &key.finish, &key.condition, &key.language,
+				&key.graded, &quantity, &reserved); err != nil {
+				return err
+			}
+			available[key] = quantity
+			card := cardKey{key.idInventory, key.idCard}
+			if _, found := spare[card]; !found {
+				spare[card] = -reserved
+			}
+			spare[card] += quantity
+		}
+		return nil
+	}, t.IDProposerInventory, t.IDReceiverInventory, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	var missing []TradeShortage
+	traded := make(map[cardKey]int)
+	for _, side := range t.sides() {
+		for _, card := range side.cards {
+			quantity := available[copyKey{side.from, card.IDCard, card.Finish, card.Condition, card.Language, card.Graded}]
+			key := cardKey{side.from, card.IDCard}
+			if free := spare[key] - traded[key]; free < quantity {
+				quantity = free
+			}
+			if quantity < 0 {
+				quantity = 0
+			}
+			if quantity < card.Quantity {
+				missing = append(missing, TradeShortage{IDInventory: side.from, TradeCard: card, Available: quantity})
+			}
+			traded[key] += card.Quantity
+		}
+	}
+	if len(missing) > 0 {
+		l.Info("data.Trade.UnavailableCopies",
+			l.Int("ID", t.ID),
+			l.Int("Missing.Len", len(missing)),
+		)
+		return &TradeUnavailableError{Missing: missing}
+	}
+	return nil
+}
+
+//recordStatus appends the status set by the player to the Trade history
+func (t *Trade) recordStatus(client raizel.Client, idPlayer int, status, message string) error {
+	_, err := client.Exec(`insert into trade_status (id_trade, status, id_player, message, dt_status) values ($1, $2, $3, $4, now())`,
+		t.ID, status, idPlayer, message)
+	return err
+}
+
+//respond reads and locks the Trade answered by the player, it must be proposed and the player its receiver
+func (t *Trade) respond(client raizel.Client, idPlayer int) error {
+	if t.ID <= 0 {
+		return errors.New("data.Trade.RespondErr: Message='Trade.ID is empty'")
+	}
+	if err := client.QueryOne(selectTrade+" where t.id = $1 for update of t", t.Fetch, t.ID); err != nil {
+		return err
+	}
+	if idPlayer != t.IDProposer && idPlayer != t.IDReceiver {
+		return raizel.ErrNotFound
+	}
+	if idPlayer != t.IDReceiver {
+		return ErrTradeNotReceiver
+	}
+	if t.Status != TradeProposed {
+		return ErrTradeClosed
+	}
+	return nil
+}
+
+//answer sets the status of the Trade answered by the player and records it in the history
+func (t *Trade) answer(client raizel.Client, idPlayer int, status, message string) error {
+	message = strings.TrimSpace(message)
+	if len(message) > maxTradeMessage {
+		return ErrInvalidTrade
+	}
+	if _, err := client.Exec("update trade set status = $2, dt_updated = now() where id = $1", t.ID, status); err != nil {
+		return err
+	}
+	if err := t.recordStatus(client, idPlayer, status, message); err != nil {
+		return err
+	}
+	t.Status = status
+	l.Info("data.Trade.Closed",
+		l.Int("ID", t.ID),
+		l.Int("IDPlayer", idPlayer),
+		l.String("Status", status),
+	)
+	return nil
+}
+
+//Accept exchanges the copies of the Trade answered by its receiver: the offered copies move from the proposer
+//inventory to the receiver inventory and the requested copies the other way, all of them or none. The copies reserved
+//to decks are not exchanged, the trade is unavailable while they are reserved
+func (t *Trade) Accept(client raizel.Client, idPlayer int, message string) error {
+	return InTransaction(client, func(tx raizel.Client) error {
+		if err := t.respond(tx, idPlayer); err != nil {
+			return err
+		}
+		if err := t.readCards(tx); err != nil {
+			return err
+		}
+		if err := t.checkCopies(tx, true); err != nil {
+			return err
+		}
+		received := make([][]interface{}, 0, len(t.Offered)+len(t.Requested))
+		for _, side := range t.sides() {
+			for _, card := range side.cards {
+				_, err := tx.Exec(`
+					update inventory_card set quantity = quantity - $7
+					where id_inventory = $1 and id_card = $2 and finish = $3 and condition = $4 and language = $5 and graded = $6`,
+					side.from, card.IDCard, card.Finish, card.Condition, card.Language, card.Graded, card.Quantity)
+				if err != nil {
+					return err
+				}
+				received = append(received, []interface{}{side.to, card.IDCard, card.Finish, card.Condition, card.Language, card.Graded, card.Quantity})
+			}
+		}
+		receive := `
+			insert into inventory_card (id_inventory, id_card, finish, condition, language, graded, quantity)
+			values %s
+			on conflict(id_inventory, id_card, finish, condition, language, graded)
+			do update set quantity = inventory_card.quantity + excluded.quantity
+		`
+		if err := batchInsert(tx, receive, received); err != nil {
+			return err
+		}
+		return t.answer(tx, idPlayer, TradeAccepted, message)
+	})
+}
+
+//Decline refuses the Trade answered by its receiver
+func (t *Trade) Decline(client raizel.Client, idPlayer int, message string) error {
+	return InTransaction(client, func(tx raizel.Client) error {
+		if err := t.respond(tx, idPlayer); err != nil {
+			return err
+		}
+		return t.answer(tx, idPlayer, TradeDeclined, message)
+	})
+}
+
+//Counter answers the Trade with the counter proposal of its receiver. The Trade is closed as countered and the
+//counter, between the same inventories with the players swapped, waits for the answer of the Trade proposer
+func (t *Trade) Counter(client raizel.Client, idPlayer int, counter *Trade) error {
+	return InTransaction(client, func(tx raizel.Client) error {
+		if err := t.respond(tx, idPlayer); err != nil {
+			return err
+		}
+		counter.ID = 0
+		counter.IDParent = t.ID
+		counter.IDProposer, counter.Proposer, counter.IDProposerInventory = t.IDReceiver, t.Receiver, t.IDReceiverInventory
+		counter.IDReceiver, counter.Receiver, counter.IDReceiverInventory = t.IDProposer, t.Proposer, t.IDProposerInventory
+		counter.Status = TradeProposed
+		if err := counter.propose(tx); err != nil {
+			return err
+		}
+		return t.answer(tx, idPlayer, TradeCountered, "")
+	})
+}
+
+//readCards reads the Offered and Requested copies of the Trade
+func (t *Trade) readCards(client raizel.Client) error {
+	t.Offered, t.Requested = []TradeCard{}, []TradeCard{}
+	query := `
+		select tc.offered, tc.id_card, c.name, tc.finish, tc.condition, tc.language, tc.graded, tc.quantity
+		from trade_card tc
+			join card c on c.id = tc.id_card
+		where tc.id_trade = $1
+		order by c.name, tc.id_card, tc.finish, tc.condition, tc.language, tc.graded`
+	return client.Query(query, func(iterable raizel.Iterable) error {
+		for iterable.Next() {
+			var (
+				offered bool
+				card    TradeCard
+			)
+			if err := iterable.Scan(&offered, &card.IDCard, &card.Name, &card.Finish, &card.Condition, &card.Language,
+				&card.Graded, &card.Quantity); err != nil {
+				return err
+			}
+			if offered {
+				t.Offered = append(t.Offered, card)
+			} else {
+				t.Requested = append(t.Requested, card)
+			}
+		}
+		return nil
+	}, t.ID)
+}
+
+//Read reads the Trade with its cards and status history, only its proposer and receiver read it
+func (t *Trade) Read(client raizel.Client, idPlayer int) error {
+	if t.ID <= 0 {
+		return errors.New("data.Trade.ReadErr: Message='Trade.ID is empty'")
+	}
+	if err := client.QueryOne(selectTrade+" where t.id = $1", t.Fetch, t.ID); err != nil {
+		return err
+	}
+	if idPlayer != t.IDProposer && idPlayer != t.IDReceiver {
+		return raizel.ErrNotFound
+	}
+	if err := t.readCards(client); err != nil {
+		return err
+	}
+	t.History = []TradeStatus{}
+	query := `
+		select ts.status, ts.id_player, ts.message, ts.dt_status
+		from trade_status ts
+		where ts.id_trade = $1
+		order by ts.dt_status`
+	return client.Query(query, func(iterable raizel.Iterable) error {
+		for iterable.Next() {
+			var status TradeStatus
+			if err := iterable.Scan(&status.Status, &status.IDPlayer, &status.Message, &status.Date); err != nil {
+				return err
+			}
+			t.History = append(t.History, status)
+		}
+		return nil
+	}, t.ID)
+}
+
+//ReadTrades reads the trades proposed by or to the Player, the last updated first, of the status when it is set.
+//The cards and history of the trades are not read
+func (p *Player) ReadTrades(client raizel.Client, status string) ([]Trade, error) {
+	if p.ID <= 0 {
+		return nil, errors.New("data.Player.ReadTradesErr: Message='Player.ID is empty'")
+	}
+	trades := []Trade{}
+	query := selectTrade + `
+		where (t.id_proposer = $1 or t.id_receiver = $1) and ($2 = '' or t.status = $2)
+		order by t.dt_updated desc, t.id desc
+		limit $3`
+	err := client.Query(query, func(iterable raizel.Iterable) error {
+		for iterable.Next() {
+			var trade Trade
+			if err := trade.Fetch(iterable); err != nil {
+				return err
+			}
+			trades = append(trades, trade)
+		}
+		return nil
+	}, p.ID, status, selectLimit)
+	return trades, err
+}
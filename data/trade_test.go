@@ -0,0 +1,148 @@
+package data_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rjansen/fivecolors/data"
+	"github.com/rjansen/raizel"
+	"github.com/stretchr/testify/assert"
+)
+
+//tradeClient is a fakeClient with the trade 3 from the player 1, inventory 10, to the player 2, inventory 20
+//offering two Lightning Bolt for one foil Counterspell
+func tradeClient(status string, copies ...[]interface{}) *fakeClient {
+	return &fakeClient{
+		one: map[string][]interface{}{
+			"from trade t": {3, 0, 1, "proposer", 10, 2, "receiver", 20, status, "", time.Now(), time.Now()},
+		},
+		results: map[string][][]interface{}{
+			"from trade_card tc": {
+				{true, 1, "Lightning Bolt", "nonfoil", "NM", "en", "", 2},
+				{false, 2, "Counterspell", "foil", "NM", "en", "", 1},
+			},
+			"from inventory_card v": copies,
+		},
+	}
+}
+
+func Test_TradePropose(t *testing.T) {
+	client := &fakeClient{results: map[string][][]interface{}{"from inventory_card v": {
+		{7, 1, "nonfoil", "NM", "en", "", 4, 0},
+		{7, 2, "foil", "NM", "en", "", 1, 0},
+	}}}
+	trade := data.Trade{IDProposer: 1, IDReceiver: 2, Message: " deal? ",
+		Offered:   []data.TradeCard{{IDCard: 1, Quantity: 2}, {IDCard: 1, Finish: "normal", Quantity: 1}},
+		Requested: []data.TradeCard{{IDCard: 2, Finish: "foil", Condition: "near mint", Quantity: 1}},
+	}
+	assert.Nil(t, trade.Propose(client))
+	assert.True(t, client.committed)
+	assert.Equal(t, 7, trade.ID)
+	assert.Equal(t, data.TradeProposed, trade.Status)
+	assert.Equal(t, "deal?", trade.Message)
+	assert.Equal(t, []data.TradeCard{{IDCard: 1, Finish: "nonfoil", Condition: "NM", Language: "en", Quantity: 3}}, trade.Offered)
+	assert.Equal(t, []interface{}{0, 1, 0, 2}, client.params[0])
+	assert.Contains(t, client.commands[3], "insert into trade_card")
+	assert.Equal(t, []interface{}{7, true, 1, "nonfoil", "NM", "en", "", 3, 7, false, 2, "foil", "NM", "en", "", 1}, client.params[3])
+	assert.Equal(t, []interface{}{7, data.TradeProposed, 1, "deal?"}, client.params[4])
+
+	client = &fakeClient{results: map[string][][]interface{}{"from inventory_card v": {{7, 1, "nonfoil", "NM", "en", "", 2, 0}}}}
+	trade = data.Trade{IDProposer: 1, IDReceiver: 2, Offered: []data.TradeCard{{IDCard: 1, Quantity: 3}, {IDCard: 5, Quantity: 1}}}
+	err := trade.Propose(client)
+	assert.IsType(t, &data.TradeUnavailableError{}, err)
+	missing := err.(*data.TradeUnavailableError).Missing
+	assert.Len(t, missing, 2)
+	assert.Equal(t, 2, missing[0].Available)
+	assert.Equal(t, 0, missing[1].Available)
+	assert.Equal(t, 5, missing[1].IDCard)
+	assert.True(t, client.rolledBack)
+
+	for _, invalid := range []data.Trade{
+		{IDProposer: 1, IDReceiver: 1, Offered: []data.TradeCard{{IDCard: 1, Quantity: 1}}},
+		{IDProposer: 1, IDReceiver: 2},
+		{IDProposer: 1, IDReceiver: 2, Requested: []data.TradeCard{{IDCard: 1}}},
+	} {
+		assert.Equal(t, data.ErrInvalidTrade, invalid.Propose(&fakeClient{}))
+	}
+	trade = data.Trade{IDProposer: 1, IDReceiver: 2, Offered: []data.TradeCard{{IDCard: 1, Finish: "shiny", Quantity: 1}}}
+	assert.Equal(t, data.ErrInvalidInventoryCard, trade.Propose(&fakeClient{}))
+}
+
+func Test_TradeAccept(t *testing.T) {
+	client := tradeClient(data.TradeProposed, []interface{}{10, 1, "nonfoil", "NM", "en", "", 4, 0}, []interface{}{20, 2, "foil", "NM", "en", "", 1, 0})
+	trade := data.Trade{ID: 3}
+	assert.Nil(t, trade.Accept(client, 2, "thanks"))
+	assert.True(t, client.committed)
+	assert.Equal(t, data.TradeAccepted, trade.Status)
+	assert.Contains(t, client.commands[0], "for update of t")
+	assert.Contains(t, client.commands[2], "for update")
+	assert.Equal(t, []interface{}{10, 1, "nonfoil", "NM", "en", "", 2}, client.params[3])
+	assert.Equal(t, []interface{}{20, 2, "foil", "NM", "en", "", 1}, client.params[4])
+	assert.Contains(t, client.commands[5], "do update set quantity = inventory_card.quantity + excluded.quantity")
+	assert.Equal(t, []interface{}{20, 1, "nonfoil", "NM", "en", "", 2, 10, 2, "foil", "NM", "en", "", 1}, client.params[5])
+	assert.Equal(t, []interface{}{3, data.TradeAccepted}, client.params[6])
+	assert.Equal(t, []interface{}{3, data.TradeAccepted, 2, "thanks"}, client.params[7])
+
+	client = tradeClient(data.TradeProposed, []interface{}{10, 1, "nonfoil", "NM", "en", "", 1, 0})
+	trade = data.Trade{ID: 3}
+	err := trade.Accept(client, 2, "")
+	assert.IsType(t, &data.TradeUnavailableError{}, err)
+	assert.Len(t, err.(*data.TradeUnavailableError).Missing, 2)
+	assert.True(t, client.rolledBack)
+
+	//Three of the four Lightning Bolt, in two finishes, are reserved to decks of the proposer inventory
+	client = tradeClient(data.TradeProposed, []interface{}{10, 1, "nonfoil", "NM", "en", "", 3, 3},
+		[]interface{}{10, 1, "foil", "NM", "en", "", 1, 3}, []interface{}{20, 2, "foil", "NM", "en", "", 1, 0})
+	trade = data.Trade{ID: 3}
+	err = trade.Accept(client, 2, "")
+	assert.IsType(t, &data.TradeUnavailableError{}, err)
+	missing := err.(*data.TradeUnavailableError).Missing
+	if assert.Len(t, missing, 1) {
+		assert.Equal(t, 1, missing[0].IDCard)
+		assert.Equal(t, 1, missing[0].Available)
+	}
+	assert.True(t, client.rolledBack)
+
+	trade = data.Trade{ID: 3}
+	assert.Equal(t, data.ErrTradeNotReceiver, trade.Accept(tradeClient(data.TradeProposed), 1, ""))
+	assert.Equal(t, raizel.ErrNotFound, trade.Accept(tradeClient(data.TradeProposed), 9, ""))
+	assert.Equal(t, data.ErrTradeClosed, trade.Accept(tradeClient(data.TradeDeclined), 2, ""))
+}
+
+func Test_TradeDecline(t *testing.T) {
+	client := tradeClient(data.TradeProposed)
+	trade := data.Trade{ID: 3}
+	assert.Nil(t, trade.Decline(client, 2, " no "))
+	assert.Equal(t, data.TradeDeclined, trade.Status)
+	assert.Equal(t, []interface{}{3, data.TradeDeclined, 2, "no"}, client.params[2])
+}
+
+func Test_TradeCounter(t *testing.T) {
+	client := tradeClient(data.TradeProposed, []interface{}{20, 2, "foil", "NM", "en", "", 1, 0}, []interface{}{10, 1, "nonfoil", "NM", "en", "", 4, 0})
+	trade := data.Trade{ID: 3}
+	counter := data.Trade{ID: 99, Message: "one more bolt",
+		Offered:   []data.TradeCard{{IDCard: 2, Finish: "foil", Quantity: 1}},
+		Requested: []data.TradeCard{{IDCard: 1, Quantity: 3}},
+	}
+	assert.Nil(t, trade.Counter(client, 2, &counter))
+	assert.True(t, client.committed)
+	assert.Equal(t, data.TradeCountered, trade.Status)
+	assert.Equal(t, 7, counter.ID)
+	assert.Equal(t, 3, counter.IDParent)
+	assert.Equal(t, 2, counter.IDProposer)
+	assert.Equal(t, 20, counter.IDProposerInventory)
+	assert.Equal(t, 1, counter.IDReceiver)
+	assert.Equal(t, 10, counter.IDReceiverInventory)
+	assert.Equal(t, []interface{}{3, 2, 20, 1, 10, data.TradeProposed, "one more bolt"}, client.params[2])
+	assert.Equal(t, []interface{}{3, data.TradeCountered, 2, ""}, client.params[6])
+}
+
+func Test_TradeRead(t *testing.T) {
+	client := tradeClient(data.TradeProposed)
+	trade := data.Trade{ID: 3}
+	assert.Nil(t, trade.Read(client, 1))
+	assert.Equal(t, "receiver", trade.Receiver)
+	assert.Len(t, trade.Offered, 1)
+	assert.Equal(t, "Counterspell", trade.Requested[0].Name)
+	assert.Equal(t, raizel.ErrNotFound, trade.Read(tradeClient(data.TradeProposed), 9))
+}
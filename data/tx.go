@@ -0,0 +1,61 @@
+package data
+
+import (
+	"errors"
+
+	"github.com/rjansen/l"
+	"github.com/rjansen/raizel"
+)
+
+//ErrTransactionNotSupported is raised when a transaction is required but the raizel client can not begin one
+var ErrTransactionNotSupported = errors.New("data.Transaction.NotSupportedErr: Message='The raizel client does not begin transactions, call SetupSQL'")
+
+//Transaction is a raizel.Client bound to one database transaction
+type Transaction interface {
+	raizel.Client
+	Commit() error
+	Rollback() error
+}
+
+//Transactional is a raizel.Client able to begin a Transaction
+type Transactional interface {
+	raizel.Client
+	Begin() (Transaction, error)
+}
+
+//InTransaction runs the unit of work with a Transaction begun from the client, it commits when the unit returns nil
+//and rollbacks when it fails or panics. A client that is already a Transaction runs the unit inside it
+func InTransaction(client raizel.Client, unit raizel.ClientFunc) (err error) {
+	if _, inTransaction := client.(Transaction); inTransaction {
+		return unit(client)
+	}
+	transactional, isTransactional := client.(Transactional)
+	if !isTransactional {
+		return ErrTransactionNotSupported
+	}
+	tx, err := transactional.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			tx.Rollback()
+			panic(recovered)
+		}
+	}()
+	if err = unit(tx); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			l.Error("data.Transaction.RollbackErr", l.Err(rollbackErr))
+		}
+		l.Info("data.Transaction.RolledBack", l.Err(err))
+		return err
+	}
+	return tx.Commit()
+}
+
+//ExecuteInTransaction gets a Client through raizel.Execute and runs the unit of work with InTransaction
+func ExecuteInTransaction(unit raizel.ClientFunc) error {
+	return raizel.Execute(func(client raizel.Client) error {
+		return InTransaction(client, unit)
+	})
+}
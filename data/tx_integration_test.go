@@ -0,0 +1,156 @@
+package data_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/rjansen/fivecolors/data"
+	"github.com/rjansen/raizel"
+	raizelSQL "github.com/rjansen/raizel/sql"
+	"github.com/stretchr/testify/assert"
+)
+
+//failingPool gets the Transactional clients of the SetupSQL pool whose transactions fail the command containing failOn
+type failingPool struct {
+	raizel.ClientPool
+	failOn string
+}
+
+func (p failingPool) Get() (raizel.Client, error) {
+	client, err := p.ClientPool.Get()
+	if err != nil {
+		return nil, err
+	}
+	return failingClient{Transactional: client.(data.Transactional), failOn: p.failOn}, nil
+}
+
+//failingClient begins the real Transaction wrapped into a failingTransaction
+type failingClient struct {
+	data.Transactional
+	failOn string
+}
+
+func (c failingClient) Begin() (data.Transaction, error) {
+	tx, err := c.Transactional.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return failingTransaction{Transaction: tx, failOn: c.failOn}, nil
+}
+
+//failingTransaction runs in the real Transaction and fails the command containing failOn
+type failingTransaction struct {
+	data.Transaction
+	failOn string
+}
+
+func (t failingTransaction) Exec(command string, params ...interface{}) (raizel.Result, error) {
+	if strings.Contains(command, t.failOn) {
+		return nil, errFakeExec
+	}
+	return t.Transaction.Exec(command, params...)
+}
+
+//transactionDatabase sets the SetupSQL pool with the postgres url of FIVECOLORS_TEST_DATABASE_URL
+func transactionDatabase(t testing.TB) {
+	url := os.Getenv("FIVECOLORS_TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("FIVECOLORS_TEST_DATABASE_URL is not set")
+	}
+	assert.Nil(t, data.SetupSQL(&raizelSQL.Configuration{Driver: "postgres", URL: url, NumConns: 2}))
+}
+
+//failOn installs with raizel.Setup a failingPool over the SetupSQL pool, the returned func restores the SetupSQL pool
+func failOn(t *testing.T, command string) func() {
+	pool, err := raizel.GetPool()
+	assert.Nil(t, err)
+	assert.Nil(t, raizel.Setup(failingPool{ClientPool: pool, failOn: command}))
+	return func() {
+		raizel.Setup(pool)
+	}
+}
+
+//testPlayerAndCards reads the first player and up to cards cards of the database
+func testPlayerAndCards(t testing.TB, cards int) (int, []int) {
+	var (
+		idPlayer int
+		cardIDs  []int
+	)
+	err := raizel.Execute(func(client raizel.Client) error {
+		if err := client.QueryOne("select min(id) from player", func(f raizel.Fetchable) error {
+			return f.Scan(&idPlayer)
+		}); err != nil {
+			return err
+		}
+		return client.Query("select id from card order by id limit $1", func(i raizel.Iterable) error {
+			for i.Next() {
+				var id int
+				if err := i.Scan(&id); err != nil {
+					return err
+				}
+				cardIDs = append(cardIDs, id)
+			}
+			return nil
+		}, cards)
+	})
+	assert.Nil(t, err)
+	if len(cardIDs) == 0 {
+		t.Skip("The database has no cards")
+	}
+	return idPlayer, cardIDs
+}
+
+func Test_DeckPersistRollbackIntegration(t *testing.T) {
+	transactionDatabase(t)
+	idPlayer, cardIDs := testPlayerAndCards(t, 2)
+	if len(cardIDs) < 2 {
+		t.Skip("The database has less than 2 cards")
+	}
+	deck := &data.Deck{Name: "Test_DeckPersistRollbackIntegration", IDPlayer: idPlayer, Cards: []data.Card{
+		{ID: cardIDs[0], DeckCard: data.DeckCard{IDBoard: data.MainBoard, Quantity: 4}},
+	}}
+	assert.Nil(t, raizel.Execute(deck.Persist))
+	defer raizel.Execute(deck.Delete)
+
+	deck.Name = "Test_DeckPersistRollbackIntegration_Renamed"
+	deck.Cards = append(deck.Cards, data.Card{ID: cardIDs[1], DeckCard: data.DeckCard{IDBoard: data.MainBoard, Quantity: 2}})
+	restore := failOn(t, "insert into deck_revision_card")
+	err := raizel.Execute(deck.Persist)
+	restore()
+	assert.Equal(t, errFakeExec, err)
+
+	stored := data.Deck{ID: deck.ID}
+	assert.Nil(t, raizel.Execute(stored.ReadByID))
+	assert.Equal(t, "Test_DeckPersistRollbackIntegration", stored.Name)
+	if assert.Len(t, stored.Cards, 1) {
+		assert.Equal(t, cardIDs[0], stored.Cards[0].ID)
+		assert.Equal(t, 4, stored.Cards[0].DeckCard.Quantity)
+	}
+	var revisions []data.DeckRevision
+	assert.Nil(t, raizel.Execute(func(client raizel.Client) error {
+		revisions, err = stored.ReadRevisions(client)
+		return err
+	}))
+	assert.Len(t, revisions, 1)
+}
+
+func Test_InventoryPersistRollbackIntegration(t *testing.T) {
+	transactionDatabase(t)
+	idPlayer, cardIDs := testPlayerAndCards(t, 1)
+	inventory := &data.Inventory{Name: "Test_InventoryPersistRollbackIntegration", IDPlayer: idPlayer,
+		Cards: []data.Card{{ID: cardIDs[0], InventoryCard: data.InventoryCard{Quantity: 3}}}}
+	restore := failOn(t, "insert into inventory_card")
+	err := raizel.Execute(inventory.Persist)
+	restore()
+	assert.Equal(t, errFakeExec, err)
+	assert.Equal(t, 0, inventory.ID)
+
+	var created int
+	assert.Nil(t, raizel.Execute(func(client raizel.Client) error {
+		return client.QueryOne("select count(1) from inventory where name = $1", func(f raizel.Fetchable) error {
+			return f.Scan(&created)
+		}, inventory.Name)
+	}))
+	assert.Equal(t, 0, created)
+}
@@ -0,0 +1,197 @@
+package data_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rjansen/fivecolors/data"
+	"github.com/rjansen/raizel"
+	"github.com/stretchr/testify/assert"
+)
+
+var errFakeExec = errors.New("fake exec failure")
+
+//fakeClient is a Transactional raizel.Client that records the commands and fails the ones containing failOn
+type fakeClient struct {
+	failOn   string
+	commands []string
+	params   [][]interface{}
+	//notAffected fails no command but the ones containing it affect no rows
+	notAffected string
+	//rows are the result of every Query
+	rows [][]interface{}
+	//results are the rows of the Query commands containing the key, instead of rows
+	results map[string][][]interface{}
+	//one are the row of the QueryOne commands containing the key, the other QueryOne scan every int as 7
+	one map[string][]interface{}
+	//notFound fails with raizel.ErrNotFound the QueryOne commands containing it
+	notFound   string
+	begun      int
+	committed  bool
+	rolledBack bool
+}
+
+type fakeTransaction struct {
+	*fakeClient
+}
+
+type fakeRow struct{}
+
+//fakeResult affects one row unless it is the result of a notAffected command
+type fakeResult struct {
+	notAffected bool
+}
+
+func (r fakeRow) Scan(dest ...interface{}) error {
+	for _, target := range dest {
+		if id, isInt := target.(*int); isInt {
+			*id = 7
+		}
+	}
+	return nil
+}
+
+func (r fakeResult) LastInsertId() (int64, error) { return 0, nil }
+func (r fakeResult) RowsAffected() (int64, error) {
+	if r.notAffected {
+		return 0, nil
+	}
+	return 1, nil
+}
+
+func (c *fakeClient) QueryOne(query string, fetchFunc func(raizel.Fetchable) error, params ...interface{}) error {
+	c.commands = append(c.commands, query)
+	c.params = append(c.params, params)
+	if c.notFound != "" && strings.Contains(query, c.notFound) {
+		return raizel.ErrNotFound
+	}
+	for key, row := range c.one {
+		if strings.Contains(query, key) {
+			return fetchFunc(&pageRows{rows: [][]interface{}{row}, next: 1})
+		}
+	}
+	return fetchFunc(fakeRow{})
+}
+
+func (c *fakeClient) Query(query string, iterFunc func(raizel.Iterable) error, params ...interface{}) error {
+	c.commands = append(c.commands, query)
+	c.params = append(c.params, params)
+	for key, rows := range c.results {
+		if strings.Contains(query, key) {
+			return iterFunc(&pageRows{rows: rows})
+		}
+	}
+	return iterFunc(&pageRows{rows: c.rows})
+}
+
+func (c *fakeClient) Exec(command string, params ...interface{}) (raizel.Result, error) {
+	c.commands = append(c.commands, command)
+	c.params = append(c.params, params)
+	if c.failOn != "" && strings.Contains(command, c.failOn) {
+		return nil, errFakeExec
+	}
+	return fakeResult{notAffected: c.notAffected != "" && strings.Contains(command, c.notAffected)}, nil
+}
+
+func (c *fakeClient) Close() error { return nil }
+
+func (c *fakeClient) Begin() (data.Transaction, error) {
+	c.begun++
+	return fakeTransaction{c}, nil
+}
+
+func (t fakeTransaction) Commit() error {
+	t.committed = true
+	return nil
+}
+
+func (t fakeTransaction) Rollback() error {
+	t.rolledBack = true
+	return nil
+}
+
+func fakeDeck() *data.Deck {
+	return &data.Deck{Name: "Test_TransactionDeck", Cards: []data.Card{
+		{ID: 1, DeckCard: data.DeckCard{IDBoard: data.MainBoard, Quantity: 4}},
+		{ID: 2, DeckCard: data.DeckCard{IDBoard: data.MainBoard, Quantity: 4}},
+	}}
+}
+
+func Test_DeckPersistCommits(t *testing.T) {
+	client := &fakeClient{}
+	deck := fakeDeck()
+	assert.Nil(t, deck.Persist(client))
+	assert.Equal(t, 7, deck.ID)
+	assert.Equal(t, 1, client.begun)
+	assert.True(t, client.committed)
+	assert.False(t, client.rolledBack)
+}
+
+func Test_DeckPersistRollsBack(t *testing.T) {
+	client := &fakeClient{failOn: "insert into deck_card"}
+	deck := fakeDeck()
+	assert.Equal(t, errFakeExec, deck.Persist(client))
+	assert.Equal(t, 0, deck.ID)
+	assert.True(t, client.rolledBack)
+	assert.False(t, client.committed)
+	assert.Contains(t, client.commands[1], "delete from deck_card")
+}
+
+func Test_DeckPersistNotOwned(t *testing.T) {
+	client := &fakeClient{notAffected: "update deck set"}
+	deck := fakeDeck()
+	deck.ID, deck.IDPlayer = 3, 2
+	assert.Equal(t, raizel.ErrNotFound, deck.Persist(client))
+	assert.Equal(t, []interface{}{deck.Name, 3, 2}, client.params[0])
+	assert.Len(t, client.commands, 1)
+	assert.True(t, client.rolledBack)
+}
+
+func Test_DeckDeleteNotOwned(t *testing.T) {
+	client := &fakeClient{one: map[string][]interface{}{"from deck d": {3, "Test_DeckDeleteNotOwned", 9}}}
+	deck := &data.Deck{ID: 3, IDPlayer: 2}
+	assert.Equal(t, raizel.ErrNotFound, deck.Delete(client))
+	assert.Len(t, client.commands, 1)
+	assert.True(t, client.rolledBack)
+}
+
+func Test_DeckDeleteOwned(t *testing.T) {
+	client := &fakeClient{one: map[string][]interface{}{"from deck d": {3, "Test_DeckDeleteOwned", 2}}}
+	deck := &data.Deck{ID: 3, IDPlayer: 2}
+	assert.Nil(t, deck.Delete(client))
+	assert.Contains(t, client.commands[len(client.commands)-1], "delete from deck where id = $1")
+	assert.True(t, client.committed)
+}
+
+func Test_InventoryPersistRollsBack(t *testing.T) {
+	client := &fakeClient{failOn: "insert into inventory_card"}
+	inventory := &data.Inventory{Name: "Test_TransactionInventory", IDPlayer: 1, Cards: []data.Card{{ID: 1}}}
+	assert.Equal(t, errFakeExec, inventory.Persist(client))
+	assert.Equal(t, 0, inventory.ID)
+	assert.True(t, client.rolledBack)
+	assert.False(t, client.committed)
+}
+
+func Test_InventoryPersistInvalid(t *testing.T) {
+	client := &fakeClient{}
+	inventory := &data.Inventory{IDPlayer: 1, Name: " "}
+	assert.Equal(t, data.ErrInvalidInventory, inventory.Persist(client))
+	assert.Empty(t, client.commands)
+	assert.False(t, client.committed)
+}
+
+func Test_InTransactionNested(t *testing.T) {
+	client := &fakeClient{}
+	err := data.InTransaction(client, func(tx raizel.Client) error {
+		return fakeDeck().Persist(tx)
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, client.begun)
+	assert.True(t, client.committed)
+}
+
+func Test_InTransactionNotSupported(t *testing.T) {
+	var client struct{ raizel.Client }
+	assert.Equal(t, data.ErrTransactionNotSupported, fakeDeck().Persist(client))
+}
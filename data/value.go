@@ -0,0 +1,201 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/rjansen/l"
+	"github.com/rjansen/raizel"
+)
+
+//MaxValueHistory is the greatest number of points of a value history
+const MaxValueHistory = 400
+
+var (
+	//ErrInvalidValueHistory is raised when the history starts after its end, has an unknown interval or too many points
+	ErrInvalidValueHistory = errors.New("data.Value.InvalidHistoryErr: Message='History requires from <= date, interval day, week or month and at most 400 points'")
+
+	//valueIntervals are the postgres intervals of the history points
+	valueIntervals = map[string]string{"day": "1 day", "week": "1 week", "month": "1 month"}
+)
+
+//ValueOptions are the dates of a value. Date defaults to today and, when From is set,
+//the History holds the value from From to Date by Interval, which defaults to day
+type ValueOptions struct {
+	Date     time.Time
+	From     time.Time
+	Interval string
+}
+
+//CardValue is the value of the copies of one card finish with the last price on or before the value date
+type CardValue struct {
+	IDCard   int     `json:"idCard"`
+	Name     string  `json:"name"`
+	Finish   string  `json:"finish"`
+	Quantity int     `json:"quantity"`
+	Price    float64 `json:"price"`
+	Value    float64 `json:"value"`
+	Priced   bool    `json:"priced"`
+}
+
+//ValuePoint is the value of the copies on one date
+type ValuePoint struct {
+	Date  time.Time `json:"date"`
+	Value float64   `json:"value"`
+}
+
+//CollectionValue is the value of the copies of an Inventory or of a Deck on Date, the cards are sorted by value.
+//Unpriced counts the copies without a price on or before Date
+type CollectionValue struct {
+	IDInventory int          `json:"idInventory,omitempty"`
+	IDDeck      int          `json:"idDeck,omitempty"`
+	Date        time.Time    `json:"date"`
+	Value       float64      `json:"value"`
+	Copies      int          `json:"copies"`
+	Unpriced    int          `json:"unpriced"`
+	Cards       []CardValue  `json:"cards"`
+	History     []ValuePoint `json:"history,omitempty"`
+}
+
+//Value reads the value of the Inventory copies by card and finish. The History uses the current copies
+func (i *Inventory) Value(client raizel.Client, options ValueOptions) (CollectionValue, error) {
+	value := CollectionValue{IDInventory: i.ID}
+	if i.ID <= 0 {
+		return value, errors.New("data.Inventory.ValueErr: Message='Inventory.ID is empty'")
+	}
+	copies := `(select id_card, finish, sum(quantity) quantity from inventory_card
+		where id_inventory = $1 and quantity > 0 group by id_card, finish) v`
+	err := value.read(client, copies, i.ID, options)
+	return value, err
+}
+
+//Value reads the value of the Deck cards of both boards with the nonfoil prices. The History uses the current cards
+func (d *Deck) Value(client raizel.Client, options ValueOptions) (CollectionValue, error) {
+	value := CollectionValue{IDDeck: d.ID}
+	if d.ID <= 0 {
+		return value, errors.New("data.Deck.ValueErr: Message='Deck.ID is empty'")
+	}
+	if err := client.QueryOne("select d.id, d.name, d.id_player from deck d where d.id = $1", d.FetchSmall, d.ID); err != nil {
+		return value, err
+	}
+	copies := `(select id_card, '` + FinishNonFoil + `' finish, sum(quantity) quantity from deck_card
+		where id_deck = $1 group by id_card) v`
+	err := value.read(client, copies, d.ID, options)
+	return value, err
+}
+
+//lastPrice is the lateral join of the last card_price p of the copies v on or before the date parameter
+func lastPrice(date string) string {
+	return `left join lateral (
+			select cp.price from card_price cp
+			where cp.id_card = v.id_card and cp.finish = v.finish and cp.dt_price <= ` + date + `
+			order by cp.dt_price desc limit 1
+		) p on true`
+}
+
+//read fills the value of the copies v, a derived table of id_card, finish and quantity of the id parameter
+func (c *CollectionValue) read(client raizel.Client, copies string, id int, options ValueOptions) error {
+	c.Date = options.Date
+	if c.Date.IsZero() {
+		c.Date = time.Now()
+	}
+	c.Date = time.Date(c.Date.Year(), c.Date.Month(), c.Date.Day(), 0, 0, 0, 0, time.UTC)
+	date := c.Date.Format(PriceDateLayout)
+	c.Cards = []CardValue{}
+	query := `
+		select c.id, c.name, v.finish, v.quantity, coalesce(p.price, 0), p.price is not null
+		from ` + copies + `
+			join card c on c.id = v.id_card
+			` + lastPrice("$2::date")
+	err := client.Query(query, func(i raizel.Iterable) error {
+		for i.Next() {
+			var card CardValue
+			if err := i.Scan(&card.IDCard, &card.Name, &card.Finish, &card.Quantity, &card.Price, &card.Priced); err != nil {
+				return err
+			}
+			card.Value = cents(card.Price * float64(card.Quantity))
+			c.Value += card.Value
+			c.Copies += card.Quantity
+			if !card.Priced {
+				c.Unpriced += card.Quantity
+			}
+			c.Cards = append(c.Cards, card)
+		}
+		return nil
+	}, id, date)
+	if err != nil {
+		return err
+	}
+	c.Value = cents(c.Value)
+	sort.SliceStable(c.Cards, func(i, j int) bool {
+		if c.Cards[i].Value != c.Cards[j].Value {
+			return c.Cards[i].Value > c.Cards[j].Value
+		}
+		if c.Cards[i].Name != c.Cards[j].Name {
+			return c.Cards[i].Name < c.Cards[j].Name
+		}
+		return c.Cards[i].Finish < c.Cards[j].Finish
+	})
+	if !options.From.IsZero() {
+		if err := c.readHistory(client, copies, id, options); err != nil {
+			return err
+		}
+	}
+	l.Debug("data.CollectionValue.Read",
+		l.Int("IDInventory", c.IDInventory),
+		l.Int("IDDeck", c.IDDeck),
+		l.String("Date", date),
+		l.Int("Cards.Len", len(c.Cards)),
+		l.Int("History.Len", len(c.History)),
+	)
+	return nil
+}
+
+//readHistory fills the History with the value of the copies on each date from options.From to Date
+func (c *CollectionValue) readHistory(client raizel.Client, copies string, id int, options ValueOptions) error {
+	if options.Interval == "" {
+		options.Interval = "day"
+	}
+	interval, found := valueIntervals[options.Interval]
+	from := time.Date(options.From.Year(), options.From.Month(), options.From.Day(), 0, 0, 0, 0, time.UTC)
+	if !found || from.After(c.Date) || historyPoints(from, c.Date, options.Interval) > MaxValueHistory {
+		return ErrInvalidValueHistory
+	}
+	query := fmt.Sprintf(`
+		select d.day::date, coalesce(sum(v.quantity * p.price), 0)
+		from generate_series($2::date, $3::date, interval '%s') d(day)
+			left join %s on true
+			%s
+		group by d.day
+		order by d.day`, interval, copies, lastPrice("d.day"))
+	c.History = []ValuePoint{}
+	return client.Query(query, func(i raizel.Iterable) error {
+		for i.Next() {
+			var point ValuePoint
+			if err := i.Scan(&point.Date, &point.Value); err != nil {
+				return err
+			}
+			point.Value = cents(point.Value)
+			c.History = append(c.History, point)
+		}
+		return nil
+	}, id, from.Format(PriceDateLayout), c.Date.Format(PriceDateLayout))
+}
+
+//historyPoints is the number of dates from from to to by interval
+func historyPoints(from, to time.Time, interval string) int {
+	switch interval {
+	case "week":
+		return int(to.Sub(from).Hours()/24/7) + 1
+	case "month":
+		return (to.Year()-from.Year())*12 + int(to.Month()-from.Month()) + 1
+	}
+	return int(to.Sub(from).Hours()/24) + 1
+}
+
+func cents(value float64) float64 {
+	return math.Round(value*100) / 100
+}
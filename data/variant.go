@@ -0,0 +1,212 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/rjansen/raizel"
+)
+
+const (
+	//FinishNonFoil is the finish of the regular copies
+	FinishNonFoil = "nonfoil"
+	//FinishFoil is the finish of the foil copies
+	FinishFoil = "foil"
+	//FinishEtched is the finish of the etched foil copies
+	FinishEtched = "etched"
+	//DefaultCondition is the condition of the copies persisted without one
+	DefaultCondition = "NM"
+	//DefaultLanguage is the language of the copies persisted without one
+	DefaultLanguage = "en"
+	//maxGraded is the size of the inventory_card.graded column
+	maxGraded = 32
+)
+
+var (
+	//ErrInvalidInventoryCard is raised when the finish, condition, language or graded of a copy is not valid
+	ErrInvalidInventoryCard = errors.New("data.InventoryCard.InvalidErr: Message='Finish, condition, language or graded is invalid'")
+
+	//Conditions are the copy conditions from the best to the worst
+	Conditions = []string{"NM", "LP", "MP", "HP", "DMG"}
+	//Languages are the printed languages by code
+	Languages = map[string]string{
+		"en": "english", "es": "spanish", "fr": "french", "de": "german", "it": "italian", "pt": "portuguese",
+		"ja": "japanese", "ko": "korean", "ru": "russian", "zhs": "simplified chinese", "zht": "traditional chinese",
+		"he": "hebrew", "la": "latin", "grc": "ancient greek", "ar": "arabic", "sa": "sanskrit", "ph": "phyrexian",
+	}
+
+	//conditionNames are the condition names used by Deckbox, TCGplayer and Cardmarket exports
+	conditionNames = map[string]string{
+		"mint": "NM", "near mint": "NM", "m": "NM",
+		"lightly played": "LP", "slightly played": "LP", "excellent": "LP", "sp": "LP", "ex": "LP",
+		"moderately played": "MP", "good": "MP", "gd": "MP",
+		"heavily played": "HP", "played": "HP", "pl": "HP",
+		"damaged": "DMG", "poor": "DMG", "po": "DMG",
+	}
+	languageNames = map[string]string{"chinese": "zhs", "portuguese (brazil)": "pt", "jp": "ja", "kr": "ko", "cn": "zhs", "tw": "zht"}
+)
+
+//ParseFinish returns the finish of the value: empty, nonfoil, normal and false values are nonfoil,
+//foil and true values are foil and etched values are etched
+func ParseFinish(value string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", FinishNonFoil, "non-foil", "normal", "false", "no", "n", "0":
+		return FinishNonFoil, true
+	case FinishFoil, "true", "yes", "y", "1":
+		return FinishFoil, true
+	case FinishEtched, "etched foil", "foil etched":
+		return FinishEtched, true
+	}
+	return "", false
+}
+
+//ParseCondition returns the condition of the value, by code or name. An empty value is DefaultCondition
+func ParseCondition(value string) (string, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return DefaultCondition, true
+	}
+	for _, condition := range Conditions {
+		if strings.EqualFold(condition, value) {
+			return condition, true
+		}
+	}
+	condition, found := conditionNames[strings.ToLower(value)]
+	return condition, found
+}
+
+//ParseLanguage returns the language code of the value, by code or name. An empty value is DefaultLanguage
+func ParseLanguage(value string) (string, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return DefaultLanguage, true
+	}
+	if _, found := Languages[value]; found {
+		return value, true
+	}
+	for code, name := range Languages {
+		if name == value {
+			return code, true
+		}
+	}
+	code, found := languageNames[value]
+	return code, found
+}
+
+//Normalize validates the finish, condition, language and graded of the copies and fills the defaults of the empty ones
+func (c *InventoryCard) Normalize() error {
+	var valid [3]bool
+	c.Finish, valid[0] = ParseFinish(c.Finish)
+	c.Condition, valid[1] = ParseCondition(c.Condition)
+	c.Language, valid[2] = ParseLanguage(c.Language)
+	c.Graded = strings.TrimSpace(c.Graded)
+	if !valid[0] || !valid[1] || !valid[2] || len(c.Graded) > maxGraded {
+		return ErrInvalidInventoryCard
+	}
+	return nil
+}
+
+//inventoryCopies is the inventory_card i of the inventory parameter with the copies summed by card and the columns.
+//The restrictions over the inventory_card v alias filter the summed copies
+func inventoryCopies(param string, restrictions []string, columns ...string) string {
+	where := "v.id_inventory = " + param
+	for _, restriction := range restrictions {
+		where += " and " + restriction
+	}
+	groupBy := "v.id_inventory, v.id_card"
+	for _, column := range columns {
+		groupBy += ", v." + column
+	}
+	return `(select ` + groupBy + `, sum(v.quantity) quantity from inventory_card v
+				where ` + where + ` group by ` + groupBy + `) i`
+}
+
+//copyRestrictions parses the Finish, Condition, Language and Graded filters into restrictions over
+//the inventory_card v alias with the parameters numbered after idxParam
+func (q *CardQuery) copyRestrictions(idxParam int) ([]string, []interface{}, int, error) {
+	var (
+		restrictions []string
+		values       []interface{}
+	)
+	lists := []struct {
+		column, filter string
+		parse          func(string) (string, bool)
+	}{
+		{"v.finish", q.Finish, ParseFinish},
+		{"v.condition", q.Condition, ParseCondition},
+		{"v.language", q.Language, ParseLanguage},
+	}
+	for _, list := range lists {
+		var params []string
+		for _, value := range strings.Split(list.filter, ",") {
+			if strings.TrimSpace(value) == "" {
+				continue
+			}
+			parsed, valid := list.parse(value)
+			if !valid {
+				return nil, nil, idxParam, ErrInvalidInventoryCard
+			}
+			idxParam++
+			params = append(params, fmt.Sprintf("$%d", idxParam))
+			values = append(values, parsed)
+		}
+		if len(params) > 0 {
+			restrictions = append(restrictions, fmt.Sprintf("%s in (%s)", list.column, strings.Join(params, ", ")))
+		}
+	}
+	switch strings.ToLower(strings.TrimSpace(q.Graded)) {
+	case "":
+	case "true":
+		restrictions = append(restrictions, "v.graded <> ''")
+	case "false":
+		restrictions = append(restrictions, "v.graded = ''")
+	default:
+		return nil, nil, idxParam, ErrInvalidInventoryCard
+	}
+	return restrictions, values, idxParam, nil
+}
+
+//readCopies fills the Copies of the Inventory cards with the copies matching the finish, condition, language
+//and graded filters of the cardQuery
+func (i *Inventory) readCopies(client raizel.Client, cardQuery *CardQuery) error {
+	if len(i.Cards) == 0 {
+		return nil
+	}
+	ids := make([]int, len(i.Cards))
+	cardIndex := make(map[int]int, len(i.Cards))
+	for index, card := range i.Cards {
+		ids[index] = card.ID
+		cardIndex[card.ID] = index
+		i.Cards[index].Copies = []InventoryCard{}
+	}
+	restrictions, values, _, err := cardQuery.copyRestrictions(2)
+	if err != nil {
+		return err
+	}
+	query := `
+		select v.id_card, v.finish, v.condition, v.language, v.graded, v.quantity
+		from inventory_card v
+		where v.id_inventory = $1 and v.id_card = any($2) and v.quantity > 0`
+	for _, restriction := range restrictions {
+		query += " and " + restriction
+	}
+	query += " order by v.id_card, v.finish, v.condition, v.language, v.graded"
+	return client.Query(query, func(iterable raizel.Iterable) error {
+		for iterable.Next() {
+			var (
+				idCard        int
+				inventoryCard = InventoryCard{IDInventory: i.ID}
+			)
+			if err := iterable.Scan(&idCard, &inventoryCard.Finish, &inventoryCard.Condition, &inventoryCard.Language,
+				&inventoryCard.Graded, &inventoryCard.Quantity); err != nil {
+				return err
+			}
+			if index, found := cardIndex[idCard]; found {
+				i.Cards[index].Copies = append(i.Cards[index].Copies, inventoryCard)
+			}
+		}
+		return nil
+	}, append([]interface{}{i.ID, pq.Array(ids)}, values...)...)
+}
@@ -0,0 +1,69 @@
+package data_test
+
+import (
+	"testing"
+
+	"github.com/rjansen/fivecolors/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_InventoryCardNormalize(t *testing.T) {
+	inventoryCard := data.InventoryCard{Quantity: 2, Finish: "Foil", Condition: "lightly played", Language: "Japanese", Graded: " PSA 9 "}
+	assert.Nil(t, inventoryCard.Normalize())
+	assert.Equal(t, data.InventoryCard{Quantity: 2, Finish: data.FinishFoil, Condition: "LP", Language: "ja", Graded: "PSA 9"}, inventoryCard)
+
+	inventoryCard = data.InventoryCard{Quantity: 1}
+	assert.Nil(t, inventoryCard.Normalize())
+	assert.Equal(t, data.InventoryCard{Quantity: 1, Finish: data.FinishNonFoil, Condition: data.DefaultCondition,
+		Language: data.DefaultLanguage}, inventoryCard)
+
+	for _, invalid := range []data.InventoryCard{{Finish: "shiny"}, {Condition: "EX+"}, {Language: "elvish"},
+		{Graded: "Professional Sports Authenticator Gem Mint 10"}} {
+		assert.Equal(t, data.ErrInvalidInventoryCard, invalid.Normalize())
+	}
+}
+
+func Test_InventoryPersistCopies(t *testing.T) {
+	client := &fakeClient{}
+	inventory := data.Inventory{ID: 3, IDPlayer: 1, Cards: []data.Card{
+		{ID: 1, InventoryCard: data.InventoryCard{Quantity: 4}},
+		{ID: 1, InventoryCard: data.InventoryCard{Quantity: 2, Finish: "foil"}},
+		{ID: 2, Copies: []data.InventoryCard{
+			{Quantity: 1, Condition: "HP", Language: "de"},
+			{Quantity: 1, Graded: "BGS 9.5"},
+			{Quantity: 3, Condition: "hp", Language: "German"},
+		}},
+	}}
+	assert.Nil(t, inventory.Persist(client))
+	upsert := client.commands[1]
+	assert.Contains(t, upsert, "insert into inventory_card (id_inventory, id_card, finish, condition, language, graded, quantity)")
+	assert.Contains(t, upsert, "on conflict(id_inventory, id_card, finish, condition, language, graded)")
+	assert.Equal(t, []interface{}{
+		3, 1, "nonfoil", "NM", "en", "", 4,
+		3, 1, "foil", "NM", "en", "", 2,
+		3, 2, "nonfoil", "HP", "de", "", 3,
+		3, 2, "nonfoil", "NM", "en", "BGS 9.5", 1,
+	}, client.params[1])
+
+	client = &fakeClient{}
+	inventory.Cards = []data.Card{{ID: 1, InventoryCard: data.InventoryCard{Quantity: 1, Condition: "mangled"}}}
+	assert.Equal(t, data.ErrInvalidInventoryCard, inventory.Persist(client))
+	assert.True(t, client.rolledBack)
+}
+
+func Test_CardQueryCopyFilters(t *testing.T) {
+	cardQuery := data.CardQuery{RegexName: "bolt", Finish: "foil,etched", Condition: "NM, lp", Graded: "false", IDInventory: 7}
+	assert.Nil(t, cardQuery.Build())
+	assert.Contains(t, cardQuery.SQL, "where v.id_inventory = $6 and v.finish in ($2, $3) and v.condition in ($4, $5)"+
+		" and v.graded = '' group by v.id_inventory, v.id_card) i on i.id_card = c.id")
+	assert.Contains(t, cardQuery.SQL, "where c.name ~* $1 and coalesce(i.quantity, 0) > 0")
+	assert.Equal(t, []interface{}{"bolt", "foil", "etched", "NM", "LP", 7}, cardQuery.Values)
+
+	assert.Nil(t, cardQuery.BuildTotals())
+	assert.Contains(t, cardQuery.SQL, "v.finish in ($2, $3)")
+	assert.Equal(t, []interface{}{"bolt", "foil", "etched", "NM", "LP", 7}, cardQuery.Values)
+
+	for _, invalid := range []data.CardQuery{{Finish: "shiny"}, {Language: "klingon"}, {Graded: "maybe"}} {
+		assert.Equal(t, data.ErrInvalidInventoryCard, invalid.Build())
+	}
+}
@@ -0,0 +1,241 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rjansen/l"
+	"github.com/rjansen/raizel"
+)
+
+//ErrInvalidWishlist is raised when a wishlist card has no card, a negative quantity or an invalid finish
+var ErrInvalidWishlist = errors.New("data.Wishlist.InvalidErr: Message='Wishlist cards require a card, a quantity not negative and a valid finish'")
+
+//WishlistCard is a card wanted by a player. Quantity is the minimum copies wanted, an empty Finish is any finish
+//and Printing restricts the wanted copies to the IDCard printing instead of any printing with the card name
+type WishlistCard struct {
+	IDCard   int    `json:"idCard"`
+	Name     string `json:"name,omitempty"`
+	Quantity int    `json:"quantity"`
+	Finish   string `json:"finish,omitempty"`
+	Printing bool   `json:"printing"`
+}
+
+//Wishlist is the cards wanted by a Player
+type Wishlist struct {
+	IDPlayer int            `json:"idPlayer"`
+	Cards    []WishlistCard `json:"cards"`
+}
+
+//WishlistOffer is the spare copies, beyond the deck reservations, of a card printing and finish of an inventory
+type WishlistOffer struct {
+	IDPlayer    int    `json:"idPlayer"`
+	Username    string `json:"username"`
+	IDInventory int    `json:"idInventory"`
+	IDCard      int    `json:"idCard"`
+	Expansion   string `json:"expansion"`
+	Finish      string `json:"finish"`
+	Spare       int    `json:"spare"`
+}
+
+//WishlistMatch is a wishlist card of a player with the copies the player misses to reach its quantity and the
+//offers of spare copies matching it
+type WishlistMatch struct {
+	IDPlayer int    `json:"idPlayer"`
+	Username string `json:"username"`
+	WishlistCard
+	Owned   int             `json:"owned"`
+	Missing int             `json:"missing"`
+	Offers  []WishlistOffer `json:"offers"`
+}
+
+//WishlistMatches are the Wants, the wishlist cards of the player with the spare copies of the other players,
+//and the Haves, the wishlist cards of the other players with the spare copies of the player
+type WishlistMatches struct {
+	IDPlayer int             `json:"idPlayer"`
+	Wants    []WishlistMatch `json:"wants"`
+	Haves    []WishlistMatch `json:"haves"`
+}
+
+//Normalize validates the wishlist card and parses its finish, an empty finish is any finish
+func (c *WishlistCard) Normalize() error {
+	if c.IDCard <= 0 || c.Quantity < 0 {
+		return ErrInvalidWishlist
+	}
+	if c.Finish = strings.TrimSpace(c.Finish); c.Finish != "" {
+		var valid bool
+		if c.Finish, valid = ParseFinish(c.Finish); !valid {
+			return ErrInvalidWishlist
+		}
+	}
+	return nil
+}
+
+//Read reads the cards of the Wishlist by name
+func (w *Wishlist) Read(client raizel.Client) error {
+	if w.IDPlayer <= 0 {
+		return errors.New("data.Wishlist.ReadErr: Message='Wishlist.IDPlayer is empty'")
+	}
+	w.Cards = []WishlistCard{}
+	query := `
+		select w.id_card, c.name, w.quantity, w.finish, w.printing
+		from wishlist_card w
+			join card c on c.id = w.id_card
+		where w.id_player = $1
+		order by c.name, w.id_card`
+	return client.Query(query, func(iterable raizel.Iterable) error {
+		for iterable.Next() {
+			var card WishlistCard
+			if err := iterable.Scan(&card.IDCard, &card.Name, &card.Quantity, &card.Finish, &card.Printing); err != nil {
+				return err
+			}
+			w.Cards = append(w.Cards, card)
+		}
+		return nil
+	}, w.IDPlayer)
+}
+
+//Persist adds or updates the Wishlist cards, the cards with quantity zero are removed from the wishlist
+func (w *Wishlist) Persist(client raizel.Client) error {
+	if w.IDPlayer <= 0 {
+		return errors.New("data.Wishlist.PersistErr: Message='Wishlist.IDPlayer is empty'")
+	}
+	//One multi-row upsert can not touch a row twice, the last repeated card wins
+	var (
+		ids       []int
+		cardByID  = make(map[int]WishlistCard, len(w.Cards))
+		persisted = make([][]interface{}, 0, len(w.Cards))
+		removed   []int
+	)
+	for _, card := range w.Cards {
+		if err := card.Normalize(); err != nil {
+			l.Info("data.Wishlist.InvalidCard", l.Struct("Card", card))
+			return err
+		}
+		if _, repeated := cardByID[card.IDCard]; !repeated {
+			ids = append(ids, card.IDCard)
+		}
+		cardByID[card.IDCard] = card
+	}
+	for _, id := range ids {
+		card := cardByID[id]
+		if card.Quantity == 0 {
+			removed = append(removed, id)
+			continue
+		}
+		persisted = append(persisted, []interface{}{w.IDPlayer, id, card.Quantity, card.Finish, card.Printing})
+	}
+	return InTransaction(client, func(tx raizel.Client) error {
+		for _, idCard := range removed {
+			if _, err := tx.Exec("delete from wishlist_card where id_player = $1 and id_card = $2", w.IDPlayer, idCard); err != nil {
+				return err
+			}
+		}
+		upsert := `
+			insert into wishlist_card (id_player, id_card, quantity, finish, printing)
+			values %s
+			on conflict(id_player, id_card)
+			do update set quantity = excluded.quantity, finish = excluded.finish, printing = excluded.printing
+		`
+		if err := batchInsert(tx, upsert, persisted); err != nil {
+			return err
+		}
+		l.Info("data.Wishlist.Persisted",
+			l.Int("IDPlayer", w.IDPlayer),
+			l.Int("Cards.Len", len(persisted)),
+			l.Int("Removed.Len", len(removed)),
+		)
+		return nil
+	})
+}
+
+//spareCopies are the copies s of every inventory by card and finish beyond the deck reservations of the card,
+//the reservations are not by finish and reduce the spare copies of every finish
+const spareCopies = `(
+			select v.id_inventory, v.id_card, v.finish,
+				least(v.quantity, v.total - coalesce(r.reserved, 0)) spare
+			from (
+				select id_inventory, id_card, finish, sum(quantity) quantity,
+					sum(sum(quantity)) over (partition by id_inventory, id_card) total
+				from inventory_card where quantity > 0
+				group by id_inventory, id_card, finish
+			) v
+				left join (
+					select id_inventory, id_card, sum(quantity) reserved from deck_reservation group by id_inventory, id_card
+				) r on r.id_inventory = v.id_inventory and r.id_card = v.id_card
+		) s`
+
+//Matches reads the Wants, the Wishlist cards the player misses with the spare copies of the other players, and
+//the Haves, the cards the other players miss with the spare copies of the Wishlist player
+func (w *Wishlist) Matches(client raizel.Client) (WishlistMatches, error) {
+	matches := WishlistMatches{IDPlayer: w.IDPlayer}
+	if w.IDPlayer <= 0 {
+		return matches, errors.New("data.Wishlist.MatchesErr: Message='Wishlist.IDPlayer is empty'")
+	}
+	var err error
+	if matches.Wants, err = readWishlistMatches(client, "w.id_player = $1 and i.id_player <> $1", w.IDPlayer); err != nil {
+		return matches, err
+	}
+	if matches.Haves, err = readWishlistMatches(client, "w.id_player <> $1 and i.id_player = $1", w.IDPlayer); err != nil {
+		return matches, err
+	}
+	l.Debug("data.Wishlist.Matches",
+		l.Int("IDPlayer", w.IDPlayer),
+		l.Int("Wants.Len", len(matches.Wants)),
+		l.Int("Haves.Len", len(matches.Haves)),
+	)
+	return matches, nil
+}
+
+//readWishlistMatches reads the wishlist cards w, missing copies to their players, with the spare copies of the
+//inventories i matching them and the restriction
+func readWishlistMatches(client raizel.Client, restriction string, idPlayer int) ([]WishlistMatch, error) {
+	query := fmt.Sprintf(`
+		select w.id_player, wp.username, w.id_card, wc.name, w.quantity, w.finish, w.printing, o.owned,
+			i.id_player, ip.username, s.id_inventory, s.id_card, coalesce(e.name, ''), s.finish, s.spare
+		from wishlist_card w
+			join player wp on wp.id = w.id_player
+			join card wc on wc.id = w.id_card
+			join lateral (
+				select coalesce(sum(v.quantity), 0) owned
+				from inventory_card v
+					join inventory oi on oi.id = v.id_inventory
+					join card oc on oc.id = v.id_card
+				where oi.id_player = w.id_player and (oc.id = w.id_card or (not w.printing and oc.name = wc.name))
+					and (w.finish = '' or v.finish = w.finish)
+			) o on true
+			join card c on c.id = w.id_card or (not w.printing and c.name = wc.name)
+			join %s on s.id_card = c.id and (w.finish = '' or s.finish = w.finish)
+			join inventory i on i.id = s.id_inventory
+			join player ip on ip.id = i.id_player
+			left join expansion e on e.id = c.id_expansion
+		where %s and w.quantity > o.owned and s.spare > 0
+		order by wc.name, wp.username, w.id_player, w.id_card, ip.username, s.id_inventory, e.name, s.id_card, s.finish`,
+		spareCopies, restriction)
+	matches := []WishlistMatch{}
+	err := client.Query(query, func(iterable raizel.Iterable) error {
+		for iterable.Next() {
+			var (
+				match WishlistMatch
+				offer WishlistOffer
+			)
+			if err := iterable.Scan(&match.IDPlayer, &match.Username, &match.IDCard, &match.Name, &match.Quantity,
+				&match.Finish, &match.Printing, &match.Owned,
+				&offer.IDPlayer, &offer.Username, &offer.IDInventory, &offer.IDCard, &offer.Expansion, &offer.Finish,
+				&offer.Spare); err != nil {
+				return err
+			}
+			last := len(matches) - 1
+			if last < 0 || matches[last].IDPlayer != match.IDPlayer || matches[last].IDCard != match.IDCard {
+				match.Missing = match.Quantity - match.Owned
+				match.Offers = []WishlistOffer{}
+				matches = append(matches, match)
+				last++
+			}
+			matches[last].Offers = append(matches[last].Offers, offer)
+		}
+		return nil
+	}, idPlayer)
+	return matches, err
+}
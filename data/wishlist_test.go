@@ -0,0 +1,49 @@
+package data_test
+
+import (
+	"testing"
+
+	"github.com/rjansen/fivecolors/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_WishlistPersist(t *testing.T) {
+	client := &fakeClient{}
+	wishlist := data.Wishlist{IDPlayer: 1, Cards: []data.WishlistCard{
+		{IDCard: 1, Quantity: 4},
+		{IDCard: 2, Quantity: 1, Finish: "true", Printing: true},
+		{IDCard: 3, Quantity: 2},
+		{IDCard: 1, Quantity: 3, Finish: " "},
+		{IDCard: 3},
+	}}
+	assert.Nil(t, wishlist.Persist(client))
+	assert.True(t, client.committed)
+	assert.Equal(t, []interface{}{1, 3}, client.params[0])
+	assert.Contains(t, client.commands[1], "on conflict(id_player, id_card)")
+	assert.Equal(t, []interface{}{1, 1, 3, "", false, 1, 2, 1, data.FinishFoil, true}, client.params[1])
+
+	for _, card := range []data.WishlistCard{{Quantity: 1}, {IDCard: 1, Quantity: -1}, {IDCard: 1, Quantity: 1, Finish: "shiny"}} {
+		wishlist = data.Wishlist{IDPlayer: 1, Cards: []data.WishlistCard{card}}
+		assert.Equal(t, data.ErrInvalidWishlist, wishlist.Persist(&fakeClient{}))
+	}
+}
+
+func Test_WishlistMatches(t *testing.T) {
+	client := &fakeClient{rows: [][]interface{}{
+		{1, "me", 10, "Lightning Bolt", 4, "", false, 1, 2, "ana", 20, 10, "Magic 2010", "nonfoil", 2},
+		{1, "me", 10, "Lightning Bolt", 4, "", false, 1, 3, "bob", 30, 11, "Masters 25", "foil", 1},
+		{1, "me", 12, "Counterspell", 1, "foil", true, 0, 2, "ana", 20, 12, "Ice Age", "foil", 1},
+	}}
+	wishlist := data.Wishlist{IDPlayer: 1}
+	matches, err := wishlist.Matches(client)
+	assert.Nil(t, err)
+	assert.Len(t, matches.Wants, 2)
+	assert.Equal(t, 3, matches.Wants[0].Missing)
+	assert.Len(t, matches.Wants[0].Offers, 2)
+	assert.Equal(t, "bob", matches.Wants[0].Offers[1].Username)
+	assert.Equal(t, 1, matches.Wants[1].Missing)
+	assert.Contains(t, client.commands[0], "w.id_player = $1 and i.id_player <> $1 and w.quantity > o.owned and s.spare > 0")
+	assert.Contains(t, client.commands[1], "w.id_player <> $1 and i.id_player = $1 and w.quantity > o.owned and s.spare > 0")
+	assert.Contains(t, client.commands[0], "least(v.quantity, v.total - coalesce(r.reserved, 0)) spare")
+	assert.Equal(t, []interface{}{1}, client.params[1])
+}
@@ -1,12 +1,15 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"github.com/rjansen/fivecolors/api"
 	"github.com/rjansen/fivecolors/config"
+	"github.com/rjansen/fivecolors/data"
+	"github.com/rjansen/fivecolors/security"
 	"github.com/rjansen/l"
-	raizelSQL "github.com/rjansen/raizel/sql"
 	"net/http"
-	//"github.com/rjansen/avalon/identity"
+	"os"
 	// _ "github.com/go-sql-driver/mysql"
 	_ "github.com/lib/pq"
 )
@@ -21,23 +24,37 @@ func init() {
 		panic(err)
 	}
 
-	if err = raizelSQL.Setup(&config.Value.Raizel); err != nil {
+	if err = data.SetupSQL(&config.Value.Raizel); err != nil {
 		l.Panic("5colors.RaizelSetupError", l.Err(err))
 	}
-
-	// if err = identity.Setup(&configuration.Identity); err != nil {
-	// 	l.Panic("IdentitySetupError", l.Err(err))
-	// }
 }
 
 func main() {
-	// http.Handle("/identity/", security.NewIdentityHandler())
-	http.HandleFunc("/api/players/", api.NewAnonPlayerHandler())
-	http.HandleFunc("/api/cards/", api.NewAnonCardHandler())
-	http.HandleFunc("/api/tokens/", api.NewAnonTokenHandler())
-	http.HandleFunc("/api/decks/", api.NewAnonDeckHandler())
-	http.HandleFunc("/api/expansions/", api.NewAnonExpansionHandler())
-	http.HandleFunc("/api/inventories/", api.NewAnonInventoryHandler())
+	if command := map[string]func([]string) error{
+		"import-catalog": importCatalog,
+		"import-prices":  importPrices,
+	}[flag.Arg(0)]; command != nil {
+		if err := command(flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	//Only the server signs session tokens, the import commands run without SESSION_SECRET
+	if err := security.Setup(&config.Value.Security); err != nil {
+		l.Panic("5colors.SecuritySetupError", l.Err(err))
+	}
+	http.HandleFunc("/api/identity/", security.NewIdentityHandler())
+	http.Handle("/api/players/", security.InjectPlayer(api.NewAnonPlayerHandler()))
+	http.Handle("/api/cards/", security.InjectPlayer(api.NewAnonCardHandler()))
+	http.Handle("/api/tokens/", security.InjectPlayer(api.NewAnonTokenHandler()))
+	http.Handle("/api/decks/", security.InjectPlayer(api.NewAnonDeckHandler()))
+	http.Handle("/api/expansions/", security.InjectPlayer(api.NewAnonExpansionHandler()))
+	http.Handle("/api/inventories/", security.InjectPlayer(api.NewAnonInventoryHandler()))
+	http.Handle("/api/math/", security.InjectPlayer(api.NewAnonMathHandler()))
+	http.Handle("/api/trades/", security.InjectPlayer(api.NewAnonTradeHandler()))
+	http.Handle("/api/wishlists/", security.InjectPlayer(api.NewAnonWishlistHandler()))
 	http.Handle("/api/assets/",
 		http.StripPrefix("/api/assets/",
 			http.FileServer(http.Dir(config.Value.AssetDir)),
@@ -0,0 +1,3 @@
+-- Set code of the expansion (e.g. M11, DOM) used by MTG Arena decklists
+alter table expansion add column if not exists code varchar(8);
+create unique index if not exists ux_expansion_code on expansion (upper(code));
@@ -0,0 +1,3 @@
+-- Password hash of the player used by the security login flow
+alter table player add column if not exists password varchar(255);
+alter table player add column if not exists dt_lastlogin timestamp;
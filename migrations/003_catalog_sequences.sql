@@ -0,0 +1,7 @@
+-- Sequences used by the import-catalog command to create expansion, card and token rows
+create sequence if not exists sq_expansion;
+select setval('sq_expansion', greatest((select max(id) from expansion), 1));
+create sequence if not exists sq_card;
+select setval('sq_card', greatest((select max(id) from card), 1));
+create sequence if not exists sq_token;
+select setval('sq_token', greatest((select max(id) from token), 1));
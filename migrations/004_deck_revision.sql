@@ -0,0 +1,17 @@
+-- Immutable deck revisions, one row by Deck.Persist with a copy of the deck_card rows of the save
+create sequence if not exists sq_deck_revision;
+create table if not exists deck_revision (
+    id integer primary key default nextval('sq_deck_revision'),
+    id_deck integer not null references deck (id) on delete cascade,
+    revision integer not null,
+    name varchar(255) not null,
+    dt_created timestamp not null default now(),
+    unique (id_deck, revision)
+);
+create table if not exists deck_revision_card (
+    id_deck_revision integer not null references deck_revision (id) on delete cascade,
+    id_card integer not null,
+    id_board integer not null,
+    quantity integer not null,
+    primary key (id_deck_revision, id_card, id_board)
+);
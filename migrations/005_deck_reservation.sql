@@ -0,0 +1,10 @@
+-- Inventory copies committed to the deck_card entries of built decks
+create table if not exists deck_reservation (
+    id_deck integer not null references deck (id) on delete cascade,
+    id_card integer not null,
+    id_board integer not null,
+    id_inventory integer not null references inventory (id) on delete cascade,
+    quantity integer not null check (quantity > 0),
+    primary key (id_deck, id_card, id_board, id_inventory)
+);
+create index if not exists ix_deck_reservation_inventory on deck_reservation (id_inventory, id_card);
@@ -0,0 +1,8 @@
+-- Finish, condition, language and grading of the inventory copies, the copies are counted by card and attributes
+alter table inventory_card add column if not exists finish varchar(8) not null default 'nonfoil';
+alter table inventory_card add column if not exists condition varchar(3) not null default 'NM';
+alter table inventory_card add column if not exists language varchar(3) not null default 'en';
+alter table inventory_card add column if not exists graded varchar(32) not null default '';
+alter table inventory_card drop constraint if exists inventory_card_pkey;
+create unique index if not exists ux_inventory_card_variant
+    on inventory_card (id_inventory, id_card, finish, condition, language, graded);
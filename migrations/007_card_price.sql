@@ -0,0 +1,11 @@
+-- MTGJSON uuid of the card, it keys the prices of the AllPrices files
+alter table card add column if not exists uuid varchar(36);
+create unique index if not exists ux_card_uuid on card (uuid);
+-- Dated retail price of a card finish loaded by the import-prices command
+create table if not exists card_price (
+    id_card integer not null references card (id) on delete cascade,
+    finish varchar(8) not null,
+    dt_price date not null,
+    price numeric(12, 2) not null check (price >= 0),
+    primary key (id_card, finish, dt_price)
+);
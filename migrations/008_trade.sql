@@ -0,0 +1,37 @@
+-- Trades of inventory copies between two players, a counter proposal is a new trade with the countered parent
+create sequence if not exists sq_trade;
+create table if not exists trade (
+    id integer primary key default nextval('sq_trade'),
+    id_parent integer references trade (id) on delete set null,
+    id_proposer integer not null references player (id) on delete cascade,
+    id_proposer_inventory integer not null references inventory (id) on delete cascade,
+    id_receiver integer not null references player (id) on delete cascade,
+    id_receiver_inventory integer not null references inventory (id) on delete cascade,
+    status varchar(16) not null default 'proposed',
+    message varchar(500) not null default '',
+    dt_created timestamp not null default now(),
+    dt_updated timestamp not null default now()
+);
+create index if not exists ix_trade_proposer on trade (id_proposer, status);
+create index if not exists ix_trade_receiver on trade (id_receiver, status);
+-- The copies offered by the proposer and requested from the receiver
+create table if not exists trade_card (
+    id_trade integer not null references trade (id) on delete cascade,
+    offered boolean not null,
+    id_card integer not null references card (id),
+    finish varchar(8) not null,
+    condition varchar(3) not null,
+    language varchar(3) not null,
+    graded varchar(32) not null default '',
+    quantity integer not null check (quantity > 0),
+    primary key (id_trade, offered, id_card, finish, condition, language, graded)
+);
+-- Every status of a trade with the player who set it
+create table if not exists trade_status (
+    id_trade integer not null references trade (id) on delete cascade,
+    status varchar(16) not null,
+    id_player integer not null,
+    message varchar(500) not null default '',
+    dt_status timestamp not null default now()
+);
+create index if not exists ix_trade_status_trade on trade_status (id_trade, dt_status);
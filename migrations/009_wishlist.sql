@@ -0,0 +1,11 @@
+-- The cards wanted by the players, printing restricts the match to the id_card printing and an empty finish
+-- matches any finish
+create table if not exists wishlist_card (
+    id_player integer not null references player (id) on delete cascade,
+    id_card integer not null references card (id),
+    quantity integer not null check (quantity > 0),
+    finish varchar(8) not null default '',
+    printing boolean not null default false,
+    primary key (id_player, id_card)
+);
+create index if not exists ix_wishlist_card_card on wishlist_card (id_card);
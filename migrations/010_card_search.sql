@@ -0,0 +1,9 @@
+-- Full text search of the cards with the english stemming, the name weights more than the type, the rules text and
+-- the flavor. The configuration must match the data fullTextConfig
+alter table card add column if not exists search_vector tsvector generated always as (
+    setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(type_label, '')), 'B') ||
+    setweight(to_tsvector('english', coalesce(text, '')), 'C') ||
+    setweight(to_tsvector('english', coalesce(flavor, '')), 'D')
+) stored;
+create index if not exists ix_card_search_vector on card using gin (search_vector);
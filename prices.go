@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rjansen/fivecolors/data"
+	"github.com/rjansen/l"
+	"github.com/rjansen/raizel"
+)
+
+//importPrices runs the import-prices command: fivecolors -ecf <config> import-prices <AllPrices.json|prices.csv> [provider]
+//MTGJSON files read the paper retail prices of the provider, tcgplayer by default
+func importPrices(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: fivecolors -ecf <config> import-prices <AllPrices.json|prices.csv> [provider]")
+	}
+	file, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	var (
+		persisted int
+		rejected  int
+	)
+	persist := func(rows []data.PriceRow) error {
+		return raizel.Execute(func(client raizel.Client) error {
+			return data.InTransaction(client, func(tx raizel.Client) error {
+				if err := data.ResolvePrices(tx, rows); err != nil {
+					return err
+				}
+				count, err := data.PersistPrices(tx, rows)
+				if err != nil {
+					return err
+				}
+				persisted += count
+				for _, row := range rows {
+					if row.Reason != "" {
+						rejected++
+						l.Debug("5colors.PriceRejected", l.Int("Line", row.Line), l.String("UUID", row.UUID),
+							l.String("Reason", row.Reason))
+					}
+				}
+				return nil
+			})
+		})
+	}
+	if strings.EqualFold(filepath.Ext(args[0]), ".csv") {
+		rows, readErr := data.ReadPriceCSV(file)
+		if readErr != nil {
+			return readErr
+		}
+		err = persist(rows)
+	} else {
+		var provider string
+		if len(args) > 1 {
+			provider = args[1]
+		}
+		err = data.ReadAllPrices(file, provider, persist)
+	}
+	if err != nil {
+		return err
+	}
+	l.Info("5colors.PricesImported", l.String("File", args[0]), l.Int("Persisted", persisted), l.Int("Rejected", rejected))
+	fmt.Printf("Prices: persisted=%d rejected=%d\n", persisted, rejected)
+	return nil
+}
@@ -1,105 +1,211 @@
 package security
 
 import (
-	// "github.com/rjansen/avalon/identity"
-	"github.com/rjansen/fivecolors/data"
-	// "github.com/rjansen/l"
-	// "github.com/valyala/fasthttp"
 	"net/http"
-	// "strings"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/rjansen/fivecolors/data"
+	haki "github.com/rjansen/haki/http"
+	"github.com/rjansen/l"
+	"github.com/rjansen/raizel"
 )
 
-type PlayerInjectableHandler interface {
-	// identity.AuthenticatableHandler
-	GetPlayer() *data.Player
-	SetPlayer(player *data.Player)
+//tokenFromRequest reads the session token from the Authorization bearer header or from the session cookie
+func tokenFromRequest(r *http.Request) string {
+	if authorization := r.Header.Get("Authorization"); strings.HasPrefix(authorization, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(authorization, "Bearer "))
+	}
+	if Config != nil {
+		if cookie, err := r.Cookie(Config.CookieName); err == nil {
+			return cookie.Value
+		}
+	}
+	return ""
 }
 
-type InjectedPlayerHandler struct {
-	// identity.AuthenticatedHandler
-	player *data.Player
+//InjectPlayer adds the session player, when the request carries a valid token, into the request context
+func InjectPlayer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token := tokenFromRequest(r); token != "" {
+			claims, err := ParseToken(token)
+			if err == nil {
+				r = r.WithContext(NewContext(r.Context(), claims.Player()))
+			} else {
+				l.Info("security.InjectPlayer.InvalidToken", l.String("Path", r.URL.Path), l.Err(err))
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
 }
 
-func (p *InjectedPlayerHandler) SetPlayer(player *data.Player) {
-	p.player = player
+//RequirePlayer injects the session player and rejects with 401 the requests without a valid token
+func RequirePlayer(next http.Handler) http.Handler {
+	return InjectPlayer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, found := FromContext(r.Context()); !found {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	}))
 }
 
-func (p *InjectedPlayerHandler) GetPlayer() *data.Player {
-	return p.player
+//Credentials is the login and register request payload
+type Credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
 }
 
-func NewInjectPlayerHandler(handler PlayerInjectableHandler) identity.AuthenticatableHandler {
-	return &InjectPlayerHandler{PlayerInjectableHandler: handler}
+//Session is the login and register response payload
+type Session struct {
+	Player *data.Player `json:"player"`
+	Token  string       `json:"token"`
 }
 
-type InjectPlayerHandler struct {
-	PlayerInjectableHandler
+//NewIdentityHandler creates a new IdentityHandler instance
+func NewIdentityHandler() http.HandlerFunc {
+	var identityHandler IdentityHandler
+	return InjectPlayer(haki.Handler(haki.Log(haki.Error(identityHandler.ServeHTTP)))).ServeHTTP
 }
 
-func (handler *InjectPlayerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	// serveUnauthorizedResult := func(w http.ResponseWriter, r *http.Request) {
-	// 	log.Println("security.UnauthorizedRequest: Message[401 StatusUnauthorized]")
-	// 	w.WriteHeader(http.StatusUnauthorized)
-	// }
-	// session := handler.GetSession()
-	// player := &data.Player{}
-	// if err := player.FillFromSession(session); err != nil {
-	// 	log.Printf("security.InjectPlayerHandler.ErrorFillingSession: Err=%v", err.Error())
-	// 	serveUnauthorizedResult(w, r)
-	// } else {
-	// 	handler.PlayerInjectableHandler.SetPlayer(player)
-	// 	handler.PlayerInjectableHandler.ServeHTTP(w, r)
-	// }
+//IdentityHandler is the handler for the login, register, logout and current player requests
+type IdentityHandler struct{}
+
+func (h IdentityHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) error {
+	basePath, lastPath := path.Split(r.URL.Path)
+	l.Debug("IdentityHandler.ServeHTTP",
+		l.String("Method", r.Method),
+		l.String("Path", r.URL.Path),
+		l.String("BasePath", basePath),
+		l.String("LastPath", lastPath),
+	)
+	switch r.Method {
+	case "GET":
+		if lastPath == "" || lastPath == "identity" {
+			return h.Read(w, r)
+		}
+	case "POST":
+		switch lastPath {
+		case "login":
+			return h.Login(w, r)
+		case "register":
+			return h.Register(w, r)
+		case "logout":
+			return h.Logout(w, r)
+		}
+	}
+	return haki.Status(w, http.StatusMethodNotAllowed)
 }
 
-func (handler InjectPlayerHandler) HandleRequest(ctx *fasthttp.RequestCtx) {
-
+//Read returns the player of the current session
+func (h IdentityHandler) Read(w http.ResponseWriter, r *http.Request) error {
+	sessionPlayer, found := FromContext(r.Context())
+	if !found {
+		return haki.Status(w, http.StatusUnauthorized)
+	}
+	player, err := data.GetPlayer(sessionPlayer.Username)
+	if err != nil {
+		if err == raizel.ErrNotFound {
+			return haki.Status(w, http.StatusUnauthorized)
+		}
+		l.Error("IdentityHandler.ReadErr", l.String("Username", sessionPlayer.Username), l.Err(err))
+		return haki.Err(w, err)
+	}
+	return haki.JSON(w, http.StatusOK, player)
 }
 
-//NewIdentityHandler creates a new GetPlayerHandler instance
-func NewIdentityHandler() http.Handler {
-	return identity.NewHeaderAuthenticatedHandler(&IdentityHandler{})
+//Login checks the credentials against the player table and starts a new session
+func (h IdentityHandler) Login(w http.ResponseWriter, r *http.Request) error {
+	var credentials Credentials
+	if err := haki.ReadJSON(r, &credentials); err != nil {
+		return haki.Status(w, http.StatusBadRequest)
+	}
+	l.Info("IdentityHandler.Login", l.String("Username", credentials.Username))
+	var player data.Player
+	player.Username = strings.TrimSpace(credentials.Username)
+	if player.Username == "" || credentials.Password == "" {
+		return haki.Status(w, http.StatusBadRequest)
+	}
+	err := raizel.Execute(func(client raizel.Client) error {
+		if err := player.ReadPassword(client); err != nil {
+			if err == raizel.ErrNotFound {
+				return ErrInvalidCredentials
+			}
+			return err
+		}
+		if err := CheckPassword(player.Password, credentials.Password); err != nil {
+			return err
+		}
+		if err := player.ReadByUsername(client); err != nil {
+			return err
+		}
+		return player.Persist(client)
+	})
+	if err != nil {
+		if err == ErrInvalidCredentials {
+			l.Info("IdentityHandler.Login.Unauthorized", l.String("Username", player.Username))
+			return haki.Status(w, http.StatusUnauthorized)
+		}
+		return haki.Err(w, err)
+	}
+	return h.startSession(w, http.StatusOK, &player)
 }
 
-//IdentityHandler is the handler to get Players
-type IdentityHandler struct {
-	identity.AuthenticatedHandler
+//Register creates a new player with the provided credentials and starts a new session
+func (h IdentityHandler) Register(w http.ResponseWriter, r *http.Request) error {
+	var credentials Credentials
+	if err := haki.ReadJSON(r, &credentials); err != nil {
+		return haki.Status(w, http.StatusBadRequest)
+	}
+	l.Info("IdentityHandler.Register", l.String("Username", credentials.Username))
+	var player data.Player
+	player.Username = strings.TrimSpace(credentials.Username)
+	if player.Username == "" || len(credentials.Password) < 8 {
+		return haki.Status(w, http.StatusBadRequest)
+	}
+	var err error
+	if player.Password, err = HashPassword(credentials.Password); err != nil {
+		return haki.Err(w, err)
+	}
+	if err = raizel.Execute(player.Register); err != nil {
+		if err == data.ErrPlayerExists {
+			return haki.Status(w, http.StatusConflict)
+		}
+		return haki.Err(w, err)
+	}
+	return h.startSession(w, http.StatusCreated, &player)
 }
 
-func (h IdentityHandler) HandleRequest(ctx *fasthttp.RequestCtx) {
-
+//Logout expires the session cookie
+func (h IdentityHandler) Logout(w http.ResponseWriter, r *http.Request) error {
+	http.SetCookie(w, &http.Cookie{
+		Name:     Config.CookieName,
+		Value:    "",
+		Domain:   Config.CookieDomain,
+		Path:     Config.CookiePath,
+		Expires:  time.Unix(0, 0),
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   Config.CookieSecure,
+	})
+	return haki.Status(w, http.StatusNoContent)
 }
 
-func (h *IdentityHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	// urlPathParameters := strings.Split(r.URL.Path, "/")
-	// queryParameters := r.URL.Query()
-	// logger.Infof("IdentityHandler: URL[%q] PathParameters[%q] QueryParameters[%q]", r.URL.Path, urlPathParameters, queryParameters)
-
-	// session := h.GetSession()
-	// if strings.TrimSpace(session.Username) == "" {
-	// 	http.NotFound(w, r)
-	// 	return
-	// }
-	// player, err := data.GetPlayer(session.Username)
-	// if err != nil {
-	// 	logger.Errorf("IdentityHandler.GetPlayerError: Session.ID[%v] Player.Username[%v] Error[%v]", session.ID, session.Username, err.Error())
-	// 	http.NotFound(w, r)
-	// 	//http.Error(w, err.Error(), http.StatusInternalServerError)
-	// 	return
-	// }
-
-	// session.Data = player.SessionData()
-
-	// w.Header().Set("Content-Type", "application/octet-stream")
-	// w.WriteHeader(http.StatusOK)
-
-	// jwtBytes, err := identity.Serialize(*session)
-	// bytesWritten, err := w.Write(jwtBytes)
-	// if err != nil {
-	// 	logger.Errorf("IdentityHandler.WriteResponseError: Player[%v] Error[%v]", player, err)
-	// 	http.Error(w, err.Error(), http.StatusInternalServerError)
-	// } else {
-	// 	logger.Debugf("IdentityHandler.JwtWritten: Player[%v] Bytes[%v]", player, bytesWritten)
-	// }
-
+func (h IdentityHandler) startSession(w http.ResponseWriter, status int, player *data.Player) error {
+	token, err := NewToken(player)
+	if err != nil {
+		return haki.Err(w, err)
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     Config.CookieName,
+		Value:    token,
+		Domain:   Config.CookieDomain,
+		Path:     Config.CookiePath,
+		MaxAge:   int(Config.TTL.Seconds()),
+		HttpOnly: true,
+		Secure:   Config.CookieSecure,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return haki.JSON(w, status, Session{Player: player, Token: token})
 }
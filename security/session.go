@@ -0,0 +1,201 @@
+package security
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rjansen/fivecolors/data"
+)
+
+type contextKey int
+
+const (
+	playerContextKey contextKey = iota
+
+	passwordScheme     = "pbkdf2-sha256"
+	passwordIterations = 120000
+	passwordKeyLength  = 32
+	passwordSaltLength = 16
+)
+
+var (
+	//ErrInvalidToken is raised when the session token is malformed, tampered or expired
+	ErrInvalidToken = errors.New("security.InvalidTokenErr: Message='Session token is invalid or expired'")
+	//ErrInvalidCredentials is raised when the username or password does not match
+	ErrInvalidCredentials = errors.New("security.InvalidCredentialsErr: Message='Username or password is invalid'")
+	//ErrInvalidConfig is raised when Setup is called without a secret
+	ErrInvalidConfig = errors.New("security.InvalidConfigErr: Message='Security.Secret is empty'")
+
+	tokenHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	//Config is the currently security configuration
+	Config *Configuration
+)
+
+//Configuration holds the session token parameters
+type Configuration struct {
+	Secret       string        `mapstructure:"secret"`
+	CookieName   string        `mapstructure:"cookieName"`
+	CookieDomain string        `mapstructure:"cookieDomain"`
+	CookiePath   string        `mapstructure:"cookiePath"`
+	CookieSecure bool          `mapstructure:"cookieSecure"`
+	TTL          time.Duration `mapstructure:"ttl"`
+}
+
+func (c Configuration) String() string {
+	return fmt.Sprintf("security.Configuration SecretIsEmpty=%t CookieName=%s CookieDomain=%s CookiePath=%s CookieSecure=%t TTL=%s",
+		c.Secret == "", c.CookieName, c.CookieDomain, c.CookiePath, c.CookieSecure, c.TTL,
+	)
+}
+
+//Setup initializes the package
+func Setup(cfg *Configuration) error {
+	if cfg == nil || strings.TrimSpace(cfg.Secret) == "" {
+		return ErrInvalidConfig
+	}
+	if cfg.CookieName == "" {
+		cfg.CookieName = "FIVECOLORS_ID"
+	}
+	if cfg.CookiePath == "" {
+		cfg.CookiePath = "/"
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = 24 * time.Hour
+	}
+	Config = cfg
+	return nil
+}
+
+//Claims is the payload of the signed session token
+type Claims struct {
+	Subject     string `json:"sub"`
+	ID          int    `json:"id"`
+	IDInventory int    `json:"idInventory"`
+	IssuedAt    int64  `json:"iat"`
+	ExpiresAt   int64  `json:"exp"`
+}
+
+//Player returns the session player
+func (c Claims) Player() *data.Player {
+	return &data.Player{ID: c.ID, Username: c.Subject, IDInventory: c.IDInventory}
+}
+
+func sign(unsigned string) string {
+	mac := hmac.New(sha256.New, []byte(Config.Secret))
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+//NewToken creates a HS256 JWT for the player valid for Configuration.TTL
+func NewToken(player *data.Player) (string, error) {
+	if Config == nil {
+		return "", ErrInvalidConfig
+	}
+	now := time.Now()
+	claims := Claims{
+		Subject:     player.Username,
+		ID:          player.ID,
+		IDInventory: player.IDInventory,
+		IssuedAt:    now.Unix(),
+		ExpiresAt:   now.Add(Config.TTL).Unix(),
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	unsigned := tokenHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + sign(unsigned), nil
+}
+
+//ParseToken validates the token signature and expiration and returns its claims
+func ParseToken(token string) (*Claims, error) {
+	if Config == nil {
+		return nil, ErrInvalidConfig
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != tokenHeader {
+		return nil, ErrInvalidToken
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(sign(parts[0]+"."+parts[1]))) {
+		return nil, ErrInvalidToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if claims.ID <= 0 || claims.Subject == "" || time.Now().Unix() >= claims.ExpiresAt {
+		return nil, ErrInvalidToken
+	}
+	return &claims, nil
+}
+
+//HashPassword creates a salted PBKDF2 hash in the pbkdf2-sha256$iterations$salt$key form
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, passwordSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, passwordIterations, passwordKeyLength)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join([]string{
+		passwordScheme,
+		strconv.Itoa(passwordIterations),
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	}, "$"), nil
+}
+
+//CheckPassword compares the password with a hash created by HashPassword
+func CheckPassword(hash, password string) error {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 4 || parts[0] != passwordScheme {
+		return ErrInvalidCredentials
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return ErrInvalidCredentials
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return ErrInvalidCredentials
+	}
+	expected, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return ErrInvalidCredentials
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, iterations, len(expected))
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare(key, expected) != 1 {
+		return ErrInvalidCredentials
+	}
+	return nil
+}
+
+//NewContext returns a copy of the context carrying the session player
+func NewContext(ctx context.Context, player *data.Player) context.Context {
+	return context.WithValue(ctx, playerContextKey, player)
+}
+
+//FromContext returns the session player injected by the security handlers
+func FromContext(ctx context.Context) (*data.Player, bool) {
+	player, ok := ctx.Value(playerContextKey).(*data.Player)
+	return player, ok && player != nil
+}
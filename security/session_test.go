@@ -0,0 +1,71 @@
+package security_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rjansen/fivecolors/data"
+	"github.com/rjansen/fivecolors/security"
+	"github.com/stretchr/testify/assert"
+)
+
+func setup(ttl time.Duration) {
+	security.Setup(&security.Configuration{Secret: "Test_Secret", TTL: ttl})
+}
+
+func Test_TokenRoundTrip(t *testing.T) {
+	setup(time.Hour)
+	token, err := security.NewToken(&data.Player{ID: 7, Username: "dummyuser", IDInventory: 3})
+	assert.Nil(t, err)
+	claims, err := security.ParseToken(token)
+	assert.Nil(t, err)
+	assert.Equal(t, &data.Player{ID: 7, Username: "dummyuser", IDInventory: 3}, claims.Player())
+}
+
+func Test_TokenTamperedAndExpired(t *testing.T) {
+	setup(time.Hour)
+	token, err := security.NewToken(&data.Player{ID: 7, Username: "dummyuser"})
+	assert.Nil(t, err)
+	parts := strings.Split(token, ".")
+	forged, _ := security.NewToken(&data.Player{ID: 1, Username: "admin"})
+	_, err = security.ParseToken(parts[0] + "." + strings.Split(forged, ".")[1] + "." + parts[2])
+	assert.Equal(t, security.ErrInvalidToken, err)
+
+	security.Config.TTL = -time.Hour
+	expired, err := security.NewToken(&data.Player{ID: 7, Username: "dummyuser"})
+	assert.Nil(t, err)
+	_, err = security.ParseToken(expired)
+	assert.Equal(t, security.ErrInvalidToken, err)
+}
+
+func Test_Password(t *testing.T) {
+	hash, err := security.HashPassword("five colors")
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(hash, "pbkdf2-sha256$"))
+	assert.Nil(t, security.CheckPassword(hash, "five colors"))
+	assert.Equal(t, security.ErrInvalidCredentials, security.CheckPassword(hash, "four colors"))
+}
+
+func Test_RequirePlayer(t *testing.T) {
+	setup(time.Hour)
+	var injected *data.Player
+	handler := security.RequirePlayer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		injected, _ = security.FromContext(r.Context())
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/api/decks/", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Nil(t, injected)
+
+	token, _ := security.NewToken(&data.Player{ID: 7, Username: "dummyuser"})
+	req := httptest.NewRequest("GET", "/api/decks/", nil)
+	req.AddCookie(&http.Cookie{Name: security.Config.CookieName, Value: token})
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "dummyuser", injected.Username)
+}
@@ -1,3 +1,3 @@
-webpackJsonp([2],{1019:function(t,e,n){var s=n(742);"string"==typeof s?t.exports=s:t.exports=s.toString()},1020:function(t,e,n){var s=n(743);"string"==typeof s?t.exports=s:t.exports=s.toString()},1021:function(t,e,n){var s=n(744);"string"==typeof s?t.exports=s:t.exports=s.toString()},1022:function(t,e,n){var s=n(745);"string"==typeof s?t.exports=s:t.exports=s.toString()},1023:function(t,e,n){var s=n(746);"string"==typeof s?t.exports=s:t.exports=s.toString()},1024:function(t,e,n){var s=n(747);"string"==typeof s?t.exports=s:t.exports=s.toString()},1025:function(t,e,n){var s=n(748);"string"==typeof s?t.exports=s:t.exports=s.toString()},1026:function(t,e,n){"use strict";function s(){return n.i(a.a)().bootstrapModule(o.a).then(i.a).catch(function(t){return console.error(t)})}Object.defineProperty(e,"__esModule",{value:!0});var a=n(446),i=n(287),r=n(288),o=(n.n(r),n(447));e.main=s,n.i(r.bootloader)(s)},174:function(t,e,n){"use strict";var s=n(1);n.d(e,"a",function(){return a});var a=function(){function t(){this._state={}}return Object.defineProperty(t.prototype,"state",{get:function(){return this._state=this._clone(this._state)},set:function(t){throw new Error("do not mutate the `.state` directly")},enumerable:!0,configurable:!0}),t.prototype.get=function(t){var e=this.state;return e.hasOwnProperty(t)?e[t]:e},t.prototype.set=function(t,e){return this._state[t]=e},t.prototype._clone=function(t){return JSON.parse(JSON.stringify(t))},t}();a=__decorate([n.i(s.p)()],a)},175:function(t,e,n){"use strict";var s=n(1),a=n(78),i=n(0),r=(n.n(i),n(133)),o=(n.n(r),n(80));n.d(e,"a",function(){return c}),n.d(e,"b",function(){return d});var c=function(){function t(t,e){this.urls=t,this.http=e}return t.prototype.ngOnInit=function(){},t.prototype.searchCards=function(t){if(console.log("CardService.searchCards parameter="+JSON.stringify(t)),t.isMock)return console.log("MockServer"),i.Observable.create([{id:5118,name:"Mind Rot",label:"Mind Rot - (3.480/50)",manacostLabel:"2, Black",text:"Target player discards two cards."},{id:9266,name:"Read the Bones",label:"Read the Bones - (3.542/96)",manacostLabel:"2, Black",text:"Scry 2, then draw two cards. You lose 2 life. <i>(To scry 2, look at the top two cards of your library, then put any number of them on the bottom of your library and the rest on top in any order.)</i>"}]);var e=[];if(void 0!=t.stockQuantity&&t.stockQuantity>=0&&e.push("q="+t.stockQuantity),void 0!=t.expansion&&t.expansion.id>0&&e.push("e="+t.expansion.id),void 0!=t.index&&""!=t.index&&e.push("n="+t.index),void 0!=t.name&&""!=t.name&&e.push("rx_name="+t.name),void 0!=t.type&&""!=t.type&&e.push("rx_type="+t.type),void 0!=t.cost&&""!=t.cost&&e.push("rx_cost="+t.cost),void 0!=t.text&&""!=t.text&&e.push("rx_text="+t.text),e.length<=0)throw new Error("CardSearchEmptyParameters");var n=this.urls.cards+"query/?"+e.join("&");return this.http.get(n).map(function(t){return t.json()}).catch(this.handleError)},t.prototype.handleError=function(t){return console.error(t),i.Observable.throw(t.json().error||"ServerError")},t}();c=__decorate([n.i(s.p)(),__metadata("design:paramtypes",[o.a,a.b])],c);var d;(function(t){t[t.Main=1]="Main",t[t.Side=2]="Side"})(d||(d={}))},176:function(t,e,n){"use strict";var s=n(1),a=n(78),i=n(0),r=(n.n(i),n(133)),o=(n.n(r),n(80));n.d(e,"a",function(){return c});var c=function(){function t(t,e){this.urls=t,this.http=e}return t.prototype.ngOnInit=function(){},t.prototype.listExpansions=function(t){if(t.isMock)return console.log("MockServer"),i.Observable.create([{id:3,name:"Dark Ascension",label:"Dark Ascension - (2/171)",idAsset:21},{id:19,name:"Innistrad",label:"Innistrad - (3/274)",idAsset:2786}]);var e=this.urls.expansions;return this.http.get(e).map(function(t){return t.json()}).catch(this.handleError)},t.prototype.handleError=function(t){return console.error(t),i.Observable.throw(t.json().error||"ServerError")},t}();c=__decorate([n.i(s.p)(),__metadata("design:paramtypes",[o.a,a.b])],c)},250:function(t,e,n){"use strict";var s=n(1),a=n(78),i=n(0),r=(n.n(i),n(133)),o=(n.n(r),n(80));n.d(e,"a",function(){return c});var c=function(){function t(t,e){this.urls=t,this.http=e,this.session=null,this.player=null}return t.prototype.loadSession=function(t){var e=this;void 0===t&&(t=null);var n=new a.c({"Content-Type":"application/json"}),s=new a.d({headers:n});this.http.get(this.urls.sessions,s).catch(this.handleError).subscribe(function(n){return e.applySession(n,t)})},t.prototype.applySession=function(t,e){if(void 0===e&&(e=null),200!=t.status)throw Error("ErrorLoadingSession: SessionURL="+this.urls.sessions);this.session=t.json(),null!=e&&e(this.session)},t.prototype.loadPlayer=function(t){var e=this;void 0===t&&(t=null);var n=new a.c({"Content-Type":"application/json"}),s=new a.d({headers:n});this.http.get(this.urls.players,s).catch(this.handleError).subscribe(function(n){return e.applyPlayer(n,t)})},t.prototype.applyPlayer=function(t,e){if(void 0===e&&(e=null),200!=t.status)throw Error("ErrorLoadingPlayer: PlayerURL="+this.urls.players);this.player=t.json(),null!=e&&e(this.player)},t.prototype.handleError=function(t){return console.error(t),i.Observable.throw(t.json().error||"ServerError")},t}();c=__decorate([n.i(s.p)(),__metadata("design:paramtypes",[o.a,a.b])],c)},287:function(t,e,n){"use strict";var s=n(121),a=n(1);n.d(e,"a",function(){return o}),n.d(e,"b",function(){return c});var i=[],r=function(t){return t};n.i(a.a)(),r=function(t){return n.i(s.a)(),t},i=i.slice();var o=r,c=i.slice()},372:function(t,e,n){"use strict";var s=n(577);n.d(e,"a",function(){return s.a})},373:function(t,e,n){"use strict";var s=n(587);n.d(e,"a",function(){return s.a})},374:function(t,e,n){"use strict";var s=n(589);n.d(e,"a",function(){return s.a})},375:function(t,e,n){"use strict";var s=n(594);n.d(e,"a",function(){return s.a})},376:function(t,e,n){"use strict";var s=n(1);n.d(e,"a",function(){return a});var a=function(){function t(){}return t.prototype.transform=function(t,e,n){return void 0===n&&(n=null),t.filter(function(t){var s=t,a=e.split(".");return a.forEach(function(t){return void 0!=s&&void(s=s[t])}),s==n})},t}();a=__decorate([n.i(s.o)({name:"filter",pure:!1})],a)},377:function(t,e,n){"use strict";var s=n(376),a=n(378);n.d(e,"b",function(){return a.a}),n.d(e,"a",function(){return i});var i=[s.a,a.a]},378:function(t,e,n){"use strict";var s=n(1);n.d(e,"a",function(){return a});var a=function(){function t(){}return t.prototype.transform=function(t,e){return void 0===e&&(e=null),Object.keys(t).sort().map(function(e){return t[e]})},t}();a=__decorate([n.i(s.o)({name:"values",pure:!1})],a)},379:function(t,e,n){"use strict";var s=n(1),a=n(78),i=n(0),r=(n.n(i),n(133)),o=(n.n(r),n(80)),c=n(175),d=n(176),l=n(250);n.d(e,"a",function(){return u});var u=function(){function t(t,e,n,s,a){this.urls=t,this.http=e,this.sessionService=n,this.cardService=s,this.expansionService=a}return t.prototype.ngOnInit=function(){},t.prototype.listExpansions=function(t){return this.expansionService.listExpansions(t)},t.prototype.searchCards=function(t){return this.cardService.searchCards(t)},t.prototype.updateDeck=function(t){var e=JSON.stringify(t),n=new a.c({"Content-Type":"application/json"}),s=new a.d({headers:n});return this.http.post(this.urls.decks,e,s).catch(this.handleError)},t.prototype.deleteDeck=function(t){var e=new a.c({Accept:"application/json"}),n=new a.d({headers:e});return this.http.delete(this.urls.decks,n).catch(this.handleError)},t.prototype.findDeck=function(t){if(void 0==t||t<=0)throw Error("DeckFindRequiredFieldsError: DeckId="+t);var e=new a.c({Accept:"application/json"}),n=new a.d({headers:e});return this.http.get(this.urls.decks+t,n).map(function(t){return t.json()}).catch(this.handleError)},t.prototype.findDeckByName=function(t){if(void 0==t||""==t)throw Error("DeckFindRequiredFieldsError: DeckName="+t);var e=new a.c({"Content-Type":"application/json"}),n=new a.d({headers:e});return this.http.get(this.urls.decks+encodeURIComponent(t),n).map(function(t){return t.json()}).catch(this.handleError)},t.prototype.listDeck=function(t){if(void 0==t)throw Error("DeckListRequiredFieldsError: DeckNameRx="+t);var e=new a.c({Accpet:"application/json"}),n=new a.d({headers:e});return this.http.get(this.urls.decks+("query/?rx_name="+encodeURIComponent(t)),n).map(function(t){return t.json()}).catch(this.handleError)},t.prototype.handleError=function(t){return console.error(t),i.Observable.throw(t.json().error||"ServerError")},t}();u=__decorate([n.i(s.p)(),__metadata("design:paramtypes",[o.a,a.b,l.a,c.a,d.a])],u)},380:function(t,e,n){"use strict";var s=n(1),a=n(78),i=n(0),r=(n.n(i),n(133)),o=(n.n(r),n(80)),c=n(175),d=n(176);n.d(e,"a",function(){return l});var l=function(){function t(t,e,n,s){this.urls=t,this.http=e,this.cardService=n,this.expansionService=s}return t.prototype.ngOnInit=function(){},t.prototype.listExpansions=function(t){return this.expansionService.listExpansions(t)},t.prototype.searchCards=function(t){return this.cardService.searchCards(t)},t.prototype.updateInventory=function(t){var e=JSON.stringify(t),n=new a.c({"Content-Type":"application/json"}),s=new a.d({headers:n});return this.http.post(this.urls.inventories,e,s).catch(this.handleError)},t.prototype.handleError=function(t){return console.error(t),i.Observable.throw(t.json().error||"ServerError")},t}();l=__decorate([n.i(s.p)(),__metadata("design:paramtypes",[o.a,a.b,c.a,d.a])],l)},445:function(t,e){function n(t){throw new Error("Cannot find module '"+t+"'.")}n.keys=function(){return[]},n.resolve=n,t.exports=n,n.id=445},447:function(t,e,n){"use strict";var s=n(579);n.d(e,"a",function(){return s.a})},577:function(t,e,n){"use strict";var s=n(1),a=n(366);n.d(e,"a",function(){return i}),console.log("`About` component loaded asynchronously");var i=function(){function t(t){this.route=t}return t.prototype.ngOnInit=function(){var t=this;this.route.data.subscribe(function(e){t.localState=e.yourData}),console.log("hello `About` component"),this.asyncDataWithWebpack()},t.prototype.asyncDataWithWebpack=function(){var t=this;setTimeout(function(){n.e(0).then(n.bind(null,1028)).then(function(e){console.log("async mockData",e),t.localState=e})})},t}();i=__decorate([n.i(s._4)({selector:"about",styles:["\n  "],template:"\n    <h1>About</h1>\n    <div>\n      For hot module reloading run\n      <pre>npm run start:hmr</pre>\n    </div>\n    <div>\n      <h3>\n        patrick@AngularClass.com\n      </h3>\n    </div>\n    <pre>this.localState = {{ localState | json }}</pre>\n  "}),__metadata("design:paramtypes",[a.c])],i)},578:function(t,e,n){"use strict";var s=n(1),a=n(174);n.d(e,"a",function(){return i});var i=function(){function t(t){this.appState=t,this.angularclassLogo="assets/img/angularclass-avatar.png",this.name="Angular 2 Webpack Starter",this.url="https://twitter.com/AngularClass"}return t.prototype.ngOnInit=function(){console.log("Initial App State",this.appState.state)},t}();i=__decorate([n.i(s._4)({selector:"app",encapsulation:s.O.None,styles:[n(1019)],template:'\n    <nav>\n      <a [routerLink]=" [\'./\'] " routerLinkActive="active">\n        Index\n      </a>\n      <a [routerLink]=" [\'./home\'] " routerLinkActive="active">\n        Home\n      </a>\n      <a [routerLink]=" [\'./detail\'] " routerLinkActive="active">\n        Detail\n      </a>\n      <a [routerLink]=" [\'./barrel\'] " routerLinkActive="active">\n        Barrel\n      </a>\n      <a [routerLink]=" [\'./about\'] " routerLinkActive="active">\n        About\n      </a>\n    </nav>\n\n    <main>\n      <router-outlet></router-outlet>\n    </main>\n\n    <pre class="app-state">this.appState.state = {{ appState.state | json }}</pre>\n\n    <footer>\n      <span>WebPack Angular 2 Starter by <a [href]="url">@AngularClass</a></span>\n      <div>\n        <a [href]="url">\n          <img [src]="angularclassLogo" width="25%">\n        </a>\n      </div>\n    </footer>\n  '}),__metadata("design:paramtypes",[a.a])],i)},579:function(t,e,n){"use strict";var s=n(121),a=n(542),i=n(78),r=n(1),o=n(366),c=n(288),d=(n.n(c),n(287)),l=n(581),u=n(97),p=n(377),h=n(588),m=n(375),g=n(373),f=n(586),v=n(578),y=n(580),b=n(174),k=n(374),x=n(372),R=n(595),I=n(592);n.d(e,"a",function(){return C});var w=y.a.concat(u.a,[b.a]),C=function(){function t(t,e){this.appRef=t,this.appState=e}return t.prototype.hmrOnInit=function(t){if(t&&t.state){if(console.log("HMR store",JSON.stringify(t,null,2)),this.appState._state=t.state,"restoreInputValues"in t){var e=t.restoreInputValues;setTimeout(e)}this.appRef.tick(),delete t.state,delete t.restoreInputValues}},t.prototype.hmrOnDestroy=function(t){var e=this.appRef.components.map(function(t){return t.location.nativeElement}),s=this.appState._state;t.state=s,t.disposeOldHosts=n.i(c.createNewHosts)(e),t.restoreInputValues=n.i(c.createInputTransfer)(),n.i(c.removeNgStyles)()},t.prototype.hmrAfterDestroy=function(t){t.disposeOldHosts(),delete t.disposeOldHosts},t}();C=__decorate([n.i(r.i)({bootstrap:[h.a],declarations:[h.a,f.a,m.a,g.a,f.b].concat(p.a,[v.a,x.a,k.a,R.a,I.a]),imports:[s.b,a.a,i.a,o.a.forRoot(l.a,{useHash:!0,preloadingStrategy:o.b})],providers:[d.b,w]}),__metadata("design:paramtypes",[r.K,b.a])],C)},580:function(t,e,n){"use strict";var s=n(1),a=n(0),i=(n.n(a),n(418));n.n(i);n.d(e,"a",function(){return o});var r=function(){function t(){}return t.prototype.resolve=function(t,e){return a.Observable.of({res:"I am data"})},t}();r=__decorate([n.i(s.p)()],r);var o=[r]},581:function(t,e,n){"use strict";var s=n(374),a=n(372),i=n(375),r=n(373);n.d(e,"a",function(){return o});var o=[{path:"",component:r.a},{path:"home",component:s.a},{path:"about",component:a.a},{path:"inventory",component:i.a},{path:"deck",component:r.a},{path:"**",component:r.a}]},582:function(t,e,n){"use strict";var s=n(1),a=n(97),i=n(1018),r=(n.n(i),n(598));n.n(r);n.d(e,"a",function(){return c});var o=n(272),c=function(){function t(){this.decreaseOn=!1,this.increaseOn=!1,this.removeOn=!1,this.changeBoardOn=!1,this.showPopOn=!0,this.shoppingOn=!0,this.onDecrease=new s.G,this.onIncrease=new s.G,this.onRemove=new s.G,this.onChangeBoard=new s.G,this.onShowPop=new s.G,this.onHidePop=new s.G,this.onShopping=new s.G,this.state={popOpen:!1},null==this.componentId&&(this.componentId=n.i(a.b)())}return t.prototype.ngOnInit=function(){},t.prototype.getComponentLabel=function(){return null!=this.label?this.label:null==this.card.deckCard?this.card.inventoryCard.quantity.toString():this.card.inventoryCard.quantity<=0||this.card.inventoryCard.quantity>=this.card.deckCard.quantity?this.card.deckCard.quantity.toString():this.card.inventoryCard.quantity+"/"+this.card.deckCard.quantity},t.prototype.decrease=function(){this.onDecrease.emit(this.card)},t.prototype.increase=function(){this.onIncrease.emit(this.card)},t.prototype.remove=function(){this.onRemove.emit(this.card)},t.prototype.changeBoard=function(){this.onChangeBoard.emit(this.card)},t.prototype.showPop=function(){var t=o("#"+this.componentId);if(this.state.popOpen)return t.popover("dispose"),void(this.state.popOpen=!1);var e=t.offset(),n="left",s="0 0";e.top>370?(n="top",e.left<100?s="0 -30px":e.left>1e3&&(s="0 25px")):e.left<300&&(n="right"),t=t.popover({template:'<div class="popover" role="tooltip">\n                    <div class="popover-arrow"></div>\n                    <div class="card-header card-pop-header text-xs-center">\n                        <span class="searchResultsItemTitle"><strong class="popover-title" style="padding: 0px; border: none;"></strong></span>\n                        <img src="/api/assets/'+this.card.expansion.idAsset+'" style="height: 18px; vertical-align: middle;" />\n                    </div>\n                    <div class="popover-content"></div>\n                </div>',content:'<img class="card-img-large" src="/api/assets/'+this.card.idAsset+'" />',trigger:"manual",html:!0,placement:n,offset:s,title:this.card.name}),t.popover("show"),this.state.popOpen=!0,this.onShowPop.emit(this.card)},t.prototype.hidePop=function(){var t=o("#"+this.componentId);t.popover("dispose"),this.state.popOpen=!1,this.onHidePop.emit(this.card)},t.prototype.shopping=function(){console.log("Card.shopping id='"+this.componentId+"' cardId="+this.card.id+" name='"+this.card.name+"'");var t=window.open("http://ligamagic.com.br/?view=cards%2Fsearch&card="+encodeURIComponent(this.card.name).replace(/'/g,"%27"),"_blank");t.focus(),this.onShopping.emit(this.card)},t}();__decorate([n.i(s.w)(),__metadata("design:type",Object)],c.prototype,"card",void 0),__decorate([n.i(s.w)(),__metadata("design:type",String)],c.prototype,"label",void 0),__decorate([n.i(s.w)(),__metadata("design:type",String)],c.prototype,"componentId",void 0),__decorate([n.i(s.w)(),__metadata("design:type",Boolean)],c.prototype,"decreaseOn",void 0),__decorate([n.i(s.w)(),__metadata("design:type",Boolean)],c.prototype,"increaseOn",void 0),__decorate([n.i(s.w)(),__metadata("design:type",Boolean)],c.prototype,"removeOn",void 0),__decorate([n.i(s.w)(),__metadata("design:type",Boolean)],c.prototype,"changeBoardOn",void 0),__decorate([n.i(s.w)(),__metadata("design:type",Boolean)],c.prototype,"showPopOn",void 0),__decorate([n.i(s.w)(),__metadata("design:type",Boolean)],c.prototype,"shoppingOn",void 0),__decorate([n.i(s._1)(),__metadata("design:type",Object)],c.prototype,"onDecrease",void 0),__decorate([n.i(s._1)(),__metadata("design:type",Object)],c.prototype,"onIncrease",void 0),__decorate([n.i(s._1)(),__metadata("design:type",Object)],c.prototype,"onRemove",void 0),__decorate([n.i(s._1)(),__metadata("design:type",Object)],c.prototype,"onChangeBoard",void 0),__decorate([n.i(s._1)(),__metadata("design:type",Object)],c.prototype,"onShowPop",void 0),__decorate([n.i(s._1)(),__metadata("design:type",Object)],c.prototype,"onHidePop",void 0),__decorate([n.i(s._1)(),__metadata("design:type",Object)],c.prototype,"onShopping",void 0),c=__decorate([n.i(s._4)({selector:"card",template:n(749),styles:[n(1020)]}),__metadata("design:paramtypes",[])],c)},583:function(t,e,n){"use strict";var s=n(582);n.d(e,"a",function(){return s.a})},584:function(t,e,n){"use strict";var s=n(1),a=n(97);n.d(e,"a",function(){return i});var i=function(){function t(t){this.inventoryService=t,this.showResults=!1,this.onResult=new s.G,this.onNewPageResult=new s.G,this.parameter={index:void 0,expansion:void 0,text:void 0,cost:void 0,type:void 0,name:void 0,stockQuantity:void 0,isMock:!1},this.model={querying:!1,searchResultsList:[],searchResults:[],expansions:[]}}return t.prototype.ngOnInit=function(){this.listExpansions()},t.prototype.listExpansions=function(){var t=this;this.inventoryService.listExpansions({isMock:!1}).subscribe(function(e){return t.applyListExpansionsResults(e)},function(e){return t.logError(e)})},t.prototype.applyListExpansionsResults=function(t){this.model.expansions=t},t.prototype.addExpansionParameter=function(t){this.parameter.expansion=t},t.prototype.filterIsInValid=function(){var t=null==this.parameter||null==this.parameter.expansion&&(null==this.parameter.stockQuantity||this.parameter.stockQuantity<=0)&&(null==this.parameter.index||this.parameter.index.trim().length<=0)&&(null==this.parameter.name||this.parameter.name.trim().length<=0)&&(null==this.parameter.type||this.parameter.type.trim().length<=0)&&(null==this.parameter.text||this.parameter.text.trim().length<=0)&&(null==this.parameter.cost||this.parameter.cost.trim().length<=0);return t},t.prototype.search=function(){var t=this;this.model.querying=!0,setTimeout(function(){return t.model.querying=!1},5e3),this.inventoryService.searchCards(this.parameter).subscribe(function(e){return t.applySearchResults(e)},function(e){return t.logError(e)})},t.prototype.applySearchResults=function(t){if(this.model.querying=!1,void 0!=t){if(this.model.searchResultsList.length>0){var e=this.model.searchResultsList.indexOf(this.model.searchResults);this.model.searchResultsList[e]=t}else this.model.searchResultsList.push(t);this.model.searchResults=t}else{if(this.model.searchResultsList.length>0){var e=this.model.searchResultsList.indexOf(this.model.searchResults);this.model.searchResultsList[e]=[]}this.model.searchResults=[]}this.onResult.emit(t)},t.prototype.searchOnNewPage=function(){var t=this;this.model.querying=!0,setTimeout(function(){return t.model.querying=!1},5e3),this.inventoryService.searchCards(this.parameter).subscribe(function(e){return t.applyNewSearchResults(e)},function(e){return t.logError(e)})},t.prototype.applyNewSearchResults=function(t){this.model.querying=!1,void 0!=t&&(this.model.searchResultsList.push(t),this.model.searchResults=t),this.onNewPageResult.emit(t)},t.prototype.isCurrentSearchResults=function(t){return t==this.model.searchResultsList.indexOf(this.model.searchResults)},t.prototype.selectResult=function(t,e){this.model.searchResults=t,this.onResult.emit(t)},t.prototype.closeSearchResults=function(){var t=this.model.searchResultsList.indexOf(this.model.searchResults);this.model.searchResultsList.splice(t,1),this.model.searchResultsList.length>0?this.model.searchResults=this.model.searchResultsList[this.model.searchResultsList.length-1]:this.model.searchResults=[]},t.prototype.logError=function(t){this.model.querying=!1,this.model.searchResults=[],console.error(t)},t}();__decorate([n.i(s.w)(),__metadata("design:type",Boolean)],i.prototype,"showResults",void 0),__decorate([n.i(s._1)(),__metadata("design:type",Object)],i.prototype,"onResult",void 0),__decorate([n.i(s._1)(),__metadata("design:type",Object)],i.prototype,"onNewPageResult",void 0),i=__decorate([n.i(s._4)({selector:"card-finder",template:n(750),styles:[n(1021)]}),__metadata("design:paramtypes",[a.c])],i)},585:function(t,e,n){"use strict";var s=n(584);n.d(e,"a",function(){return s.a})},586:function(t,e,n){"use strict";var s=n(585);n.d(e,"b",function(){return s.a});var a=n(583);n.d(e,"a",function(){return a.a})},587:function(t,e,n){"use strict";var s=n(1),a=n(97),i=n(599);n.n(i);n.d(e,"a",function(){return o});var r=n(272),o=function(){function t(t){this.deckService=t,this.parameter={index:void 0,expansion:void 0,text:void 0,cost:void 0,type:void 0,name:void 0,stockQuantity:void 0,isMock:!1},this.deck={id:void 0,name:void 0,cards:[]},this.model={querying:!1,updating:!1,finding:!1,searchResultsList:[],searchResults:[],expansions:[],decks:[],selectedBoard:1,deckSearchRx:""}}return t.prototype.generateDeckStatistics=function(){console.log("GeneratingDeckStatistics DeckId="+this.deck.id);for(var t={},e={labels:[],series:[]},n=0,s=this.deck.cards;n<s.length;n++){var r=s[n];if(r.deckCard.idBoard==a.d.Main){if(r.manacostLabel.trim().length>0){var o=0;r.manacostLabel.split(", ").forEach(function(t,e){o+=t.match(/\d+/g)?parseFloat(t):1}),o in t?t[o]+=r.deckCard.quantity:r.typeLabel.match(/land/gi)||(t[o]=r.deckCard.quantity)}var c=function(t,n){var s=e.labels.indexOf(t);s>=0?e.series[s]+=n.deckCard.quantity:(e.labels.push(t),e.series.push(n.deckCard.quantity))};r.typeLabel.match(/creature/gi)&&c("Creature",r),r.typeLabel.match(/artifact/gi)&&c("Artifact",r),r.typeLabel.match(/enchantment/gi)&&c("Enchantment",r),r.typeLabel.match(/instant/gi)&&c("Instant",r),r.typeLabel.match(/sorcery/gi)&&c("Sorcery",r),r.typeLabel.match(/planeswalker/gi)&&c("Planeswalker",r),r.typeLabel.match(/land/gi)&&c("Land",r)}}for(var d=Object.keys(t).sort(function(t,e){return parseFloat(t)-parseFloat(e)}),l={labels:[],series:[[]]},u=0,p=d;u<p.length;u++){var h=p[u];l.labels.push(h),l.series[0].push(t[h])}console.log("GeneratingGraphs DeckId="+this.deck.id+" CostData="+JSON.stringify(l)+" TypesData="+JSON.stringify(e)),new i.Line("#lineDeckChart",l,{low:0,high:15,showArea:!0,axisX:{onlyInteger:!0},axisY:{onlyInteger:!0}}),new i.Bar("#barDeckChart",{labels:e.labels,series:[e.series]},{low:0,high:25,axisX:{onlyInteger:!0},axisY:{onlyInteger:!0}});var m=function(t,e){return t+e};new i.Pie("#pieDeckChart",e,{labelPosition:"outside",showLabel:!0,total:60,chartPadding:20,labelOffset:-43,labelDirection:"explode",labelInterpolationFnc:function(t){var n=e.labels.indexOf(t),s=e.series[n],a=t+" "+Math.round(s/e.series.reduce(m)*100)+"%";return a}}),this.model.selectedBoard=3},t.prototype.logFindResult=function(t){this.applySearchResults(t)},t.prototype.logFindNewPageResult=function(t){this.applyNewSearchResults(t)},t.prototype.ngOnInit=function(){this.listExpansions()},t.prototype.ngOnChanges=function(t){console.log("ngOnChanges Changes="+t)},t.prototype.analyseDeckName=function(t){if(9==t.keyCode){var e=t.target.value;if(e.length>2)return console.log("DeckNameSearchRx="+e),this.list(e),!1}else 27==t.keyCode&&this.closeDecksResults()},t.prototype.selectDeck=function(t){this.deck.id=t.id,this.find()},t.prototype.listExpansions=function(){var t=this;this.deckService.listExpansions({isMock:!1}).subscribe(function(e){return t.applyListExpansionsResults(e)},function(e){return t.logError(e)})},t.prototype.applyListExpansionsResults=function(t){this.model.expansions=t},t.prototype.addExpansionParameter=function(t){this.parameter.expansion=t},t.prototype.calculateMainCardsSize=function(){var t=0;return this.deck.cards.forEach(function(e){e.deckCard.idBoard==a.d.Main&&(t+=e.deckCard.quantity)}),t},t.prototype.calculateSideCardsSize=function(){var t=0;return this.deck.cards.forEach(function(e){e.deckCard.idBoard==a.d.Side&&(t+=e.deckCard.quantity)}),t},t.prototype.getCardQuantity=function(t){var e=0,n=this.getDeckCard(a.d.Main,t.id);e=void 0!=n?t.inventoryCard.quantity-n.deckCard.quantity:t.inventoryCard.quantity;var s=this.getDeckCard(a.d.Side,t.id);return void 0!=s&&(e-=s.deckCard.quantity),e},t.prototype.isChangedCardQuantity=function(t){var e=this.getDeckCard(a.d.Main,t.id);if(void 0==e){var n=this.getDeckCard(a.d.Side,t.id);return void 0!=n&&n.deckCard.quantity>0}return e.deckCard.quantity>0},t.prototype.removeCard=function(t){var e=this.getDeckCard(this.model.selectedBoard,t.id);if(void 0==e){e={id:t.id,name:t.name,expansion:t.expansion,idAsset:t.idAsset,deckCard:{quantity:1,idBoard:this.model.selectedBoard}};this.deck.cards.push(e)}else e.deckCard.quantity>0&&(e.deckCard.quantity-=1)},t.prototype.changeBoard=function(t){if(!(t.deckCard.quantity<=0)){var e=t.deckCard.idBoard==a.d.Main?a.d.Side:a.d.Main,n=this.getDeckCard(t.deckCard.idBoard,t.id),s=this.getDeckCard(e,t.id);void 0==s?(s={id:t.id,name:t.name,expansion:t.expansion,idAsset:t.idAsset,deckCard:{quantity:1,idBoard:e},inventoryCard:{quantity:t.inventoryCard.quantity}},this.deck.cards.push(s)):s.deckCard.quantity+=1,n.deckCard.quantity-=1}},t.prototype.addCard=function(t){var e=this.getDeckCard(this.model.selectedBoard,t.id);if(void 0==e){e={id:t.id,name:t.name,expansion:t.expansion,idAsset:t.idAsset,manacostLabel:t.manacostLabel,typeLabel:t.typeLabel,deckCard:{quantity:1,idBoard:this.model.selectedBoard},inventoryCard:{quantity:t.inventoryCard.quantity}};this.deck.cards.push(e)}else e.deckCard.quantity+=1},t.prototype.getDeckCardIdx=function(t,e){for(var n,s=0;s<this.deck.cards.length;s++){var a=this.deck.cards[s];if(a.deckCard.idBoard==t&&a.id==e){n=s;break}}return n},t.prototype.getDeckCard=function(t,e){var n=this.getDeckCardIdx(t,e);return void 0!=n?this.deck.cards[n]:void 0},t.prototype.removeDeckItem=function(t){var e=this.getDeckCardIdx(this.model.selectedBoard,t.id);console.log("RemoveDeckItem: Board.Id="+this.model.selectedBoard+" Card.Id="+t.id+" CardIdx="+e),this.deck.cards.splice(e,1)},t.prototype.filterIsInValid=function(){var t=null==this.parameter||null==this.parameter.expansion&&(null==this.parameter.stockQuantity||this.parameter.stockQuantity<=0)&&(null==this.parameter.index||this.parameter.index.trim().length<=0)&&(null==this.parameter.name||this.parameter.name.trim().length<=0)&&(null==this.parameter.type||this.parameter.type.trim().length<=0)&&(null==this.parameter.cost||this.parameter.cost.trim().length<=0);return t},t.prototype.search=function(){var t=this;this.model.querying=!0,this.deckService.searchCards(this.parameter).subscribe(function(e){return t.applySearchResults(e)},function(e){return t.logError(e)})},t.prototype.applySearchResults=function(t){if(this.model.querying=!1,void 0!=t){if(this.model.searchResultsList.length>0){var e=this.model.searchResultsList.indexOf(this.model.searchResults);this.model.searchResultsList[e]=t}else this.model.searchResultsList.push(t);this.model.searchResults=t}else{if(this.model.searchResultsList.length>0){var e=this.model.searchResultsList.indexOf(this.model.searchResults);this.model.searchResultsList[e]=[]}this.model.searchResults=[]}},t.prototype.searchOnNewPage=function(){var t=this;this.model.querying=!0,this.deckService.searchCards(this.parameter).subscribe(function(e){return t.applyNewSearchResults(e)},function(e){return t.logError(e)})},t.prototype.applyNewSearchResults=function(t){this.model.querying=!1,void 0!=t&&(this.model.searchResultsList.push(t),this.model.searchResults=t)},t.prototype.isCurrentSearchResults=function(t){return t==this.model.searchResultsList.indexOf(this.model.searchResults)},t.prototype.selectResult=function(t,e){this.model.searchResults=t},t.prototype.closeSearchResults=function(){var t=this.model.searchResultsList.indexOf(this.model.searchResults);this.model.searchResultsList.splice(t,1),this.model.searchResultsList.length>0?this.model.searchResults=this.model.searchResultsList[this.model.searchResultsList.length-1]:this.model.searchResults=[]},t.prototype.copyDeck=function(){this.deck.id=void 0,this.deck.name+=" - Copy",this.update()},t.prototype.deleteDeck=function(){var t=this;console.log("DeleteDeck: DeckID="+this.deck.id),this.model.updating=!0,this.deckService.deleteDeck(this.deck.id).subscribe(function(e){return t.applyDeleteResponse(e)},function(e){return t.logError(e)})},t.prototype.closeDeck=function(){this.deck={id:void 0,name:void 0,cards:[]}},t.prototype.update=function(){var t=this;this.model.updating=!0;var e={id:""==this.deck.id?void 0:this.deck.id,name:this.deck.name,cards:[],isMock:!1};for(var n in this.deck.cards)this.deck.cards.forEach(function(t){e.cards.push({id:t.id,deckCard:{idBoard:t.deckCard.idBoard,quantity:t.deckCard.quantity}})});console.log("PostDeck: DeckUpdate="+JSON.stringify(e)),this.deckService.updateDeck(e).subscribe(function(e){return t.applyUpdateResponse(e)},function(e){return t.logError(e)})},t.prototype.applyUpdateResponse=function(t){this.model.updating=!1,console.log("ApplyUpdateStatus="+JSON.stringify(t)),201==t.status&&(this.deck.id=parseInt(t.text(),10))},t.prototype.applyDeleteResponse=function(t){this.model.updating=!1,console.log("ApplyDeleteStatus="+JSON.stringify(t)),200==t.status&&this.closeDeck()},t.prototype.find=function(){var t=this;if(this.model.finding=!0,void 0!=this.deck.id&&""!=this.deck.id)this.deckService.findDeck(this.deck.id).subscribe(function(e){return t.applyFindResult(e)},function(e){return t.logError(e)});else{if(void 0==this.deck.name||""==this.deck.name)throw Error("DeckIdOrNameAreRequiredToFindError: DeckId="+this.deck.id+", DeckName="+this.deck.name);this.deckService.findDeckByName(this.deck.name).subscribe(function(e){return t.applyFindResult(e)},function(e){return t.logError(e)})}},t.prototype.applyFindResult=function(t){this.model.finding=!1,this.deck=t,this.model.selectedBoard=a.d.Main},t.prototype.list=function(t){var e=this;this.clearDecks(),0==t.length?this.model.deckSearchRx="*":this.model.deckSearchRx=t,this.deckService.listDeck(t).subscribe(function(t){return e.applyListDeckResults(t)},function(t){return e.logError(t)})},t.prototype.applyListDeckResults=function(t){this.model.finding=!1,void 0!=t?this.model.decks=t:this.clearDecks(),this.openDecksResults()},t.prototype.openDecksResults=function(){r("#deckSearck").parent().addClass("open")},t.prototype.closeDecksResults=function(){r("#deckSearck").parent().removeClass("open")},t.prototype.clearDecks=function(){this.model.decks=[]},t.prototype.logError=function(t){this.model.querying=!1,this.model.updating=!1,this.model.finding=!1,this.model.searchResults=[],console.error(t)},t}();o=__decorate([n.i(s._4)({selector:"deck",template:n(751),styles:[n(1022)]}),__metadata("design:paramtypes",[a.e])],o);
+webpackJsonp([2],{1019:function(t,e,n){var s=n(742);"string"==typeof s?t.exports=s:t.exports=s.toString()},1020:function(t,e,n){var s=n(743);"string"==typeof s?t.exports=s:t.exports=s.toString()},1021:function(t,e,n){var s=n(744);"string"==typeof s?t.exports=s:t.exports=s.toString()},1022:function(t,e,n){var s=n(745);"string"==typeof s?t.exports=s:t.exports=s.toString()},1023:function(t,e,n){var s=n(746);"string"==typeof s?t.exports=s:t.exports=s.toString()},1024:function(t,e,n){var s=n(747);"string"==typeof s?t.exports=s:t.exports=s.toString()},1025:function(t,e,n){var s=n(748);"string"==typeof s?t.exports=s:t.exports=s.toString()},1026:function(t,e,n){"use strict";function s(){return n.i(a.a)().bootstrapModule(o.a).then(i.a).catch(function(t){return console.error(t)})}Object.defineProperty(e,"__esModule",{value:!0});var a=n(446),i=n(287),r=n(288),o=(n.n(r),n(447));e.main=s,n.i(r.bootloader)(s)},174:function(t,e,n){"use strict";var s=n(1);n.d(e,"a",function(){return a});var a=function(){function t(){this._state={}}return Object.defineProperty(t.prototype,"state",{get:function(){return this._state=this._clone(this._state)},set:function(t){throw new Error("do not mutate the `.state` directly")},enumerable:!0,configurable:!0}),t.prototype.get=function(t){var e=this.state;return e.hasOwnProperty(t)?e[t]:e},t.prototype.set=function(t,e){return this._state[t]=e},t.prototype._clone=function(t){return JSON.parse(JSON.stringify(t))},t}();a=__decorate([n.i(s.p)()],a)},175:function(t,e,n){"use strict";var s=n(1),a=n(78),i=n(0),r=(n.n(i),n(133)),o=(n.n(r),n(80));n.d(e,"a",function(){return c}),n.d(e,"b",function(){return d});var c=function(){function t(t,e){this.urls=t,this.http=e}return t.prototype.ngOnInit=function(){},t.prototype.searchCards=function(t){if(console.log("CardService.searchCards parameter="+JSON.stringify(t)),t.isMock)return console.log("MockServer"),i.Observable.create([{id:5118,name:"Mind Rot",label:"Mind Rot - (3.480/50)",manacostLabel:"2, Black",text:"Target player discards two cards."},{id:9266,name:"Read the Bones",label:"Read the Bones - (3.542/96)",manacostLabel:"2, Black",text:"Scry 2, then draw two cards. You lose 2 life. <i>(To scry 2, look at the top two cards of your library, then put any number of them on the bottom of your library and the rest on top in any order.)</i>"}]);var e=[];if(void 0!=t.stockQuantity&&t.stockQuantity>=0&&e.push("qtd="+t.stockQuantity),void 0!=t.expansion&&t.expansion.id>0&&e.push("e="+t.expansion.id),void 0!=t.index&&""!=t.index&&e.push("n="+t.index),void 0!=t.name&&""!=t.name&&e.push("rx_name="+t.name),void 0!=t.type&&""!=t.type&&e.push("rx_type="+t.type),void 0!=t.cost&&""!=t.cost&&e.push("rx_cost="+t.cost),void 0!=t.text&&""!=t.text&&e.push("rx_text="+t.text),e.length<=0)throw new Error("CardSearchEmptyParameters");var n=this.urls.cards+"query/?"+e.join("&");return this.http.get(n).map(function(t){return t.json()}).catch(this.handleError)},t.prototype.handleError=function(t){return console.error(t),i.Observable.throw(t.json().error||"ServerError")},t}();c=__decorate([n.i(s.p)(),__metadata("design:paramtypes",[o.a,a.b])],c);var d;(function(t){t[t.Main=1]="Main",t[t.Side=2]="Side"})(d||(d={}))},176:function(t,e,n){"use strict";var s=n(1),a=n(78),i=n(0),r=(n.n(i),n(133)),o=(n.n(r),n(80));n.d(e,"a",function(){return c});var c=function(){function t(t,e){this.urls=t,this.http=e}return t.prototype.ngOnInit=function(){},t.prototype.listExpansions=function(t){if(t.isMock)return console.log("MockServer"),i.Observable.create([{id:3,name:"Dark Ascension",label:"Dark Ascension - (2/171)",idAsset:21},{id:19,name:"Innistrad",label:"Innistrad - (3/274)",idAsset:2786}]);var e=this.urls.expansions;return this.http.get(e).map(function(t){return t.json()}).catch(this.handleError)},t.prototype.handleError=function(t){return console.error(t),i.Observable.throw(t.json().error||"ServerError")},t}();c=__decorate([n.i(s.p)(),__metadata("design:paramtypes",[o.a,a.b])],c)},250:function(t,e,n){"use strict";var s=n(1),a=n(78),i=n(0),r=(n.n(i),n(133)),o=(n.n(r),n(80));n.d(e,"a",function(){return c});var c=function(){function t(t,e){this.urls=t,this.http=e,this.session=null,this.player=null}return t.prototype.loadSession=function(t){var e=this;void 0===t&&(t=null);var n=new a.c({"Content-Type":"application/json"}),s=new a.d({headers:n});this.http.get(this.urls.sessions,s).catch(this.handleError).subscribe(function(n){return e.applySession(n,t)})},t.prototype.applySession=function(t,e){if(void 0===e&&(e=null),200!=t.status)throw Error("ErrorLoadingSession: SessionURL="+this.urls.sessions);this.session=t.json(),null!=e&&e(this.session)},t.prototype.loadPlayer=function(t){var e=this;void 0===t&&(t=null);var n=new a.c({"Content-Type":"application/json"}),s=new a.d({headers:n});this.http.get(this.urls.players,s).catch(this.handleError).subscribe(function(n){return e.applyPlayer(n,t)})},t.prototype.applyPlayer=function(t,e){if(void 0===e&&(e=null),200!=t.status)throw Error("ErrorLoadingPlayer: PlayerURL="+this.urls.players);this.player=t.json(),null!=e&&e(this.player)},t.prototype.handleError=function(t){return console.error(t),i.Observable.throw(t.json().error||"ServerError")},t}();c=__decorate([n.i(s.p)(),__metadata("design:paramtypes",[o.a,a.b])],c)},287:function(t,e,n){"use strict";var s=n(121),a=n(1);n.d(e,"a",function(){return o}),n.d(e,"b",function(){return c});var i=[],r=function(t){return t};n.i(a.a)(),r=function(t){return n.i(s.a)(),t},i=i.slice();var o=r,c=i.slice()},372:function(t,e,n){"use strict";var s=n(577);n.d(e,"a",function(){return s.a})},373:function(t,e,n){"use strict";var s=n(587);n.d(e,"a",function(){return s.a})},374:function(t,e,n){"use strict";var s=n(589);n.d(e,"a",function(){return s.a})},375:function(t,e,n){"use strict";var s=n(594);n.d(e,"a",function(){return s.a})},376:function(t,e,n){"use strict";var s=n(1);n.d(e,"a",function(){return a});var a=function(){function t(){}return t.prototype.transform=function(t,e,n){return void 0===n&&(n=null),t.filter(function(t){var s=t,a=e.split(".");return a.forEach(function(t){return void 0!=s&&void(s=s[t])}),s==n})},t}();a=__decorate([n.i(s.o)({name:"filter",pure:!1})],a)},377:function(t,e,n){"use strict";var s=n(376),a=n(378);n.d(e,"b",function(){return a.a}),n.d(e,"a",function(){return i});var i=[s.a,a.a]},378:function(t,e,n){"use strict";var s=n(1);n.d(e,"a",function(){return a});var a=function(){function t(){}return t.prototype.transform=function(t,e){return void 0===e&&(e=null),Object.keys(t).sort().map(function(e){return t[e]})},t}();a=__decorate([n.i(s.o)({name:"values",pure:!1})],a)},379:function(t,e,n){"use strict";var s=n(1),a=n(78),i=n(0),r=(n.n(i),n(133)),o=(n.n(r),n(80)),c=n(175),d=n(176),l=n(250);n.d(e,"a",function(){return u});var u=function(){function t(t,e,n,s,a){this.urls=t,this.http=e,this.sessionService=n,this.cardService=s,this.expansionService=a}return t.prototype.ngOnInit=function(){},t.prototype.listExpansions=function(t){return this.expansionService.listExpansions(t)},t.prototype.searchCards=function(t){return this.cardService.searchCards(t)},t.prototype.updateDeck=function(t){var e=JSON.stringify(t),n=new a.c({"Content-Type":"application/json"}),s=new a.d({headers:n});return this.http.post(this.urls.decks,e,s).catch(this.handleError)},t.prototype.deleteDeck=function(t){var e=new a.c({Accept:"application/json"}),n=new a.d({headers:e});return this.http.delete(this.urls.decks,n).catch(this.handleError)},t.prototype.findDeck=function(t){if(void 0==t||t<=0)throw Error("DeckFindRequiredFieldsError: DeckId="+t);var e=new a.c({Accept:"application/json"}),n=new a.d({headers:e});return this.http.get(this.urls.decks+t,n).map(function(t){return t.json()}).catch(this.handleError)},t.prototype.findDeckByName=function(t){if(void 0==t||""==t)throw Error("DeckFindRequiredFieldsError: DeckName="+t);var e=new a.c({"Content-Type":"application/json"}),n=new a.d({headers:e});return this.http.get(this.urls.decks+encodeURIComponent(t),n).map(function(t){return t.json()}).catch(this.handleError)},t.prototype.listDeck=function(t){if(void 0==t)throw Error("DeckListRequiredFieldsError: DeckNameRx="+t);var e=new a.c({Accpet:"application/json"}),n=new a.d({headers:e});return this.http.get(this.urls.decks+("query/?rx_name="+encodeURIComponent(t)),n).map(function(t){return t.json()}).catch(this.handleError)},t.prototype.handleError=function(t){return console.error(t),i.Observable.throw(t.json().error||"ServerError")},t}();u=__decorate([n.i(s.p)(),__metadata("design:paramtypes",[o.a,a.b,l.a,c.a,d.a])],u)},380:function(t,e,n){"use strict";var s=n(1),a=n(78),i=n(0),r=(n.n(i),n(133)),o=(n.n(r),n(80)),c=n(175),d=n(176);n.d(e,"a",function(){return l});var l=function(){function t(t,e,n,s){this.urls=t,this.http=e,this.cardService=n,this.expansionService=s}return t.prototype.ngOnInit=function(){},t.prototype.listExpansions=function(t){return this.expansionService.listExpansions(t)},t.prototype.searchCards=function(t){return this.cardService.searchCards(t)},t.prototype.updateInventory=function(t){var e=JSON.stringify(t),n=new a.c({"Content-Type":"application/json"}),s=new a.d({headers:n});return this.http.post(this.urls.inventories,e,s).catch(this.handleError)},t.prototype.handleError=function(t){return console.error(t),i.Observable.throw(t.json().error||"ServerError")},t}();l=__decorate([n.i(s.p)(),__metadata("design:paramtypes",[o.a,a.b,c.a,d.a])],l)},445:function(t,e){function n(t){throw new Error("Cannot find module '"+t+"'.")}n.keys=function(){return[]},n.resolve=n,t.exports=n,n.id=445},447:function(t,e,n){"use strict";var s=n(579);n.d(e,"a",function(){return s.a})},577:function(t,e,n){"use strict";var s=n(1),a=n(366);n.d(e,"a",function(){return i}),console.log("`About` component loaded asynchronously");var i=function(){function t(t){this.route=t}return t.prototype.ngOnInit=function(){var t=this;this.route.data.subscribe(function(e){t.localState=e.yourData}),console.log("hello `About` component"),this.asyncDataWithWebpack()},t.prototype.asyncDataWithWebpack=function(){var t=this;setTimeout(function(){n.e(0).then(n.bind(null,1028)).then(function(e){console.log("async mockData",e),t.localState=e})})},t}();i=__decorate([n.i(s._4)({selector:"about",styles:["\n  "],template:"\n    <h1>About</h1>\n    <div>\n      For hot module reloading run\n      <pre>npm run start:hmr</pre>\n    </div>\n    <div>\n      <h3>\n        patrick@AngularClass.com\n      </h3>\n    </div>\n    <pre>this.localState = {{ localState | json }}</pre>\n  "}),__metadata("design:paramtypes",[a.c])],i)},578:function(t,e,n){"use strict";var s=n(1),a=n(174);n.d(e,"a",function(){return i});var i=function(){function t(t){this.appState=t,this.angularclassLogo="assets/img/angularclass-avatar.png",this.name="Angular 2 Webpack Starter",this.url="https://twitter.com/AngularClass"}return t.prototype.ngOnInit=function(){console.log("Initial App State",this.appState.state)},t}();i=__decorate([n.i(s._4)({selector:"app",encapsulation:s.O.None,styles:[n(1019)],template:'\n    <nav>\n      <a [routerLink]=" [\'./\'] " routerLinkActive="active">\n        Index\n      </a>\n      <a [routerLink]=" [\'./home\'] " routerLinkActive="active">\n        Home\n      </a>\n      <a [routerLink]=" [\'./detail\'] " routerLinkActive="active">\n        Detail\n      </a>\n      <a [routerLink]=" [\'./barrel\'] " routerLinkActive="active">\n        Barrel\n      </a>\n      <a [routerLink]=" [\'./about\'] " routerLinkActive="active">\n        About\n      </a>\n    </nav>\n\n    <main>\n      <router-outlet></router-outlet>\n    </main>\n\n    <pre class="app-state">this.appState.state = {{ appState.state | json }}</pre>\n\n    <footer>\n      <span>WebPack Angular 2 Starter by <a [href]="url">@AngularClass</a></span>\n      <div>\n        <a [href]="url">\n          <img [src]="angularclassLogo" width="25%">\n        </a>\n      </div>\n    </footer>\n  '}),__metadata("design:paramtypes",[a.a])],i)},579:function(t,e,n){"use strict";var s=n(121),a=n(542),i=n(78),r=n(1),o=n(366),c=n(288),d=(n.n(c),n(287)),l=n(581),u=n(97),p=n(377),h=n(588),m=n(375),g=n(373),f=n(586),v=n(578),y=n(580),b=n(174),k=n(374),x=n(372),R=n(595),I=n(592);n.d(e,"a",function(){return C});var w=y.a.concat(u.a,[b.a]),C=function(){function t(t,e){this.appRef=t,this.appState=e}return t.prototype.hmrOnInit=function(t){if(t&&t.state){if(console.log("HMR store",JSON.stringify(t,null,2)),this.appState._state=t.state,"restoreInputValues"in t){var e=t.restoreInputValues;setTimeout(e)}this.appRef.tick(),delete t.state,delete t.restoreInputValues}},t.prototype.hmrOnDestroy=function(t){var e=this.appRef.components.map(function(t){return t.location.nativeElement}),s=this.appState._state;t.state=s,t.disposeOldHosts=n.i(c.createNewHosts)(e),t.restoreInputValues=n.i(c.createInputTransfer)(),n.i(c.removeNgStyles)()},t.prototype.hmrAfterDestroy=function(t){t.disposeOldHosts(),delete t.disposeOldHosts},t}();C=__decorate([n.i(r.i)({bootstrap:[h.a],declarations:[h.a,f.a,m.a,g.a,f.b].concat(p.a,[v.a,x.a,k.a,R.a,I.a]),imports:[s.b,a.a,i.a,o.a.forRoot(l.a,{useHash:!0,preloadingStrategy:o.b})],providers:[d.b,w]}),__metadata("design:paramtypes",[r.K,b.a])],C)},580:function(t,e,n){"use strict";var s=n(1),a=n(0),i=(n.n(a),n(418));n.n(i);n.d(e,"a",function(){return o});var r=function(){function t(){}return t.prototype.resolve=function(t,e){return a.Observable.of({res:"I am data"})},t}();r=__decorate([n.i(s.p)()],r);var o=[r]},581:function(t,e,n){"use strict";var s=n(374),a=n(372),i=n(375),r=n(373);n.d(e,"a",function(){return o});var o=[{path:"",component:r.a},{path:"home",component:s.a},{path:"about",component:a.a},{path:"inventory",component:i.a},{path:"deck",component:r.a},{path:"**",component:r.a}]},582:function(t,e,n){"use strict";var s=n(1),a=n(97),i=n(1018),r=(n.n(i),n(598));n.n(r);n.d(e,"a",function(){return c});var o=n(272),c=function(){function t(){this.decreaseOn=!1,this.increaseOn=!1,this.removeOn=!1,this.changeBoardOn=!1,this.showPopOn=!0,this.shoppingOn=!0,this.onDecrease=new s.G,this.onIncrease=new s.G,this.onRemove=new s.G,this.onChangeBoard=new s.G,this.onShowPop=new s.G,this.onHidePop=new s.G,this.onShopping=new s.G,this.state={popOpen:!1},null==this.componentId&&(this.componentId=n.i(a.b)())}return t.prototype.ngOnInit=function(){},t.prototype.getComponentLabel=function(){return null!=this.label?this.label:null==this.card.deckCard?this.card.inventoryCard.quantity.toString():this.card.inventoryCard.quantity<=0||this.card.inventoryCard.quantity>=this.card.deckCard.quantity?this.card.deckCard.quantity.toString():this.card.inventoryCard.quantity+"/"+this.card.deckCard.quantity},t.prototype.decrease=function(){this.onDecrease.emit(this.card)},t.prototype.increase=function(){this.onIncrease.emit(this.card)},t.prototype.remove=function(){this.onRemove.emit(this.card)},t.prototype.changeBoard=function(){this.onChangeBoard.emit(this.card)},t.prototype.showPop=function(){var t=o("#"+this.componentId);if(this.state.popOpen)return t.popover("dispose"),void(this.state.popOpen=!1);var e=t.offset(),n="left",s="0 0";e.top>370?(n="top",e.left<100?s="0 -30px":e.left>1e3&&(s="0 25px")):e.left<300&&(n="right"),t=t.popover({template:'<div class="popover" role="tooltip">\n                    <div class="popover-arrow"></div>\n                    <div class="card-header card-pop-header text-xs-center">\n                        <span class="searchResultsItemTitle"><strong class="popover-title" style="padding: 0px; border: none;"></strong></span>\n                        <img src="/api/assets/'+this.card.expansion.idAsset+'" style="height: 18px; vertical-align: middle;" />\n                    </div>\n                    <div class="popover-content"></div>\n                </div>',content:'<img class="card-img-large" src="/api/assets/'+this.card.idAsset+'" />',trigger:"manual",html:!0,placement:n,offset:s,title:this.card.name}),t.popover("show"),this.state.popOpen=!0,this.onShowPop.emit(this.card)},t.prototype.hidePop=function(){var t=o("#"+this.componentId);t.popover("dispose"),this.state.popOpen=!1,this.onHidePop.emit(this.card)},t.prototype.shopping=function(){console.log("Card.shopping id='"+this.componentId+"' cardId="+this.card.id+" name='"+this.card.name+"'");var t=window.open("http://ligamagic.com.br/?view=cards%2Fsearch&card="+encodeURIComponent(this.card.name).replace(/'/g,"%27"),"_blank");t.focus(),this.onShopping.emit(this.card)},t}();__decorate([n.i(s.w)(),__metadata("design:type",Object)],c.prototype,"card",void 0),__decorate([n.i(s.w)(),__metadata("design:type",String)],c.prototype,"label",void 0),__decorate([n.i(s.w)(),__metadata("design:type",String)],c.prototype,"componentId",void 0),__decorate([n.i(s.w)(),__metadata("design:type",Boolean)],c.prototype,"decreaseOn",void 0),__decorate([n.i(s.w)(),__metadata("design:type",Boolean)],c.prototype,"increaseOn",void 0),__decorate([n.i(s.w)(),__metadata("design:type",Boolean)],c.prototype,"removeOn",void 0),__decorate([n.i(s.w)(),__metadata("design:type",Boolean)],c.prototype,"changeBoardOn",void 0),__decorate([n.i(s.w)(),__metadata("design:type",Boolean)],c.prototype,"showPopOn",void 0),__decorate([n.i(s.w)(),__metadata("design:type",Boolean)],c.prototype,"shoppingOn",void 0),__decorate([n.i(s._1)(),__metadata("design:type",Object)],c.prototype,"onDecrease",void 0),__decorate([n.i(s._1)(),__metadata("design:type",Object)],c.prototype,"onIncrease",void 0),__decorate([n.i(s._1)(),__metadata("design:type",Object)],c.prototype,"onRemove",void 0),__decorate([n.i(s._1)(),__metadata("design:type",Object)],c.prototype,"onChangeBoard",void 0),__decorate([n.i(s._1)(),__metadata("design:type",Object)],c.prototype,"onShowPop",void 0),__decorate([n.i(s._1)(),__metadata("design:type",Object)],c.prototype,"onHidePop",void 0),__decorate([n.i(s._1)(),__metadata("design:type",Object)],c.prototype,"onShopping",void 0),c=__decorate([n.i(s._4)({selector:"card",template:n(749),styles:[n(1020)]}),__metadata("design:paramtypes",[])],c)},583:function(t,e,n){"use strict";var s=n(582);n.d(e,"a",function(){return s.a})},584:function(t,e,n){"use strict";var s=n(1),a=n(97);n.d(e,"a",function(){return i});var i=function(){function t(t){this.inventoryService=t,this.showResults=!1,this.onResult=new s.G,this.onNewPageResult=new s.G,this.parameter={index:void 0,expansion:void 0,text:void 0,cost:void 0,type:void 0,name:void 0,stockQuantity:void 0,isMock:!1},this.model={querying:!1,searchResultsList:[],searchResults:[],expansions:[]}}return t.prototype.ngOnInit=function(){this.listExpansions()},t.prototype.listExpansions=function(){var t=this;this.inventoryService.listExpansions({isMock:!1}).subscribe(function(e){return t.applyListExpansionsResults(e)},function(e){return t.logError(e)})},t.prototype.applyListExpansionsResults=function(t){this.model.expansions=t},t.prototype.addExpansionParameter=function(t){this.parameter.expansion=t},t.prototype.filterIsInValid=function(){var t=null==this.parameter||null==this.parameter.expansion&&(null==this.parameter.stockQuantity||this.parameter.stockQuantity<=0)&&(null==this.parameter.index||this.parameter.index.trim().length<=0)&&(null==this.parameter.name||this.parameter.name.trim().length<=0)&&(null==this.parameter.type||this.parameter.type.trim().length<=0)&&(null==this.parameter.text||this.parameter.text.trim().length<=0)&&(null==this.parameter.cost||this.parameter.cost.trim().length<=0);return t},t.prototype.search=function(){var t=this;this.model.querying=!0,setTimeout(function(){return t.model.querying=!1},5e3),this.inventoryService.searchCards(this.parameter).subscribe(function(e){return t.applySearchResults(e)},function(e){return t.logError(e)})},t.prototype.applySearchResults=function(t){if(this.model.querying=!1,void 0!=t){if(this.model.searchResultsList.length>0){var e=this.model.searchResultsList.indexOf(this.model.searchResults);this.model.searchResultsList[e]=t}else this.model.searchResultsList.push(t);this.model.searchResults=t}else{if(this.model.searchResultsList.length>0){var e=this.model.searchResultsList.indexOf(this.model.searchResults);this.model.searchResultsList[e]=[]}this.model.searchResults=[]}this.onResult.emit(t)},t.prototype.searchOnNewPage=function(){var t=this;this.model.querying=!0,setTimeout(function(){return t.model.querying=!1},5e3),this.inventoryService.searchCards(this.parameter).subscribe(function(e){return t.applyNewSearchResults(e)},function(e){return t.logError(e)})},t.prototype.applyNewSearchResults=function(t){this.model.querying=!1,void 0!=t&&(this.model.searchResultsList.push(t),this.model.searchResults=t),this.onNewPageResult.emit(t)},t.prototype.isCurrentSearchResults=function(t){return t==this.model.searchResultsList.indexOf(this.model.searchResults)},t.prototype.selectResult=function(t,e){this.model.searchResults=t,this.onResult.emit(t)},t.prototype.closeSearchResults=function(){var t=this.model.searchResultsList.indexOf(this.model.searchResults);this.model.searchResultsList.splice(t,1),this.model.searchResultsList.length>0?this.model.searchResults=this.model.searchResultsList[this.model.searchResultsList.length-1]:this.model.searchResults=[]},t.prototype.logError=function(t){this.model.querying=!1,this.model.searchResults=[],console.error(t)},t}();__decorate([n.i(s.w)(),__metadata("design:type",Boolean)],i.prototype,"showResults",void 0),__decorate([n.i(s._1)(),__metadata("design:type",Object)],i.prototype,"onResult",void 0),__decorate([n.i(s._1)(),__metadata("design:type",Object)],i.prototype,"onNewPageResult",void 0),i=__decorate([n.i(s._4)({selector:"card-finder",template:n(750),styles:[n(1021)]}),__metadata("design:paramtypes",[a.c])],i)},585:function(t,e,n){"use strict";var s=n(584);n.d(e,"a",function(){return s.a})},586:function(t,e,n){"use strict";var s=n(585);n.d(e,"b",function(){return s.a});var a=n(583);n.d(e,"a",function(){return a.a})},587:function(t,e,n){"use strict";var s=n(1),a=n(97),i=n(599);n.n(i);n.d(e,"a",function(){return o});var r=n(272),o=function(){function t(t){this.deckService=t,this.parameter={index:void 0,expansion:void 0,text:void 0,cost:void 0,type:void 0,name:void 0,stockQuantity:void 0,isMock:!1},this.deck={id:void 0,name:void 0,cards:[]},this.model={querying:!1,updating:!1,finding:!1,searchResultsList:[],searchResults:[],expansions:[],decks:[],selectedBoard:1,deckSearchRx:""}}return t.prototype.generateDeckStatistics=function(){console.log("GeneratingDeckStatistics DeckId="+this.deck.id);for(var t={},e={labels:[],series:[]},n=0,s=this.deck.cards;n<s.length;n++){var r=s[n];if(r.deckCard.idBoard==a.d.Main){if(r.manacostLabel.trim().length>0){var o=0;r.manacostLabel.split(", ").forEach(function(t,e){o+=t.match(/\d+/g)?parseFloat(t):1}),o in t?t[o]+=r.deckCard.quantity:r.typeLabel.match(/land/gi)||(t[o]=r.deckCard.quantity)}var c=function(t,n){var s=e.labels.indexOf(t);s>=0?e.series[s]+=n.deckCard.quantity:(e.labels.push(t),e.series.push(n.deckCard.quantity))};r.typeLabel.match(/creature/gi)&&c("Creature",r),r.typeLabel.match(/artifact/gi)&&c("Artifact",r),r.typeLabel.match(/enchantment/gi)&&c("Enchantment",r),r.typeLabel.match(/instant/gi)&&c("Instant",r),r.typeLabel.match(/sorcery/gi)&&c("Sorcery",r),r.typeLabel.match(/planeswalker/gi)&&c("Planeswalker",r),r.typeLabel.match(/land/gi)&&c("Land",r)}}for(var d=Object.keys(t).sort(function(t,e){return parseFloat(t)-parseFloat(e)}),l={labels:[],series:[[]]},u=0,p=d;u<p.length;u++){var h=p[u];l.labels.push(h),l.series[0].push(t[h])}console.log("GeneratingGraphs DeckId="+this.deck.id+" CostData="+JSON.stringify(l)+" TypesData="+JSON.stringify(e)),new i.Line("#lineDeckChart",l,{low:0,high:15,showArea:!0,axisX:{onlyInteger:!0},axisY:{onlyInteger:!0}}),new i.Bar("#barDeckChart",{labels:e.labels,series:[e.series]},{low:0,high:25,axisX:{onlyInteger:!0},axisY:{onlyInteger:!0}});var m=function(t,e){return t+e};new i.Pie("#pieDeckChart",e,{labelPosition:"outside",showLabel:!0,total:60,chartPadding:20,labelOffset:-43,labelDirection:"explode",labelInterpolationFnc:function(t){var n=e.labels.indexOf(t),s=e.series[n],a=t+" "+Math.round(s/e.series.reduce(m)*100)+"%";return a}}),this.model.selectedBoard=3},t.prototype.logFindResult=function(t){this.applySearchResults(t)},t.prototype.logFindNewPageResult=function(t){this.applyNewSearchResults(t)},t.prototype.ngOnInit=function(){this.listExpansions()},t.prototype.ngOnChanges=function(t){console.log("ngOnChanges Changes="+t)},t.prototype.analyseDeckName=function(t){if(9==t.keyCode){var e=t.target.value;if(e.length>2)return console.log("DeckNameSearchRx="+e),this.list(e),!1}else 27==t.keyCode&&this.closeDecksResults()},t.prototype.selectDeck=function(t){this.deck.id=t.id,this.find()},t.prototype.listExpansions=function(){var t=this;this.deckService.listExpansions({isMock:!1}).subscribe(function(e){return t.applyListExpansionsResults(e)},function(e){return t.logError(e)})},t.prototype.applyListExpansionsResults=function(t){this.model.expansions=t},t.prototype.addExpansionParameter=function(t){this.parameter.expansion=t},t.prototype.calculateMainCardsSize=function(){var t=0;return this.deck.cards.forEach(function(e){e.deckCard.idBoard==a.d.Main&&(t+=e.deckCard.quantity)}),t},t.prototype.calculateSideCardsSize=function(){var t=0;return this.deck.cards.forEach(function(e){e.deckCard.idBoard==a.d.Side&&(t+=e.deckCard.quantity)}),t},t.prototype.getCardQuantity=function(t){var e=0,n=this.getDeckCard(a.d.Main,t.id);e=void 0!=n?t.inventoryCard.quantity-n.deckCard.quantity:t.inventoryCard.quantity;var s=this.getDeckCard(a.d.Side,t.id);return void 0!=s&&(e-=s.deckCard.quantity),e},t.prototype.isChangedCardQuantity=function(t){var e=this.getDeckCard(a.d.Main,t.id);if(void 0==e){var n=this.getDeckCard(a.d.Side,t.id);return void 0!=n&&n.deckCard.quantity>0}return e.deckCard.quantity>0},t.prototype.removeCard=function(t){var e=this.getDeckCard(this.model.selectedBoard,t.id);if(void 0==e){e={id:t.id,name:t.name,expansion:t.expansion,idAsset:t.idAsset,deckCard:{quantity:1,idBoard:this.model.selectedBoard}};this.deck.cards.push(e)}else e.deckCard.quantity>0&&(e.deckCard.quantity-=1)},t.prototype.changeBoard=function(t){if(!(t.deckCard.quantity<=0)){var e=t.deckCard.idBoard==a.d.Main?a.d.Side:a.d.Main,n=this.getDeckCard(t.deckCard.idBoard,t.id),s=this.getDeckCard(e,t.id);void 0==s?(s={id:t.id,name:t.name,expansion:t.expansion,idAsset:t.idAsset,deckCard:{quantity:1,idBoard:e},inventoryCard:{quantity:t.inventoryCard.quantity}},this.deck.cards.push(s)):s.deckCard.quantity+=1,n.deckCard.quantity-=1}},t.prototype.addCard=function(t){var e=this.getDeckCard(this.model.selectedBoard,t.id);if(void 0==e){e={id:t.id,name:t.name,expansion:t.expansion,idAsset:t.idAsset,manacostLabel:t.manacostLabel,typeLabel:t.typeLabel,deckCard:{quantity:1,idBoard:this.model.selectedBoard},inventoryCard:{quantity:t.inventoryCard.quantity}};this.deck.cards.push(e)}else e.deckCard.quantity+=1},t.prototype.getDeckCardIdx=function(t,e){for(var n,s=0;s<this.deck.cards.length;s++){var a=this.deck.cards[s];if(a.deckCard.idBoard==t&&a.id==e){n=s;break}}return n},t.prototype.getDeckCard=function(t,e){var n=this.getDeckCardIdx(t,e);return void 0!=n?this.deck.cards[n]:void 0},t.prototype.removeDeckItem=function(t){var e=this.getDeckCardIdx(this.model.selectedBoard,t.id);console.log("RemoveDeckItem: Board.Id="+this.model.selectedBoard+" Card.Id="+t.id+" CardIdx="+e),this.deck.cards.splice(e,1)},t.prototype.filterIsInValid=function(){var t=null==this.parameter||null==this.parameter.expansion&&(null==this.parameter.stockQuantity||this.parameter.stockQuantity<=0)&&(null==this.parameter.index||this.parameter.index.trim().length<=0)&&(null==this.parameter.name||this.parameter.name.trim().length<=0)&&(null==this.parameter.type||this.parameter.type.trim().length<=0)&&(null==this.parameter.cost||this.parameter.cost.trim().length<=0);return t},t.prototype.search=function(){var t=this;this.model.querying=!0,this.deckService.searchCards(this.parameter).subscribe(function(e){return t.applySearchResults(e)},function(e){return t.logError(e)})},t.prototype.applySearchResults=function(t){if(this.model.querying=!1,void 0!=t){if(this.model.searchResultsList.length>0){var e=this.model.searchResultsList.indexOf(this.model.searchResults);this.model.searchResultsList[e]=t}else this.model.searchResultsList.push(t);this.model.searchResults=t}else{if(this.model.searchResultsList.length>0){var e=this.model.searchResultsList.indexOf(this.model.searchResults);this.model.searchResultsList[e]=[]}this.model.searchResults=[]}},t.prototype.searchOnNewPage=function(){var t=this;this.model.querying=!0,this.deckService.searchCards(this.parameter).subscribe(function(e){return t.applyNewSearchResults(e)},function(e){return t.logError(e)})},t.prototype.applyNewSearchResults=function(t){this.model.querying=!1,void 0!=t&&(this.model.searchResultsList.push(t),this.model.searchResults=t)},t.prototype.isCurrentSearchResults=function(t){return t==this.model.searchResultsList.indexOf(this.model.searchResults)},t.prototype.selectResult=function(t,e){this.model.searchResults=t},t.prototype.closeSearchResults=function(){var t=this.model.searchResultsList.indexOf(this.model.searchResults);this.model.searchResultsList.splice(t,1),this.model.searchResultsList.length>0?this.model.searchResults=this.model.searchResultsList[this.model.searchResultsList.length-1]:this.model.searchResults=[]},t.prototype.copyDeck=function(){this.deck.id=void 0,this.deck.name+=" - Copy",this.update()},t.prototype.deleteDeck=function(){var t=this;console.log("DeleteDeck: DeckID="+this.deck.id),this.model.updating=!0,this.deckService.deleteDeck(this.deck.id).subscribe(function(e){return t.applyDeleteResponse(e)},function(e){return t.logError(e)})},t.prototype.closeDeck=function(){this.deck={id:void 0,name:void 0,cards:[]}},t.prototype.update=function(){var t=this;this.model.updating=!0;var e={id:""==this.deck.id?void 0:this.deck.id,name:this.deck.name,cards:[],isMock:!1};for(var n in this.deck.cards)this.deck.cards.forEach(function(t){e.cards.push({id:t.id,deckCard:{idBoard:t.deckCard.idBoard,quantity:t.deckCard.quantity}})});console.log("PostDeck: DeckUpdate="+JSON.stringify(e)),this.deckService.updateDeck(e).subscribe(function(e){return t.applyUpdateResponse(e)},function(e){return t.logError(e)})},t.prototype.applyUpdateResponse=function(t){this.model.updating=!1,console.log("ApplyUpdateStatus="+JSON.stringify(t)),201==t.status&&(this.deck.id=parseInt(t.text(),10))},t.prototype.applyDeleteResponse=function(t){this.model.updating=!1,console.log("ApplyDeleteStatus="+JSON.stringify(t)),200==t.status&&this.closeDeck()},t.prototype.find=function(){var t=this;if(this.model.finding=!0,void 0!=this.deck.id&&""!=this.deck.id)this.deckService.findDeck(this.deck.id).subscribe(function(e){return t.applyFindResult(e)},function(e){return t.logError(e)});else{if(void 0==this.deck.name||""==this.deck.name)throw Error("DeckIdOrNameAreRequiredToFindError: DeckId="+this.deck.id+", DeckName="+this.deck.name);this.deckService.findDeckByName(this.deck.name).subscribe(function(e){return t.applyFindResult(e)},function(e){return t.logError(e)})}},t.prototype.applyFindResult=function(t){this.model.finding=!1,this.deck=t,this.model.selectedBoard=a.d.Main},t.prototype.list=function(t){var e=this;this.clearDecks(),0==t.length?this.model.deckSearchRx="*":this.model.deckSearchRx=t,this.deckService.listDeck(t).subscribe(function(t){return e.applyListDeckResults(t)},function(t){return e.logError(t)})},t.prototype.applyListDeckResults=function(t){this.model.finding=!1,void 0!=t?this.model.decks=t:this.clearDecks(),this.openDecksResults()},t.prototype.openDecksResults=function(){r("#deckSearck").parent().addClass("open")},t.prototype.closeDecksResults=function(){r("#deckSearck").parent().removeClass("open")},t.prototype.clearDecks=function(){this.model.decks=[]},t.prototype.logError=function(t){this.model.querying=!1,this.model.updating=!1,this.model.finding=!1,this.model.searchResults=[],console.error(t)},t}();o=__decorate([n.i(s._4)({selector:"deck",template:n(751),styles:[n(1022)]}),__metadata("design:paramtypes",[a.e])],o);
 },588:function(t,e,n){"use strict";var s=n(1),a=n(174),i=n(97);n.d(e,"a",function(){return r});var r=function(){function t(t,e){this.sessionService=t,this.appState=e,this.name="Fivecolors Web Interface",this.session={username:"anonymous"}}return t.prototype.ngOnInit=function(){console.log("FivecolorsInit")},t.prototype.applyLoadSessionResult=function(t){this.session=t,console.log("SessionLoaded session="+JSON.stringify(this.session))},t}();r=__decorate([n.i(s._4)({selector:"fivecolors",template:n(752),styles:[n(1023)]}),__metadata("design:paramtypes",[i.f,a.a])],r)},589:function(t,e,n){"use strict";var s=n(1),a=n(174),i=n(590);n.d(e,"a",function(){return r});var r=function(){function t(t,e){this.appState=t,this.title=e,this.localState={value:""}}return t.prototype.ngOnInit=function(){console.log("hello `Home` component")},t.prototype.submitState=function(t){console.log("submitState",t),this.appState.set("value",t),this.localState.value=""},t}();r=__decorate([n.i(s._4)({selector:"home",providers:[i.a],styles:[n(1024)],template:n(753)}),__metadata("design:paramtypes",[a.a,i.a])],r)},590:function(t,e,n){"use strict";var s=n(591);n.d(e,"a",function(){return s.a})},591:function(t,e,n){"use strict";var s=n(1),a=n(78);n.d(e,"a",function(){return i});var i=function(){function t(t){this.http=t,this.value="Angular 2"}return t.prototype.getData=function(){return console.log("Title#getData(): Get Data"),{value:"AngularClass"}},t}();i=__decorate([n.i(s.p)(),__metadata("design:paramtypes",[a.b])],i)},592:function(t,e,n){"use strict";var s=n(593);n.d(e,"a",function(){return s.a})},593:function(t,e,n){"use strict";var s=n(1);n.d(e,"a",function(){return a});var a=function(){function t(t,e){e.setElementStyle(t.nativeElement,"fontSize","x-large")}return t}();a=__decorate([n.i(s.v)({selector:"[x-large]"}),__metadata("design:paramtypes",[s.C,s.D])],a)},594:function(t,e,n){"use strict";var s=n(1),a=n(97),i=n(377);n.d(e,"a",function(){return r});var r=function(){function t(t){this.inventoryService=t,this.parameter={index:void 0,expansion:void 0,text:void 0,cost:void 0,type:void 0,name:void 0,stockQuantity:void 0,isMock:!1},this.model={querying:!1,updating:!1,searchResultsList:[],searchResults:[],expansions:[{idAsset:1,name:"Alpha"},{idAsset:2,name:"Beta"},{idAsset:3,name:"Revised"},{idAsset:50,name:"Very large name of an expansion baby, dont cry ..."}],updateQueue:{}},this.valuesPipe=new i.b}return t.prototype.ngOnInit=function(){this.listExpansions()},t.prototype.listExpansions=function(){var t=this;this.inventoryService.listExpansions({isMock:!1}).subscribe(function(e){return t.applyListExpansionsResults(e)},function(e){return t.logError(e)})},t.prototype.applyListExpansionsResults=function(t){this.model.expansions=t},t.prototype.addExpansionParameter=function(t){this.parameter.expansion=t},t.prototype.createCardQuantityLabel=function(t){var e=this.model.updateQueue[t.id];return void 0==e?t.inventoryCard.quantity:this.model.updateQueue[t.id].quantity},t.prototype.compareUpdateItemQuantity=function(t){var e=t.card.inventoryCard.quantity,n=t.quantity;return n<e?-1:n>e?1:0},t.prototype.compareCardQuantity=function(t){var e=this.model.updateQueue[t.id];if(void 0==e)return 0;var n=t.inventoryCard.quantity,s=this.model.updateQueue[t.id].quantity;return s<n?-1:s>n?1:0},t.prototype.showCard=function(t){console.log("ShowCard="+t);var e=window.open("http://ligamagic.com.br/?view=cards%2Fsearch&card="+encodeURIComponent(t.name),"_blank");e.focus()},t.prototype.removeCard=function(t){if(!(t.inventoryCard.quantity<=0)||t.id in this.model.updateQueue){var e=this.model.updateQueue[t.id];void 0==e?(e={action:"remove",card:t,quantity:t.inventoryCard.quantity-1},this.model.updateQueue[t.id]=e):e.quantity>0&&(e.quantity-=1,e.quantity<t.inventoryCard.quantity?e.action="remove":e.action="add")}},t.prototype.addCard=function(t){var e=this.model.updateQueue[t.id];void 0==e?(e={action:"add",card:t,quantity:t.inventoryCard.quantity+1},this.model.updateQueue[t.id]=e):(e.quantity+=1,e.quantity<t.inventoryCard.quantity?e.action="remove":e.action="add")},t.prototype.cleanUpdateQueue=function(){this.model.updateQueue=[]},t.prototype.removeUpdateItem=function(t){delete this.model.updateQueue[t.card.id]},t.prototype.filterIsInValid=function(){var t=null==this.parameter||null==this.parameter.expansion&&(null==this.parameter.stockQuantity||this.parameter.stockQuantity<=0)&&(null==this.parameter.index||this.parameter.index.trim().length<=0)&&(null==this.parameter.name||this.parameter.name.trim().length<=0)&&(null==this.parameter.type||this.parameter.type.trim().length<=0)&&(null==this.parameter.cost||this.parameter.cost.trim().length<=0);return t},t.prototype.search=function(){var t=this;this.model.querying=!0,setTimeout(function(){return t.model.querying=!1},5e3),this.inventoryService.searchCards(this.parameter).subscribe(function(e){return t.applySearchResults(e)},function(e){return t.logError(e)})},t.prototype.logFindResult=function(t){this.applySearchResults(t)},t.prototype.logFindNewPageResult=function(t){this.applyNewSearchResults(t)},t.prototype.applySearchResults=function(t){if(this.model.querying=!1,void 0!=t){if(this.model.searchResultsList.length>0){var e=this.model.searchResultsList.indexOf(this.model.searchResults);this.model.searchResultsList[e]=t}else this.model.searchResultsList.push(t);this.model.searchResults=t}else{if(this.model.searchResultsList.length>0){var e=this.model.searchResultsList.indexOf(this.model.searchResults);this.model.searchResultsList[e]=[]}this.model.searchResults=[]}},t.prototype.searchOnNewPage=function(){var t=this;this.model.querying=!0,setTimeout(function(){return t.model.querying=!1},5e3),this.inventoryService.searchCards(this.parameter).subscribe(function(e){return t.applyNewSearchResults(e)},function(e){return t.logError(e)})},t.prototype.applyNewSearchResults=function(t){this.model.querying=!1,void 0!=t&&(this.model.searchResultsList.push(t),this.model.searchResults=t)},t.prototype.isCurrentSearchResults=function(t){return t==this.model.searchResultsList.indexOf(this.model.searchResults)},t.prototype.selectResult=function(t,e){this.model.searchResults=t},t.prototype.closeSearchResults=function(){var t=this.model.searchResultsList.indexOf(this.model.searchResults);this.model.searchResultsList.splice(t,1),this.model.searchResultsList.length>0?this.model.searchResults=this.model.searchResultsList[this.model.searchResultsList.length-1]:this.model.searchResults=[]},t.prototype.updateQueueIsInvalid=function(){return null==this.model||this.valuesPipe.transform(this.model.updateQueue).length<=0},t.prototype.update=function(){var t=this;this.model.updating=!0,setTimeout(function(){return t.model.updating=!1},5e3);var e={cards:[]};for(var n in this.model.updateQueue)if(this.model.updateQueue.hasOwnProperty(n)){var s=this.model.updateQueue[n];e.cards.push({id:s.card.id,inventoryCard:{quantity:s.quantity}})}this.inventoryService.updateInventory(e).subscribe(function(e){return t.applyUpdateResponse(e)},function(e){return t.logError(e)})},t.prototype.applyUpdateResponse=function(t){if(console.log("ApplyUpdateStatus="+JSON.stringify(t)),201==t.status||202==t.status){for(var e in this.model.updateQueue)if(this.model.updateQueue.hasOwnProperty(e)){var n=this.model.updateQueue[e];n.card.inventoryCard.quantity=n.quantity}this.cleanUpdateQueue()}},t.prototype.logError=function(t){this.model.querying=!1,this.model.searchResults=[],console.error(t)},t}();r=__decorate([n.i(s._4)({selector:"inventory",template:n(754),styles:[n(1025)]}),__metadata("design:paramtypes",[a.c])],r)},595:function(t,e,n){"use strict";var s=n(596);n.d(e,"a",function(){return s.a})},596:function(t,e,n){"use strict";var s=n(1);n.d(e,"a",function(){return a});var a=function(){function t(){}return t}();a=__decorate([n.i(s._4)({selector:"no-content",template:"\n    <div>\n      <h1>404: page missing</h1>\n    </div>\n  "})],a)},742:function(t,e,n){e=t.exports=n(88)(),e.push([t.i,"body,html{height:100%;font-family:Arial,Helvetica,sans-serif}a.active{background-color:gray}",""])},743:function(t,e,n){e=t.exports=n(88)(),e.push([t.i,"",""])},744:function(t,e,n){e=t.exports=n(88)(),e.push([t.i,"",""])},745:function(t,e,n){e=t.exports=n(88)(),e.push([t.i,".box{flex-flow:column}.box,.boxv{display:flex;height:100%}.boxv{flex-flow:row;padding-left:7px;padding-right:7px;padding-bottom:7px}.box .row.header{flex:0 1 auto}.box .row.content{flex:1 1 auto;overflow-y:auto}.box .row.footer{flex:0 1 40px}",""])},746:function(t,e,n){e=t.exports=n(88)(),e.push([t.i,"",""])},747:function(t,e,n){e=t.exports=n(88)(),e.push([t.i,".box{flex-flow:column}.box,.boxv{display:flex;height:100%}.boxv{flex-flow:row}.box .row{border:1px dotted grey}.box .row.header{flex:0 1 auto}.box .row.content{flex:1 1 auto;overflow-y:scroll}.box .row.footer{flex:0 1 40px}",""])},748:function(t,e,n){e=t.exports=n(88)(),e.push([t.i,".box{flex-flow:column}.box,.boxv{display:flex;height:100%}.boxv{flex-flow:row;padding-left:7px;padding-right:7px;padding-bottom:7px}.box .row.header{flex:0 1 auto}.box .row.content{flex:1 1 auto;overflow-y:auto}.box .row.footer{flex:0 1 40px}",""])},749:function(t,e){t.exports='<div class="searchResultsItem col-xs-12 col-sm-6 col-md-6 col-lg-4 col-xl-4"> \n    <div class="cardFrame card text-xs-center">\n        <div class="card-header text-xs-center">\n            <span class="searchResultsItemTitle"><strong>{{card.name}}</strong></span>\n            <img src="/api/assets/{{card.expansion.idAsset}}" style="height: 18px; vertical-align: middle;" />\n        </div>\n        <img id="{{componentId}}" class="card-img searchResultsDeckImg" src="/api/assets/{{card.idAsset}}" \n             [class.show50]="card.deckCard.quantity <= 0" />\n        <img class="card-img" ngShow="false" ngSrc="/web/app/assets/images/magic_card.jpg" />\n        <div id="{{\'container\' + cardComponentId}}" class="actionOverlay card-img-overlay">\n            <div class="cardDetails center-block">\n                <div class="cardQtdLabel" \n                    [class.text-danger]="card.inventoryCard.quantity <= 0" \n                    [class.text-success]="card.deckCard.quantity > 0 && card.inventoryCard.quantity >= card.deckCard.quantity"\n                    [class.text-warning]="card.inventoryCard.quantity > 0 && card.inventoryCard.quantity < card.deckCard.quantity">\n                    {{getComponentLabel()}}\n                </div>\n                <div class="row-fluid actionPanel hide" (mouseout)="hidePop()">\n                    <button class="col-xs-4 action btn btn-secondary" (click)="decrease()" *ngIf="decreaseOn">\n                        <i class="fa fa-minus-circle"></i>\n                    </button>\n                    <button class="col-xs-4 action btn btn-secondary" (click)="increase()" *ngIf="increaseOn">\n                        <i class="fa fa-plus-circle"></i>\n                    </button>\n                    <button class="col-xs-4 action btn btn-secondary" (click)="remove()" *ngIf="removeOn">\n                        <i class="fa fa-times-circle"></i>\n                    </button>\n                    <button class="col-xs-4 action btn btn-secondary" (click)="changeBoard()" *ngIf="changeBoardOn && card?.deckCard?.idBoard > 0">\n                        <i class="fa fa-level-down" *ngIf="card.deckCard.idBoard == 1"></i>\n                        <i class="fa fa-level-up" *ngIf="card.deckCard.idBoard == 2"></i>\n                    </button>\n                    <button class="col-xs-4 action btn btn-secondary" (click)="showPop()" *ngIf="showPopOn">\n                        <i class="fa fa-eye"></i>\n                    </button>\n                    <button class="col-xs-4 action btn btn-secondary" (click)="shopping()" *ngIf="shoppingOn">\n                        <i class="fa fa-cart-plus"></i>\n                    </button>\n                </div>\n            </div>\n        </div>\n    </div>\n</div>'},750:function(t,e){t.exports='<div class="col-xs-12" style="padding-left: 0px;" (keyup.enter)="search()">\n\t<div class="row-fluid clearfix">\n\t\t<div class="parameterInputTop col-xs-4">\n\t\t\t<div class="input-group input-group-sm">\n\t\t\t\t<input #filterIndex class="form-control" placeholder="Card Set #" type="text" [(ngModel)]="parameter.index" (keyup.enter)="search()"\n\t\t\t\t/>\n\t\t\t</div>\n\t\t</div>\n\t\t<div class="parameterInputTop col-xs-8">\n\t\t\t<div class="input-group input-group-sm">\n\t\t\t\t<div class="input-group-btn">\n\t\t\t\t\t<button id="setSelectBtn" type="button" class="expansionFrame btn input-group-addon dropdown-toggle" style="width: 100%;"\n\t\t\t\t\t\tdata-toggle="dropdown" aria-haspopup="true" aria-expanded="false">\n                                <!--<span [hidden]="parameter.expansion != undefined">Set</span>-->\n                                <span>Set</span>\n                            </button>\n\t\t\t\t\t<div class="expansionDropDown dropdown-menu" aria-labelledby="setSelectBtn" (keyup.enter)="search()">\n\t\t\t\t\t\t<a class="pointerCursor dropdown-item" (click)=addExpansionParameter(null)>\n\t\t\t\t\t\t\t<i class="fa fa-close text-muted expansionImageRemoveSelect"></i>\n\t\t\t\t\t\t\t<span class="expansionNameSelect"><strong>Remove Expansion</strong></span>\n\t\t\t\t\t\t</a>\n\t\t\t\t\t\t<a class="pointerCursor dropdown-item" (click)=addExpansionParameter(expansion) *ngFor="let expansion of model.expansions">\n\t\t\t\t\t\t\t<img class="expansionImageSelect" src="/api/assets/{{expansion.idAsset}}">\n\t\t\t\t\t\t\t<span class="expansionNameSelect"><strong>{{expansion.name}}</strong></span>\n\t\t\t\t\t\t</a>\n\t\t\t\t\t</div>\n\t\t\t\t</div>\n\t\t\t\t<div class="parameterExpansion form-control" *ngIf="parameter.expansion != undefined">\n\t\t\t\t\t<img class="expansionImage" src="/api/assets/{{parameter.expansion.idAsset}}">\n\t\t\t\t\t<span class="expansionName"><strong>{{parameter.expansion.name}}</strong></span>\n\t\t\t\t</div>\n\t\t\t</div>\n\t\t</div>\n\t\t<div class="parameterInput col-xs-4">\n\t\t\t<div class=" input-group input-group-sm">\n\t\t\t\t<span class="input-group-addon">\n\t\t\t\t\t<input type="checkbox" data-toggle="tooltip" data-placement="bottom" title="Check to negate the type filter">\n\t\t\t\t</span>\n\t\t\t\t<input type="text" class="form-control" placeholder="Type Rx" [(ngModel)]="parameter.type" (keyup.enter)="search()">\n\t\t\t</div>\n\t\t</div>\n\t\t<div class="parameterInput col-xs-4">\n\t\t\t<div class="input-group input-group-sm">\n\t\t\t\t<span class="input-group-addon">\n\t\t\t\t\t<input type="checkbox" data-toggle="tooltip" data-placement="bottom" title="Check to negate the cost filter">\n\t\t\t\t</span>\n\t\t\t\t<input type="text" class="form-control" placeholder="Cost Rx" [(ngModel)]="parameter.cost" (keyup.enter)="search()">\n\t\t\t</div>\n\t\t</div>\n\t\t<div class="parameterInput col-xs-4">\n\t\t\t<div class="input-group input-group-sm">\n\t\t\t\t<span class="input-group-addon">\n\t\t\t\t\t<input type="checkbox" data-toggle="tooltip" data-placement="bottom" title="Check to negate the text filter">\n\t\t\t\t</span>\n\t\t\t\t<input type="text" class="form-control" placeholder="Text Rx" [(ngModel)]="parameter.text" (keyup.enter)="search()">\n\t\t\t</div>\n\t\t</div>\n\t\t<div class="parameterInput col-xs-7">\n\t\t\t<div class="input-group input-group-sm">\n\t\t\t\t<input class="form-control" placeholder="Card Name" type="text" [(ngModel)]="parameter.name" (keyup.enter)="search()" />\n\t\t\t</div>\n\t\t</div>\n\t\t<div class="parameterInput col-xs-3">\n\t\t\t<div class="input-group input-group-sm">\n\t\t\t\t<input class="form-control" placeholder="QTD" type="number" min="0" [(ngModel)]="parameter.stockQuantity" (keyup.enter)="search()" />\n\t\t\t</div>\n\t\t</div>\n\t\t<div class="col-xs-2 parameterInput">\n\t\t\t<div class="btn-group pull-right">\n\t\t\t\t<button type="button" class="imageBtn btn btn-secondary" [disabled]="filterIsInValid()" (click)="search()">\n\t\t\t\t\t<i class="fa fa-search" *ngIf="!model.querying"></i>\n\t\t\t\t\t<i class="fa fa-circle-o-notch fa-spin" *ngIf="model.querying"></i>\n\t\t\t\t</button>\n\t\t\t\t<button type="button" [disabled]="filterIsInValid()" class="btn btn-secondary btn-sm dropdown-toggle" data-toggle="dropdown"\n\t\t\t\t\taria-haspopup="true" aria-expanded="false">\n                            <span class="sr-only">Toggle Dropdown</span>\n\t\t\t\t</button>\n\t\t\t\t<div class="dropdown-menu">\n\t\t\t\t\t<a class="dropdown-item" (click)="searchOnNewPage()">On New Page</a>\n\t\t\t\t\t<div class="dropdown-divider"></div>\n\t\t\t\t\t<a class="dropdown-item" (click)="closeSearchResults()">Close Page</a>\n\t\t\t\t</div>\n\t\t\t</div>\n\t\t</div>\n\t</div>\n</div>\n<div class="searchResultsHead btn-group" role="group" style="margin-top: 5px; margin-bottom: 5px;"\n\t\t*ngIf="model.searchResults != undefined">\n\t<button type="button" class="btn btn-sm searchResultsTitle"\n\t\t*ngFor="let searchResultsItem of model.searchResultsList; let k = index"\n\t\t(click)="selectResult(searchResultsItem, k)" \n\t\t[class.active]="isCurrentSearchResults(k)"\n\t\t[class.btn-outline-secondary]="searchResultsItem.length <= 0"\n\t\t[class.btn-outline-success]="searchResultsItem.length > 0">\n\t\t<span class="badge">{{searchResultsItem.length}}</span>\n\t\t<a data-toggle="tooltip" data-placement="bottom" title="Click to view query filters">\n\t\t\t<span class="tag tag-pill tag-default">?</span>\n\t\t</a>\n\t</button>\n</div>\n<div *ngIf="showResults" style="margin-top: 10px; padding-left: 0px; background-color: #ff0000; height: 100px;" class="col-xs-12">\n</div>'},751:function(t,e){t.exports='<div class="container-fluid boxv">\n    <div class="col-xs-6 box">\n        <div class="row header">\n            <card-finder [showResults]="false" (onResult)="logFindResult($event)" (onNewPageResult)="logFindNewPageResult($event)">\n            </card-finder>\n        </div>\n        <div class="row content">\n            <div class="searchResultsBody col-xs-12" style="padding: 0px;">\n                <card *ngFor="let searchItem of model.searchResults" \n                      [label]="getCardQuantity(searchItem)"\n                      [increaseOn]="true" [decreaseOn]="true"\n                      (onDecrease)="removeCard($event)" (onIncrease)="addCard($event)" \n                      [card]="searchItem">\n                </card>\n            </div>\n        </div>\n    </div>\n    <div class="col-xs-6 box">\n        <div class="row header">\n            <div class="col-xs-2" style="padding: 0px;">\n                <div class="idDeckInput input-group input-group-sm">\n                    <input #idDeck ngControl="idDeck" name="idDeck" [(ngModel)]="deck.id" class="form-control" placeholder="#" type="text" />\n                </div>\n            </div>\n            <form #postDeck="ngForm" (ngSubmit)="update()">\n                <div class="col-xs-7" style="padding: 0px; ">\n                    <div class="nameInput input-group input-group-sm">\n                        <div class="input-group-btn">\n                            <button #deckSearck id="deckSearck" type="button" class="btn input-group-addon dropdown-toggle" style="width: 100%;" \n                                    data-toggle="dropdown" aria-haspopup="true" aria-expanded="false" (click)="list(\'\')">\n                            </button>\n                            <div #deckIntellisense id="deckIntellisense" class="deckIntellisense dropdown-menu" aria-labelledby="deckSearck">\n                                <h6 class="dropdown-header">Deck Name ~ {{model.deckSearchRx}}</h6>\n                                <!--<a class="pointerCursor dropdown-item" (click)=closeDeck()>\n                                    <i class="fa fa-close text-muted expansionImageRemoveSelect"></i>\n                                    <span class="expansionNameSelect"><strong>Close Deck</strong></span>\n                                </a>-->\n                                <a class="pointerCursor dropdown-item" (click)=selectDeck(deck) *ngFor="let deck of model.decks">\n                                    <span class="deckIntellisenseSelect"><strong>{{deck.id}} - {{deck.name}}</strong></span>\n                                </a>\n                            </div>\n                        </div>\n                        <input #deckName required (ngControl)="deckName" name="deckName" [(ngModel)]="deck.name" (keydown)="analyseDeckName($event)" \n                               class="form-control" placeholder="Deck Name" type="text" />\n                    </div>\n                </div>\n                <div class="col-xs-3 actionColumn parameterInputTop">\n                    <button type="button" (click)="find()" [disabled]="(deck?.id == undefined || deck?.id == \'\') && (deck?.name == undefined || deck?.name == \'\')" class="imageBtn btn btn-secondary">\n                        <i class="fa fa-folder-open" *ngIf="!model.finding"></i>\n                        <i class="fa fa-circle-o-notch fa-spin" *ngIf="model.finding"></i>\n                    </button>\n                    <div class="updateDeckBtn btn-group">\n                        <button type="submit" [disabled]="!postDeck.form.valid" class="imageBtn btn btn-secondary">\n                            <i class="fa fa-play-circle-o" *ngIf="!model.updating"></i>\n                            <i class="fa fa-circle-o-notch fa-spin" *ngIf="model.updating"></i>\n                        </button>\n                        <button type="button" [disabled]="deck?.id == undefined || deck?.id == \'\'" class="btn btn-secondary btn-sm dropdown-toggle" \n                            data-toggle="dropdown" aria-haspopup="true" aria-expanded="false">\n                                <span class="sr-only">Toggle Dropdown</span>\n                        </button>\n                        <div class="dropdown-menu dropdown-menu-right">\n                            <a class="dropdown-item" (click)="copyDeck()">Copy as New</a>\n                            <a class="dropdown-item" (click)="deleteDeck()">Delete</a>\n                            <div class="dropdown-divider"></div>\n                            <a class="dropdown-item" (click)="closeDeck()">Close</a>\n                        </div>\n                    </div>\n                </div>\n            </form>\n            <div class="searchResultsHead btn-group" role="group" style="margin-top: 5px; margin-bottom: 5px;">\n                <button type="button" [disabled]="deck?.cards.length <= 0" class="btn btn-outline-primary" style="pading: 5px; font-size: 12px;" [class.active]="model.selectedBoard == 1"\n                    *ngIf="deck.cards != undefined" (click)="model.selectedBoard = 1">\n                    Main <span class="badge">{{calculateMainCardsSize() + "/" + (deck.cards | filter:"deckCard.idBoard":1).length}}</span>\n                </button>\n                <button type="button" [disabled]="deck?.cards.length <= 0" class="btn btn-outline-info" style="pading: 5px; font-size: 12px;" [class.active]="model.selectedBoard == 2"\n                    *ngIf="deck.cards != undefined" (click)="model.selectedBoard = 2">\n                    Side <span class="badge">{{calculateSideCardsSize() + "/" + (deck.cards | filter:"deckCard.idBoard":2).length}}</span>\n                </button>\n                <button type="button" [disabled]="deck?.cards.length <= 0" class="btn btn-outline-warning" style="pading: 5px; font-size: 12px;" [class.active]="model.selectedBoard == 3" \n                    *ngIf="deck.cards != undefined" (click)="generateDeckStatistics()">\n                    <i class="fa fa-line-chart"></i>\n                </button>\n            </div>\n        </div>\n        <div class="row content">\n            <div class="searchResultsBodyDeck col-xs-12" style="padding: 0px;" *ngIf="model.selectedBoard == 1 || model.selectedBoard == 2">\n                <card *ngFor="let deckItem of deck.cards | filter:\'deckCard.idBoard\':model.selectedBoard" \n                      [decreaseOn]="true" [increaseOn]="true" [removeOn]="true" [changeBoardOn]="true"\n                      (onDecrease)="removeCard($event)" (onIncrease)="addCard($event)" \n                      (onRemove)="removeDeckItem($event)" (onChangeBoard)="changeBoard($event)"\n                      [card]="deckItem">\n                </card>\n            </div>\n            <div class="deckStatsPanel col-xs-12" [style.display]="model.selectedBoard == 3 ? \'block\' : \'none\'">\n                <h4>Converted Mana Cost</h4>\n                <div id="lineDeckChart" class="line-stats-chart ct-chart ct-perfect-fourth"></div>\n                <h4>Card By Type</h4>\n                <div id="barDeckChart" class="bar-stats-chart ct-chart ct-perfect-fourth"></div>\n                <h4>Card By Type %</h4>\n                <div id="pieDeckChart" class="pie-stats-chart ct-chart ct-perfect-fourth"></div>\n            </div>\n        </div>\n    </div>\n</div>'},752:function(t,e){t.exports='<nav class="navbar navbar-fixed-top navbar-dark bg-inverse">\n    <button class="navbar-toggler hidden-sm-up" type="button" data-toggle="collapse" data-target="#exCollapsingNavbar2">\n        &#9776;\n    </button>\n    <div class="collapse navbar-toggleable-xs" id="exCollapsingNavbar2">\n        <a class="navbar-brand" href="#">\n            <img class="card-img" src="/assets/img/magic_5_symbols_2.png" alt="FiveColors" data-toggle="tooltip"\n            data-placement="bottom" title="FiveColors" style="height: 30px;" />\n        </a>\n        <div class="nav navbar-nav">\n            <a id="deckLink" class="nav-item nav-link" routerLinkActive="active" [routerLink]="[\'./deck\']">Deck </a>\n            <a id="inventoryLink" class="nav-item nav-link" routerLinkActive="active" [routerLink]="[\'./inventory\']">Inventory </a>\n            <a id="homeLink" class="nav-item nav-link" routerLinkActive="active" [routerLink]="[\'./home\']">Home </a>\n            <!--<form class="form-inline float-xs-right" method="get" action="/auth/logout/">-->\n            <form class="form-inline float-xs-right" method="get" action="#">\n                <div class="btn-group">\n                    <button type="button" class="btn btn-sm btn-success" type="submit">{{session.username}}</button>\n                    <button type="button" class="btn btn-sm btn-success dropdown-toggle" data-toggle="dropdown" aria-haspopup="true" aria-expanded="false">\n                        <span class="sr-only">Toggle Dropdown</span>\n                    </button>\n                    <div class="dropdown-menu" style="left: -50%;">\n                        <a class="dropdown-item">Preferences</a>\n                        <div class="dropdown-divider"></div>\n                        <a class="dropdown-item" href="#">Logout</a>\n                        <!--<a class="dropdown-item" href="/auth/logout/">Logout</a>-->\n                    </div>\n                </div>\n            </form>\n        </div>\n    </div>\n</nav>\n<router-outlet></router-outlet>'},753:function(t,e){t.exports='<div class="container-fluid boxv">\n  <div class="col-xs-6 box">\n    <div class="row header">\n      <p><b>header</b>\n        <br />\n        <br />(sized to content 1)\n        <br />(sized to content 2)\n        <br />(sized to content 3)\n        <br />(sized to content 5)\n      </p>\n    </div>\n    <div class="row content">\n      <p>\n        <b>content</b> (fills remaining space)\n      </p>\n      <img class="card-img searchResultsImg" src="/api/assets/10676" />\n      <img class="card-img searchResultsImg" src="/api/assets/10676" />\n      <img class="card-img searchResultsImg" src="/api/assets/10676" />\n      <img class="card-img searchResultsImg" src="/api/assets/10676" />\n      <img class="card-img searchResultsImg" src="/api/assets/10676" />\n      <img class="card-img searchResultsImg" src="/api/assets/10676" />\n      <img class="card-img searchResultsImg" src="/api/assets/10676" />\n      <img class="card-img searchResultsImg" src="/api/assets/10676" />\n      <img class="card-img searchResultsImg" src="/api/assets/10676" />\n      <img class="card-img searchResultsImg" src="/api/assets/10676" />\n      <img class="card-img searchResultsImg" src="/api/assets/10676" />\n      <img class="card-img searchResultsImg" src="/api/assets/10676" />\n      <img class="card-img searchResultsImg" src="/api/assets/10676" />\n      <img class="card-img searchResultsImg" src="/api/assets/10676" />\n      <img class="card-img searchResultsImg" src="/api/assets/10676" />\n      <img class="card-img searchResultsImg" src="/api/assets/10676" />\n      <img class="card-img searchResultsImg" src="/api/assets/10676" />\n      <img class="card-img searchResultsImg" src="/api/assets/10676" />\n      <img class="card-img searchResultsImg" src="/api/assets/10676" />\n      <img class="card-img searchResultsImg" src="/api/assets/10676" />\n      <img class="card-img searchResultsImg" src="/api/assets/10676" />\n      <img class="card-img searchResultsImg" src="/api/assets/10676" />\n    </div>\n    <div class="row footer">\n      <p><b>footer</b> (fixed height)</p>\n    </div>\n  </div>\n  <div class="col-xs-6 box">\n    <div class="row header">\n      <p><b>header</b>\n        <br />\n        <br />(sized to content)</p>\n    </div>\n    <div class="row content">\n      <p>\n        <b>content</b> (fills remaining space)\n      </p>\n      <img class="card-img searchResultsImg" src="/api/assets/1093" />\n      <img class="card-img searchResultsImg" src="/api/assets/1093" />\n      <img class="card-img searchResultsImg" src="/api/assets/1093" />\n      <img class="card-img searchResultsImg" src="/api/assets/1093" />\n      <img class="card-img searchResultsImg" src="/api/assets/1093" />\n      <img class="card-img searchResultsImg" src="/api/assets/1093" />\n      <img class="card-img searchResultsImg" src="/api/assets/1093" />\n      <img class="card-img searchResultsImg" src="/api/assets/1093" />\n      <img class="card-img searchResultsImg" src="/api/assets/1093" />\n      <img class="card-img searchResultsImg" src="/api/assets/1093" />\n      <img class="card-img searchResultsImg" src="/api/assets/1093" />\n      <img class="card-img searchResultsImg" src="/api/assets/1093" />\n      <img class="card-img searchResultsImg" src="/api/assets/1093" />\n      <img class="card-img searchResultsImg" src="/api/assets/1093" />\n      <img class="card-img searchResultsImg" src="/api/assets/1093" />\n      <img class="card-img searchResultsImg" src="/api/assets/1093" />\n      <img class="card-img searchResultsImg" src="/api/assets/1093" />\n      <img class="card-img searchResultsImg" src="/api/assets/1093" />\n      <img class="card-img searchResultsImg" src="/api/assets/1093" />\n      <img class="card-img searchResultsImg" src="/api/assets/1093" />\n      <img class="card-img searchResultsImg" src="/api/assets/1093" />\n      <img class="card-img searchResultsImg" src="/api/assets/1093" />\n    </div>\n    <div class="row footer">\n      <p><b>footer</b> (fixed height)</p>\n    </div>\n  </div>\n</div>'},754:function(t,e){t.exports='<div class="container-fluid boxv">\n    <div class="col-xs-12 box">\n        <div class="row header">\n            <div class="col-xs-12 col-sm-6 col-md-6 col-lg-6" style="padding-left: 0px;">\n                <card-finder [showResults]="false" (onResult)="logFindResult($event)" (onNewPageResult)="logFindNewPageResult($event)">\n                </card-finder>\n            </div>\n            <div class="col-xs-12 col-sm-6 col-md-6 col-lg-6" style="padding: 0px;">\n                <div class="row-fluid clearfix">\n                    <div class="parameterInputTop col-xs-12"> \n                        <div class="updateActionFrame">\n                            <div class="col-xs-6 col-sm-6 col-md-6 col-lg-4 updateItemFrame" *ngFor="let updateItem of model.updateQueue | values">\n                                <button class="btn btn-sm updateItemBtn" \n                                [class.btn-outline-primary]="compareUpdateItemQuantity(updateItem) > 0" \n                                [class.btn-outline-secondary]="compareUpdateItemQuantity(updateItem) == 0"\n                                [class.btn-outline-warning]="compareUpdateItemQuantity(updateItem) < 0">\n                                    <span class="updateItem">{{updateItem.card.name}}</span>\n                                    <div style="display: inline; vertical-align: middle;">\n                                        <span class="tag tag-pill" \n                                        [class.tag-success]="compareUpdateItemQuantity(updateItem) > 0" \n                                        [class.tag-default]="compareUpdateItemQuantity(updateItem) == 0"\n                                        [class.tag-danger]="compareUpdateItemQuantity(updateItem) < 0">\n                                            {{updateItem.quantity}}</span>\n                                        <i class="fa fa-close text-muted" (click)=removeUpdateItem(updateItem)></i>\n                                    </div>\n                                </button>\n                            </div>\n                        </div>\n                    </div>\n                    <div class="parameterInput col-xs-12">\n                        <div class="btn-group pull-left">\n                            <button type="button" class="imageBtn btn btn-secondary" \n                                    [disabled]="updateQueueIsInvalid()" (click)=update()>\n                                <i class="fa fa-play-circle-o" *ngIf="!model.updating"></i>\n                                <i class="fa fa-circle-o-notch fa-spin" *ngIf="model.updating"></i>\n                            </button>\n                            <button type="button" [disabled]="updateQueueIsInvalid()" \n                                    class="btn btn-secondary btn-sm dropdown-toggle" data-toggle="dropdown" aria-haspopup="true" aria-expanded="false">\n                                <span class="sr-only">Toggle Dropdown</span>\n                            </button>\n                            <div class="dropdown-menu">\n                                <a class="dropdown-item" (click)=cleanUpdateQueue()>Clear</a>\n                            </div>\n                        </div>\n                    </div>\n                </div>\n            </div>\n        </div>\n        <div class="row content">\n            <div class="searchResultsBody container-fluid">\n                <div class="searchResultsItem col-xs-12 col-sm-6 col-md-4 col-lg-3 col-xl-3" *ngFor="let searchItem of model.searchResults">\n                    <div class="cardFrame card text-xs-center">\n                        <div class="card-header text-xs-center">\n                            <span class="searchResultsItemTitle"><strong>{{searchItem.name}}</strong></span>\n                            <img src="/api/assets/{{searchItem.expansion.idAsset}}" style="height: 18px; vertical-align: middle;" />\n                        </div>\n                        <img class="card-img searchResultsImg" src="/api/assets/{{searchItem.idAsset}}" \n                            [class.show50]="createCardQuantityLabel(searchItem) <= 0" />\n                        <img class="card-img" ngShow="false" ngSrc="/web/app/assets/images/magic_card.jpg" />\n                        <div class="actionOverlay card-img-overlay">\n                            <div class="cardDetails center-block">\n                                <div class="cardQtdLabel" [class.text-success]="compareCardQuantity(searchItem) > 0" [class.text-warning]="compareCardQuantity(searchItem) < 0"\n                                [class.show]="createCardQuantityLabel(searchItem) > 0" [class.hide]="createCardQuantityLabel(searchItem) <= 0">\n                                    {{createCardQuantityLabel(searchItem)}}</div>\n                                <div class="actionPanel hide">\n                                    <button class="action btn btn-secondary" (click)=showCard(searchItem)>\n                                        <i class="fa fa-eye fa-2x"></i>\n                                    </button>\n                                    <button class="action btn btn-secondary" (click)=removeCard(searchItem)>\n                                        <i class="fa fa-minus-circle fa-2x"></i>\n                                    </button>\n                                    <button class="action btn btn-secondary" (click)=addCard(searchItem)>\n                                        <i class="fa fa-plus-circle fa-2x"></i>\n                                    </button>\n                                </div>\n                            </div>\n                        </div>\n                    </div>\n                </div>\n            </div>\n        </div>\n    </div>\n</div>\n';
 },80:function(t,e,n){"use strict";var s=n(1);n.d(e,"a",function(){return a});var a=function(){function t(){this.host="",this.identity=this.host+"/identity",this.sessions=this.identity+"/sessions/",this.api=this.host+"/api",this.players=this.api+"/players/",this.cards=this.api+"/cards/",this.expansions=this.api+"/expansions/",this.inventories=this.api+"/inventories/",this.decks=this.api+"/decks/"}return t}();a=__decorate([n.i(s.p)()],a)},97:function(t,e,n){"use strict";function s(){function t(){return Math.floor(65536*(1+Math.random())).toString(16).substring(1)}return t()+t()+"-"+t()+"-"+t()+"-"+t()+"-"+t()+t()+t()}var a=n(80),i=n(175),r=n(176),o=n(250),c=n(380),d=n(379);n.d(e,"d",function(){return i.b}),n.d(e,"f",function(){return o.a}),n.d(e,"c",function(){return c.a}),n.d(e,"e",function(){return d.a}),n.d(e,"a",function(){return l}),e.b=s;var l=[a.a,i.a,r.a,o.a,c.a,d.a]}},[1026]);
\ No newline at end of file